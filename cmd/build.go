@@ -16,6 +16,7 @@ import (
 	"github.com/goreleaser/goreleaser/internal/middleware/errhandler"
 	"github.com/goreleaser/goreleaser/internal/middleware/logging"
 	"github.com/goreleaser/goreleaser/internal/middleware/skip"
+	"github.com/goreleaser/goreleaser/internal/middleware/summary"
 	"github.com/goreleaser/goreleaser/internal/pipeline"
 	"github.com/goreleaser/goreleaser/internal/skips"
 	"github.com/goreleaser/goreleaser/pkg/config"
@@ -144,14 +145,15 @@ func buildProject(options buildOpts) (*context.Context, error) {
 		return nil, err
 	}
 	return ctx, ctrlc.Default.Run(ctx, func() error {
+		defer summary.Print(ctx)
 		for _, pipe := range setupPipeline(ctx, options) {
-			if err := skip.Maybe(
+			if err := summary.Wrap(pipe.String(), skip.Maybe(
 				pipe,
 				logging.Log(
 					pipe.String(),
-					errhandler.Handle(pipe.Run),
+					errhandler.Handle(summary.Track(pipe.Run)),
 				),
-			)(ctx); err != nil {
+			))(ctx); err != nil {
 				return err
 			}
 		}
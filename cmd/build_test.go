@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"runtime"
 	"testing"
 
 	"github.com/goreleaser/goreleaser/internal/pipeline"
@@ -177,6 +178,14 @@ func TestBuildFlags(t *testing.T) {
 		}).Parallelism)
 	})
 
+	t.Run("parallelism defaults to the number of cpus", func(t *testing.T) {
+		for _, parallelism := range []int{0, -1} {
+			require.Equal(t, runtime.GOMAXPROCS(0), setup(buildOpts{
+				parallelism: parallelism,
+			}).Parallelism)
+		}
+	})
+
 	t.Run("rm dist", func(t *testing.T) {
 		require.True(t, setup(buildOpts{
 			clean: true,
@@ -8,10 +8,13 @@ import (
 	"github.com/caarlos0/ctrlc"
 	"github.com/caarlos0/log"
 	"github.com/goreleaser/goreleaser/internal/deprecate"
+	"github.com/goreleaser/goreleaser/internal/http"
 	"github.com/goreleaser/goreleaser/internal/logext"
 	"github.com/goreleaser/goreleaser/internal/middleware/errhandler"
 	"github.com/goreleaser/goreleaser/internal/middleware/logging"
 	"github.com/goreleaser/goreleaser/internal/middleware/skip"
+	"github.com/goreleaser/goreleaser/internal/middleware/summary"
+	"github.com/goreleaser/goreleaser/internal/pipe/after"
 	"github.com/goreleaser/goreleaser/internal/pipe/git"
 	"github.com/goreleaser/goreleaser/internal/pipeline"
 	"github.com/goreleaser/goreleaser/internal/skips"
@@ -36,6 +39,7 @@ type releaseOpts struct {
 	snapshot          bool
 	draft             bool
 	failFast          bool
+	printPlan         bool
 	clean             bool
 	deprecated        bool
 	parallelism       int
@@ -101,6 +105,7 @@ func newReleaseCmd() *releaseCmd {
 	cmd.Flags().BoolVar(&root.opts.snapshot, "snapshot", false, "Generate an unversioned snapshot release, skipping all validations and without publishing any artifacts (implies --skip=announce,publish,validate)")
 	cmd.Flags().BoolVar(&root.opts.draft, "draft", false, "Whether to set the release to draft. Overrides release.draft in the configuration file")
 	cmd.Flags().BoolVar(&root.opts.failFast, "fail-fast", false, "Whether to abort the release publishing on the first error")
+	cmd.Flags().BoolVar(&root.opts.printPlan, "print-plan", false, "Write where each artifact would be uploaded to the 'dist' directory instead of uploading them to artifactory and upload targets, skipping every other publisher (implies --skip=announce)")
 	cmd.Flags().BoolVar(&root.opts.skipPublish, "skip-publish", false, "Skips publishing artifacts (implies --skip=announce)")
 	cmd.Flags().BoolVar(&root.opts.skipAnnounce, "skip-announce", false, "Skips announcing releases (implies --skip=validate)")
 	cmd.Flags().BoolVar(&root.opts.skipSign, "skip-sign", false, "Skips signing artifacts")
@@ -156,20 +161,47 @@ func releaseProject(options releaseOpts) (*context.Context, error) {
 	if err := setupReleaseContext(ctx, options); err != nil {
 		return nil, err
 	}
-	return ctx, ctrlc.Default.Run(ctx, func() error {
+	err = ctrlc.Default.Run(ctx, func() error {
+		defer summary.Print(ctx)
 		for _, pipe := range pipeline.Pipeline {
-			if err := skip.Maybe(
+			if err := summary.Wrap(pipe.String(), skip.Maybe(
 				pipe,
 				logging.Log(
 					pipe.String(),
-					errhandler.Handle(pipe.Run),
+					errhandler.Handle(summary.Track(pipe.Run)),
 				),
-			)(ctx); err != nil {
+			))(ctx); err != nil {
+				if _, ok := pipe.(after.Pipe); !ok && ctx.Config.After.Always {
+					runAfterHooks(ctx)
+				}
 				return err
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		// The pipeline might still be running: cancel it and let the
+		// critical uploads finish before exiting.
+		cancel()
+		http.WaitCritical(ctx)
+	}
+	return ctx, err
+}
+
+// runAfterHooks runs the after hooks when the release failed but the user
+// asked for them to always run.
+// Errors are only logged, so the original failure is the one reported.
+func runAfterHooks(ctx *context.Context) {
+	pipe := after.Pipe{}
+	if err := summary.Wrap(pipe.String(), skip.Maybe(
+		pipe,
+		logging.Log(
+			pipe.String(),
+			errhandler.Handle(summary.Track(pipe.Run)),
+		),
+	))(ctx); err != nil {
+		log.WithError(err).Error("after hooks failed")
+	}
 }
 
 func setupReleaseContext(ctx *context.Context, options releaseOpts) error {
@@ -188,6 +220,11 @@ func setupReleaseContext(ctx *context.Context, options releaseOpts) error {
 	ctx.ReleaseFooterTmpl = options.releaseFooterTmpl
 	ctx.Snapshot = options.snapshot
 	ctx.FailFast = options.failFast
+	ctx.PrintUploadPlan = options.printPlan
+	if options.printPlan {
+		// only the artifactory and upload publishers run, to write their plans
+		skips.Set(ctx, skips.Announce)
+	}
 	ctx.Clean = options.clean || options.rmDist
 	if options.autoSnapshot && git.CheckDirty(ctx) != nil {
 		log.Info("git repository is dirty and --auto-snapshot is set, implying --snapshot")
@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	stdctx "context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/internal/skips"
 	"github.com/goreleaser/goreleaser/internal/testctx"
@@ -54,6 +62,100 @@ func TestReleaseBrokenProject(t *testing.T) {
 	require.EqualError(t, cmd.cmd.Execute(), "failed to parse dir: .: main.go:1:1: expected 'package', found not")
 }
 
+func TestReleaseHooks(t *testing.T) {
+	const yaml = `build:
+  binary: fake
+  goos:
+    - linux
+  goarch:
+    - amd64
+before:
+  hooks:
+    - cp main.go.good main.go
+after:
+  always: %v
+  hooks:
+    - touch after.txt
+`
+	args := []string{"--snapshot", "--timeout=1m", "--parallelism=2"}
+
+	t.Run("success", func(t *testing.T) {
+		setup(t)
+		createFile(t, "goreleaser.yml", fmt.Sprintf(yaml, false))
+		createFile(t, "main.go.good", "package main\nfunc main() {println(0)}")
+		createFile(t, "main.go", "not a valid go file")
+		cmd := newReleaseCmd()
+		cmd.cmd.SetArgs(args)
+		require.NoError(t, cmd.cmd.Execute(), "before hooks should run before the build")
+		require.FileExists(t, "after.txt")
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		setup(t)
+		createFile(t, "goreleaser.yml", fmt.Sprintf(yaml, false))
+		createFile(t, "main.go.good", "not a valid go file either")
+		cmd := newReleaseCmd()
+		cmd.cmd.SetArgs(args)
+		require.Error(t, cmd.cmd.Execute())
+		require.NoFileExists(t, "after.txt")
+	})
+
+	t.Run("failure always", func(t *testing.T) {
+		setup(t)
+		createFile(t, "goreleaser.yml", fmt.Sprintf(yaml, true))
+		createFile(t, "main.go.good", "not a valid go file either")
+		cmd := newReleaseCmd()
+		cmd.cmd.SetArgs(args)
+		require.Error(t, cmd.cmd.Execute())
+		require.FileExists(t, "after.txt")
+	})
+}
+
+func TestReleaseWaitsForCriticalUploads(t *testing.T) {
+	const timeout = 5 * time.Second
+	var m sync.Mutex
+	var uploaded []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		// still uploading once the release timed out
+		time.Sleep(timeout)
+		m.Lock()
+		uploaded = append(uploaded, r.URL.Path)
+		m.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	setup(t)
+	createFile(t, "goreleaser.yml", fmt.Sprintf(`build:
+  binary: fake
+  goos:
+    - linux
+  goarch:
+    - amd64
+release:
+  disable: true
+artifactories:
+  - name: test
+    target: %s/
+    username: u
+    critical:
+      - "*.tar.gz"
+`, srv.URL))
+	t.Setenv("GITHUB_TOKEN", "fake")
+	t.Setenv("ARTIFACTORY_TEST_SECRET", "x")
+
+	_, err := releaseProject(releaseOpts{
+		config:  "goreleaser.yml",
+		timeout: timeout,
+		skips:   []string{string(skips.Validate), string(skips.Announce)},
+	})
+	require.ErrorIs(t, err, stdctx.DeadlineExceeded)
+	m.Lock()
+	defer m.Unlock()
+	require.Equal(t, []string{"/foo_0.0.2_linux_amd64.tar.gz"}, uploaded)
+}
+
 func TestReleaseFlags(t *testing.T) {
 	setup := func(tb testing.TB, opts releaseOpts) *context.Context {
 		tb.Helper()
@@ -97,12 +199,28 @@ func TestReleaseFlags(t *testing.T) {
 		requireAll(t, ctx, skips.Sign, skips.Publish, skips.Validate, skips.Announce)
 	})
 
+	t.Run("print plan", func(t *testing.T) {
+		ctx := setup(t, releaseOpts{
+			printPlan: true,
+		})
+		require.True(t, ctx.PrintUploadPlan)
+		requireAll(t, ctx, skips.Announce)
+	})
+
 	t.Run("parallelism", func(t *testing.T) {
 		require.Equal(t, 1, setup(t, releaseOpts{
 			parallelism: 1,
 		}).Parallelism)
 	})
 
+	t.Run("parallelism defaults to the number of cpus", func(t *testing.T) {
+		for _, parallelism := range []int{0, -1} {
+			require.Equal(t, runtime.GOMAXPROCS(0), setup(t, releaseOpts{
+				parallelism: parallelism,
+			}).Parallelism)
+		}
+	})
+
 	t.Run("notes", func(t *testing.T) {
 		notes := "foo.md"
 		header := "header.md"
@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 
 	"github.com/goreleaser/goreleaser/pkg/config"
-	"github.com/invopop/jsonschema"
 	"github.com/spf13/cobra"
 )
 
@@ -27,10 +26,7 @@ func newSchemaCmd() *schemaCmd {
 		Args:              cobra.NoArgs,
 		ValidArgsFunction: cobra.NoFileCompletions,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			schema := jsonschema.Reflect(&config.Project{})
-			schema.Definitions["FileInfo"] = jsonschema.Reflect(&config.FileInfo{})
-			schema.Description = "goreleaser configuration definition file"
-			bts, err := json.MarshalIndent(schema, "	", "	")
+			bts, err := json.MarshalIndent(config.JSONSchema(), "	", "	")
 			if err != nil {
 				return fmt.Errorf("failed to create jsonschema: %w", err)
 			}
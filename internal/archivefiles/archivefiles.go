@@ -16,10 +16,28 @@ import (
 	"github.com/goreleaser/goreleaser/pkg/config"
 )
 
+// ErrNoMatch happens when a non-default file glob doesn't match any file.
+var ErrNoMatch = errors.New("no files matched")
+
 // Eval evaluates the given list of files to their final form.
+//
+// Globs which do not match any file fail with ErrNoMatch, unless they are
+// defaults, which are usually very generic.
+// Files which if condition evaluates to anything but true are ignored.
 func Eval(template *tmpl.Template, files []config.File) ([]config.File, error) {
 	var result []config.File
 	for _, f := range files {
+		if f.If != "" {
+			ok, err := template.Bool(f.If)
+			if err != nil {
+				return result, fmt.Errorf("failed to apply template %s: %w", f.If, err)
+			}
+			if !ok {
+				log.WithField("src", f.Source).Debug("if condition not met, skipping")
+				continue
+			}
+		}
+
 		glob, err := template.Apply(f.Source)
 		if err != nil {
 			return result, fmt.Errorf("failed to apply template %s: %w", f.Source, err)
@@ -32,10 +50,9 @@ func Eval(template *tmpl.Template, files []config.File) ([]config.File, error) {
 
 		if len(files) == 0 {
 			if !f.Default {
-				// only log if its not a default glob, as those are usually
-				// very generic and are not really warnings for the user.
-				log.WithField("glob", f.Source).Warn("no files matched")
+				return result, fmt.Errorf("%s: %w", f.Source, ErrNoMatch)
 			}
+			log.WithField("glob", f.Source).Debug("no files matched")
 			continue
 		}
 
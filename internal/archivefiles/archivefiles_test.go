@@ -48,13 +48,60 @@ func TestEval(t *testing.T) {
 	t.Run("templated src error", func(t *testing.T) {
 		_, err := Eval(tmpl, []config.File{
 			{
-				Source:      "./testdata/**/{{ .Env.NOPE }}.txt",
+				Source:      "./testdata/**/{{ .Nope }}.txt",
 				Destination: "var/foobar/d.txt",
 			},
 		})
 		testlib.RequireTemplateError(t, err)
 	})
 
+	t.Run("templated src missing env", func(t *testing.T) {
+		result, err := Eval(tmpl, []config.File{
+			{
+				Source:      "./testdata/**/d{{ .Env.NOPE }}.txt",
+				Destination: "var/foobar/",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []config.File{
+			{
+				Source:      "testdata/a/b/c/d.txt",
+				Destination: "var/foobar/d.txt",
+			},
+		}, result)
+	})
+
+	t.Run("if", func(t *testing.T) {
+		result, err := Eval(tmpl, []config.File{
+			{
+				Source:      "./testdata/**/d.txt",
+				Destination: "var/foobar/",
+				If:          `{{ eq .Env.FOLDER "d" }}`,
+			},
+			{
+				Source: "./testdata/**/nope.txt",
+				If:     `{{ eq .Env.FOLDER "nope" }}`,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []config.File{
+			{
+				Source:      "testdata/a/b/c/d.txt",
+				Destination: "var/foobar/d.txt",
+			},
+		}, result)
+	})
+
+	t.Run("if error", func(t *testing.T) {
+		_, err := Eval(tmpl, []config.File{
+			{
+				Source: "./testdata/**/d.txt",
+				If:     "{{ .Nope }}",
+			},
+		})
+		testlib.RequireTemplateError(t, err)
+	})
+
 	t.Run("templated info", func(t *testing.T) {
 		result, err := Eval(tmpl, []config.File{
 			{
@@ -89,7 +136,7 @@ func TestEval(t *testing.T) {
 				Source:      "./testdata/**/d.txt",
 				Destination: "var/foobar",
 				Info: config.FileInfo{
-					Owner: "{{ .Env.NOPE }}",
+					Owner: "{{ .Nope }}",
 				},
 			}})
 			testlib.RequireTemplateError(t, err)
@@ -99,7 +146,7 @@ func TestEval(t *testing.T) {
 				Source:      "./testdata/**/d.txt",
 				Destination: "var/foobar",
 				Info: config.FileInfo{
-					Group: "{{ .Env.NOPE }}",
+					Group: "{{ .Nope }}",
 				},
 			}})
 			testlib.RequireTemplateError(t, err)
@@ -109,7 +156,7 @@ func TestEval(t *testing.T) {
 				Source:      "./testdata/**/d.txt",
 				Destination: "var/foobar",
 				Info: config.FileInfo{
-					MTime: "{{ .Env.NOPE }}",
+					MTime: "{{ .Nope }}",
 				},
 			}})
 			testlib.RequireTemplateError(t, err)
@@ -169,15 +216,38 @@ func TestEval(t *testing.T) {
 	})
 
 	t.Run("rlcp no results", func(t *testing.T) {
-		result, err := Eval(tmpl, []config.File{{
+		_, err := Eval(tmpl, []config.File{{
 			Source:      "./testdata/abc/**/*",
 			Destination: "foo/bar",
 		}})
 
+		require.ErrorIs(t, err, ErrNoMatch)
+		require.ErrorContains(t, err, "./testdata/abc/**/*")
+	})
+
+	t.Run("default no results", func(t *testing.T) {
+		result, err := Eval(tmpl, []config.File{{
+			Source:  "./testdata/abc/**/*",
+			Default: true,
+		}})
+
 		require.NoError(t, err)
 		require.Empty(t, result)
 	})
 
+	t.Run("nested directories", func(t *testing.T) {
+		result, err := Eval(tmpl, []config.File{{
+			Source:      "./testdata/a/**/*",
+			Destination: "completions",
+		}})
+
+		require.NoError(t, err)
+		require.Equal(t, []config.File{
+			{Source: "testdata/a/b/a.txt", Destination: "completions/a.txt"},
+			{Source: "testdata/a/b/c/d.txt", Destination: "completions/c/d.txt"},
+		}, result)
+	})
+
 	t.Run("strip parent plays nicely with destination omitted", func(t *testing.T) {
 		result, err := Eval(tmpl, []config.File{{Source: "./testdata/a/b", StripParent: true}})
 
@@ -153,6 +153,7 @@ const (
 	ExtraFormat     = "Format"
 	ExtraWrappedIn  = "WrappedIn"
 	ExtraBinaries   = "Binaries"
+	ExtraBuilds     = "Builds"
 	ExtraRefresh    = "Refresh"
 	ExtraReplaces   = "Replaces"
 	ExtraDigest     = "Digest"
@@ -232,9 +233,24 @@ func ExtraOr[T any](a Artifact, key string, or T) T {
 	return a.Extra[key].(T)
 }
 
-// Checksum calculates the checksum of the artifact.
-// nolint: gosec
+// Checksum calculates the checksum of the artifact, and records it in its
+// extras.
 func (a Artifact) Checksum(algorithm string) (string, error) {
+	check, err := a.Hash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	if a.Extra == nil {
+		a.Extra = make(Extras)
+	}
+	a.Extra[ExtraChecksum] = fmt.Sprintf("%s:%s", algorithm, check)
+	return check, nil
+}
+
+// Hash calculates the checksum of the artifact without recording it, so it
+// is safe to call concurrently.
+// nolint: gosec
+func (a Artifact) Hash(algorithm string) (string, error) {
 	log.Debugf("calculating checksum for %s", a.Path)
 	file, err := os.Open(a.Path)
 	if err != nil {
@@ -264,12 +280,7 @@ func (a Artifact) Checksum(algorithm string) (string, error) {
 	if _, err := io.Copy(h, file); err != nil {
 		return "", fmt.Errorf("failed to checksum: %w", err)
 	}
-	check := hex.EncodeToString(h.Sum(nil))
-	if a.Extra == nil {
-		a.Extra = make(Extras)
-	}
-	a.Extra[ExtraChecksum] = fmt.Sprintf("%s:%s", algorithm, check)
-	return check, nil
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 var noRefresh = func() error { return nil }
@@ -350,6 +350,20 @@ func TestChecksum(t *testing.T) {
 	}
 }
 
+func TestHash(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "subject")
+	require.NoError(t, os.WriteFile(file, []byte("lorem ipsum"), 0o644))
+
+	artifact := Artifact{
+		Path:  file,
+		Extra: Extras{ExtraChecksum: "sha256:foo"},
+	}
+	sum, err := artifact.Hash("md5")
+	require.NoError(t, err)
+	require.Equal(t, "80a751fde577028640c419000e33eba6", sum)
+	require.Equal(t, "sha256:foo", artifact.Extra[ExtraChecksum])
+}
+
 func TestChecksumFileDoesntExist(t *testing.T) {
 	file := filepath.Join(t.TempDir(), "nope")
 	artifact := Artifact{
@@ -944,3 +958,58 @@ func TestArtifactTypeStringer(t *testing.T) {
 		require.Equal(t, "unknown", Type(99999).String())
 	})
 }
+
+func TestFilterChaining(t *testing.T) {
+	artifacts := New()
+	for _, a := range []*Artifact{
+		{Name: "bin-linux", Goos: "linux", Goarch: "amd64", Type: Binary, Extra: map[string]interface{}{ExtraID: "a"}},
+		{Name: "bin-darwin", Goos: "darwin", Goarch: "arm64", Type: Binary, Extra: map[string]interface{}{ExtraID: "a"}},
+		{Name: "other-linux", Goos: "linux", Goarch: "amd64", Type: Binary, Extra: map[string]interface{}{ExtraID: "b"}},
+		{Name: "archive-linux", Goos: "linux", Goarch: "amd64", Type: UploadableArchive, Extra: map[string]interface{}{ExtraID: "a"}},
+		{Name: "app.deb", Goos: "linux", Goarch: "amd64", Type: LinuxPackage, Extra: map[string]interface{}{ExtraID: "a"}},
+		{Name: "checksums.txt", Type: Checksum},
+		{Name: "checksums.txt.sig", Type: Signature},
+	} {
+		artifacts.Add(a)
+	}
+
+	names := func(arts *Artifacts) []string {
+		var result []string
+		for _, a := range arts.List() {
+			result = append(result, a.Name)
+		}
+		return result
+	}
+
+	chained := artifacts.
+		Filter(ByType(Binary)).
+		Filter(ByGoos("linux")).
+		Filter(ByIDs("a"))
+	require.Equal(t, []string{"bin-linux"}, names(chained))
+	require.Equal(t, names(chained), names(artifacts.Filter(And(
+		ByType(Binary),
+		ByGoos("linux"),
+		ByIDs("a"),
+	))))
+
+	require.ElementsMatch(t, []string{
+		"bin-linux",
+		"bin-darwin",
+		"archive-linux",
+		"app.deb",
+		"checksums.txt",
+	}, names(artifacts.Filter(ByIDs("a"))), "checksums are kept regardless of the id")
+
+	require.ElementsMatch(t, []string{
+		"archive-linux",
+		"app.deb",
+		"checksums.txt",
+	}, names(artifacts.Filter(Or(
+		ByType(UploadableArchive),
+		ByType(LinuxPackage),
+		ByType(Checksum),
+	))))
+
+	require.Empty(t, artifacts.Filter(ByGoos("windows")).Filter(ByType(Binary)).List())
+	require.Len(t, artifacts.List(), 7, "filtering must not modify the original list")
+}
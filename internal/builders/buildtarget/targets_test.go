@@ -247,4 +247,54 @@ func TestList(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, []string{"linux_amd64_v2"}, targets)
 	})
+
+	t.Run("goamd64 only expands amd64", func(t *testing.T) {
+		targets, err := List(config.Build{
+			Goos:     []string{"linux", "darwin"},
+			Goarch:   []string{"amd64", "arm64"},
+			Goamd64:  []string{"v1", "v3"},
+			GoBinary: "go",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"linux_amd64_v1",
+			"linux_amd64_v3",
+			"linux_arm64",
+			"darwin_amd64_v1",
+			"darwin_amd64_v3",
+			"darwin_arm64",
+		}, targets)
+	})
+
+	t.Run("invalid goamd64", func(t *testing.T) {
+		_, err := List(config.Build{
+			Goos:     []string{"linux"},
+			Goarch:   []string{"amd64"},
+			Goamd64:  []string{"v5"},
+			GoBinary: "go",
+		})
+		require.EqualError(t, err, "invalid goamd64: v5")
+	})
+	t.Run("ignore", func(t *testing.T) {
+		targets, err := List(config.Build{
+			Goos:    []string{"linux", "windows", "darwin"},
+			Goarch:  []string{"amd64", "arm", "arm64"},
+			Goarm:   []string{"6", "7"},
+			Goamd64: []string{"v1"},
+			Ignore: []config.IgnoredBuild{
+				{Goos: "windows", Goarch: "arm"},
+				{Goos: "linux", Goarch: "arm", Goarm: "6"},
+				{Goos: "darwin"},
+			},
+			GoBinary: "go",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"linux_amd64_v1",
+			"linux_arm_7",
+			"linux_arm64",
+			"windows_amd64_v1",
+			"windows_arm64",
+		}, targets)
+	})
 }
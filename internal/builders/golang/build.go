@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"dario.cat/mergo"
 	"github.com/caarlos0/log"
@@ -191,47 +192,29 @@ func (*Builder) Build(ctx *context.Context, build config.Build, options api.Opti
 		return err
 	}
 
-	env := []string{}
-	// used for unit testing only
-	testEnvs := []string{}
-	env = append(env, ctx.Env.Strings()...)
-	for _, e := range details.Env {
-		ee, err := tmpl.New(ctx).WithEnvS(env).WithArtifact(a).Apply(e)
-		if err != nil {
-			return err
-		}
-		log.Debugf("env %q evaluated to %q", e, ee)
-		if ee != "" {
-			env = append(env, ee)
-			if strings.HasPrefix(e, "TEST_") {
-				testEnvs = append(testEnvs, ee)
-			}
-		}
-	}
-	env = append(
-		env,
-		"GOOS="+options.Goos,
-		"GOARCH="+options.Goarch,
-		"GOARM="+options.Goarm,
-		"GOMIPS="+options.Gomips,
-		"GOMIPS64="+options.Gomips,
-		"GOAMD64="+options.Goamd64,
-	)
-
-	if len(testEnvs) > 0 {
-		a.Extra["testEnvs"] = testEnvs
+	env, err := buildEnv(ctx, details, options, a)
+	if err != nil {
+		return err
 	}
 
 	cmd, err := buildGoBuildLine(ctx, build, details, options, a, env)
 	if err != nil {
 		return err
 	}
+	if cmd[0], err = lookGoBinary(cmd[0]); err != nil {
+		return err
+	}
 
 	if err := run(ctx, cmd, env, build.Dir); err != nil {
 		return fmt.Errorf("failed to build for %s: %w", options.Target, err)
 	}
 
-	modTimestamp, err := tmpl.New(ctx).WithEnvS(env).WithArtifact(a).Apply(build.ModTimestamp)
+	modTimestamp := build.ModTimestamp
+	if modTimestamp == "" && ctx.Env["SOURCE_DATE_EPOCH"] != "" {
+		// reproducible builds: use the release date, like everything else.
+		modTimestamp = "{{ .Timestamp }}"
+	}
+	modTimestamp, err = tmpl.New(ctx).WithEnvS(env).WithArtifact(a).Apply(modTimestamp)
 	if err != nil {
 		return err
 	}
@@ -298,6 +281,9 @@ func buildGoBuildLine(
 	if build.Command == "test" && !slices.Contains(flags, "-c") {
 		cmd = append(cmd, "-c")
 	}
+	if build.Trimpath && !slices.Contains(flags, "-trimpath") {
+		cmd = append(cmd, "-trimpath")
+	}
 
 	asmflags, err := processFlags(ctx, artifact, env, details.Asmflags, "-asmflags=")
 	if err != nil {
@@ -321,12 +307,15 @@ func buildGoBuildLine(
 	}
 
 	// ldflags is not a repeatable flag
-	if len(details.Ldflags) > 0 {
+	if len(details.Ldflags) > 0 || build.Strip {
 		// flag prefix is skipped because ldflags need to output a single string
 		ldflags, err := processFlags(ctx, artifact, env, details.Ldflags, "")
 		if err != nil {
 			return cmd, err
 		}
+		if build.Strip {
+			ldflags = stripLdflags(ldflags)
+		}
 		// ldflags need to be single string in order to apply correctly
 		cmd = append(cmd, "-ldflags="+strings.Join(ldflags, " "))
 	}
@@ -339,6 +328,22 @@ func buildGoBuildLine(
 	return cmd, nil
 }
 
+// stripLdflags adds the flags omitting the symbol table and the DWARF debug
+// information to the given ldflags, unless they are already there.
+func stripLdflags(ldflags []string) []string {
+	var fields []string
+	for _, flag := range ldflags {
+		fields = append(fields, strings.Fields(flag)...)
+	}
+	var strip []string
+	for _, flag := range []string{"-s", "-w"} {
+		if !slices.Contains(fields, flag) {
+			strip = append(strip, flag)
+		}
+	}
+	return append(strip, ldflags...)
+}
+
 func validateUniqueFlags(details config.BuildDetails) {
 	for _, flag := range details.Flags {
 		if strings.HasPrefix(flag, "-tags") && len(details.Tags) > 0 {
@@ -369,6 +374,80 @@ func processFlag(ctx *context.Context, a *artifact.Artifact, env []string, rawFl
 	return tmpl.New(ctx).WithEnvS(env).WithArtifact(a).Apply(rawFlag)
 }
 
+// buildEnv evaluates the environment of the given build, merging the
+// templated build env on top of the context one, and the target on top of
+// both.
+func buildEnv(ctx *context.Context, details config.BuildDetails, options api.Options, a *artifact.Artifact) ([]string, error) {
+	env := []string{}
+	// used for unit testing only
+	testEnvs := []string{}
+	env = append(env, ctx.Env.Strings()...)
+	for _, e := range details.Env {
+		ee, err := tmpl.New(ctx).WithEnvS(env).WithArtifact(a).Apply(e)
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("env %q evaluated to %q", e, ee)
+		if ee != "" {
+			env = append(env, ee)
+			if strings.HasPrefix(e, "TEST_") {
+				testEnvs = append(testEnvs, ee)
+			}
+		}
+	}
+	env = append(
+		env,
+		"GOOS="+options.Goos,
+		"GOARCH="+options.Goarch,
+		"GOARM="+options.Goarm,
+		"GOMIPS="+options.Gomips,
+		"GOMIPS64="+options.Gomips,
+		"GOAMD64="+options.Goamd64,
+	)
+
+	if len(testEnvs) > 0 {
+		a.Extra["testEnvs"] = testEnvs
+	}
+	return mergeEnv(env), nil
+}
+
+// mergeEnv removes the duplicated keys of the given KEY=VALUE list, keeping
+// the position of their first occurrence and the value of the last one.
+func mergeEnv(env []string) []string {
+	result := make([]string, 0, len(env))
+	index := map[string]int{}
+	for _, e := range env {
+		key, _, _ := strings.Cut(e, "=")
+		if i, ok := index[key]; ok {
+			result[i] = e
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, e)
+	}
+	return result
+}
+
+// goBinaries caches the resolved go binaries, so each one is looked up only
+// once, no matter how many targets are built with it.
+// nolint: gochecknoglobals
+var goBinaries sync.Map
+
+// lookGoBinary returns the path of the given go binary, failing if it can't be
+// found.
+func lookGoBinary(gobin string) (string, error) {
+	key := gobin + string(os.PathListSeparator) + os.Getenv("PATH")
+	if path, ok := goBinaries.Load(key); ok {
+		return path.(string), nil
+	}
+	path, err := exec.LookPath(gobin)
+	if err != nil {
+		return "", fmt.Errorf("gobinary %q not found: %w", gobin, err)
+	}
+	goBinaries.Store(key, path)
+	return path, nil
+}
+
 func run(ctx *context.Context, command, env []string, dir string) error {
 	/* #nosec */
 	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
@@ -561,6 +561,44 @@ func TestBuildInvalidEnv(t *testing.T) {
 	testlib.RequireTemplateError(t, err)
 }
 
+func TestBuildEnv(t *testing.T) {
+	ctx := testctx.New(
+		testctx.WithEnv(map[string]string{"CC": "gcc"}),
+		testctx.WithVersion("1.2.3"),
+	)
+	env, err := buildEnv(ctx, config.BuildDetails{
+		Env: []string{
+			"CGO_ENABLED=1",
+			"CC=zig cc -target {{ .Arch }}-linux-musl",
+			"VERSION={{ .Version }}",
+			"CGO_ENABLED=0",
+			"EMPTY={{ if false }}nope{{ end }}",
+			"GOOS=nope",
+		},
+	}, api.Options{
+		Goos:   "linux",
+		Goarch: "arm64",
+	}, &artifact.Artifact{Goos: "linux", Goarch: "arm64"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"CC=zig cc -target arm64-linux-musl",
+		"CGO_ENABLED=0",
+		"VERSION=1.2.3",
+		"EMPTY=",
+		"GOOS=linux",
+		"GOARCH=arm64",
+		"GOARM=",
+		"GOMIPS=",
+		"GOMIPS64=",
+		"GOAMD64=",
+	}, env)
+}
+
+func TestMergeEnv(t *testing.T) {
+	require.Equal(t, []string{"A=3", "B=2", "C"}, mergeEnv([]string{"A=1", "B=2", "A=3", "C"}))
+	require.Empty(t, mergeEnv(nil))
+}
+
 func TestBuildCodeInSubdir(t *testing.T) {
 	folder := testlib.Mktmp(t)
 	subdir := filepath.Join(folder, "bar")
@@ -926,10 +964,17 @@ func TestLdFlagsFullTemplate(t *testing.T) {
 	require.Contains(t, flags, fmt.Sprintf("-X main.commitDate=%d", commit.Year()))
 }
 
+func TestMissingEnvTemplate(t *testing.T) {
+	ctx := testctx.New(testctx.WithCurrentTag("3.4.1"))
+	flags, err := tmpl.New(ctx).Apply(`-s -w -X "main.foo={{.Env.NOPE}}"`)
+	require.NoError(t, err)
+	require.Equal(t, `-s -w -X "main.foo="`, flags)
+}
+
 func TestInvalidTemplate(t *testing.T) {
 	for _, template := range []string{
 		"{{ .Nope }",
-		"{{.Env.NOPE}}",
+		"{{.Nope}}",
 	} {
 		t.Run(template, func(t *testing.T) {
 			ctx := testctx.New(testctx.WithCurrentTag("3.4.1"))
@@ -982,6 +1027,26 @@ func TestProcessFlags(t *testing.T) {
 	require.Equal(t, expected, flags)
 }
 
+func TestProcessLdflagsSameDateForAllTargets(t *testing.T) {
+	ctx := testctx.NewWithCfg(
+		config.Project{ProjectName: "proj"},
+		testctx.WithVersion("1.2.3"),
+		testctx.WithCurrentTag("v1.2.3"),
+		testctx.WithCommit("abc123"),
+		testctx.WithDate(time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("x", 3600))),
+	)
+	source := []string{
+		"-X main.version={{.Version}} -X main.tag={{.Tag}} -X main.commit={{.Commit}} -X main.date={{.Date}} -X main.project={{.ProjectName}}",
+	}
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		flags, err := processFlags(ctx, &artifact.Artifact{Goos: goos}, []string{}, source, "-ldflags=")
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"-ldflags=-X main.version=1.2.3 -X main.tag=v1.2.3 -X main.commit=abc123 -X main.date=2024-01-02T02:04:05Z -X main.project=proj",
+		}, flags)
+	}
+}
+
 func TestProcessFlagsInvalid(t *testing.T) {
 	ctx := testctx.New()
 	source := []string{
@@ -1062,6 +1127,46 @@ func TestBuildModTimestamp(t *testing.T) {
 	}
 }
 
+func TestBuildSourceDateEpoch(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	writeGoodMain(t, folder)
+
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Env: []string{"SOURCE_DATE_EPOCH=1700000000"},
+			Builds: []config.Build{{
+				ID:      "foo",
+				Binary:  "foo",
+				Targets: []string{"linux_amd64", "darwin_arm64"},
+				BuildDetails: config.BuildDetails{
+					Env: []string{"GO111MODULE=off"},
+				},
+				GoBinary: "go",
+				Command:  "build",
+			}},
+		},
+		testctx.WithCurrentTag("v5.6.7"),
+		testctx.WithVersion("5.6.7"),
+	)
+	require.Equal(t, int64(1700000000), ctx.Date.Unix())
+	build := ctx.Config.Builds[0]
+	for _, target := range build.Targets {
+		require.NoError(t, Default.Build(ctx, build, api.Options{
+			Target: target,
+			Name:   "foo",
+			Path:   filepath.Join(folder, "dist", target, "foo"),
+		}))
+	}
+
+	bins := ctx.Artifacts.Filter(artifact.ByType(artifact.Binary)).List()
+	require.Len(t, bins, 2)
+	for _, bin := range bins {
+		fi, err := os.Stat(bin.Path)
+		require.NoError(t, err)
+		require.True(t, ctx.Date.Equal(fi.ModTime()), "%s: expected mod time %s, got %s", bin.Name, ctx.Date, fi.ModTime())
+	}
+}
+
 func TestBuildGoBuildLine(t *testing.T) {
 	requireEqualCmd := func(tb testing.TB, build config.Build, expected []string) {
 		tb.Helper()
@@ -1118,6 +1223,89 @@ func TestBuildGoBuildLine(t *testing.T) {
 		})
 	})
 
+	t.Run("trimpath", func(t *testing.T) {
+		requireEqualCmd(t, config.Build{
+			Main:     ".",
+			Binary:   "foo",
+			GoBinary: "go",
+			Command:  "build",
+			Trimpath: true,
+			BuildDetails: config.BuildDetails{
+				Flags: []string{"-v"},
+			},
+		}, []string{
+			"go", "build",
+			"-v",
+			"-trimpath",
+			"-o", "foo", ".",
+		})
+	})
+
+	t.Run("trimpath already in flags", func(t *testing.T) {
+		requireEqualCmd(t, config.Build{
+			Main:     ".",
+			Binary:   "foo",
+			GoBinary: "go",
+			Command:  "build",
+			Trimpath: true,
+			BuildDetails: config.BuildDetails{
+				Flags: []string{"-trimpath"},
+			},
+		}, []string{
+			"go", "build",
+			"-trimpath",
+			"-o", "foo", ".",
+		})
+	})
+
+	t.Run("strip", func(t *testing.T) {
+		requireEqualCmd(t, config.Build{
+			Main:     ".",
+			Binary:   "foo",
+			GoBinary: "go",
+			Command:  "build",
+			Strip:    true,
+			BuildDetails: config.BuildDetails{
+				Ldflags: []string{"-X main.version={{.Version}}"},
+			},
+		}, []string{
+			"go", "build",
+			"-ldflags=-s -w -X main.version=1.2.3",
+			"-o", "foo", ".",
+		})
+	})
+
+	t.Run("strip without ldflags", func(t *testing.T) {
+		requireEqualCmd(t, config.Build{
+			Main:     ".",
+			Binary:   "foo",
+			GoBinary: "go",
+			Command:  "build",
+			Strip:    true,
+		}, []string{
+			"go", "build",
+			"-ldflags=-s -w",
+			"-o", "foo", ".",
+		})
+	})
+
+	t.Run("strip already in ldflags", func(t *testing.T) {
+		requireEqualCmd(t, config.Build{
+			Main:     ".",
+			Binary:   "foo",
+			GoBinary: "go",
+			Command:  "build",
+			Strip:    true,
+			BuildDetails: config.BuildDetails{
+				Ldflags: []string{"-w -X main.version={{.Version}}"},
+			},
+		}, []string{
+			"go", "build",
+			"-ldflags=-s -w -X main.version=1.2.3",
+			"-o", "foo", ".",
+		})
+	})
+
 	t.Run("with overrides", func(t *testing.T) {
 		requireEqualCmd(t, config.Build{
 			Main: ".",
@@ -1433,6 +1621,66 @@ func TestInvalidGoBinaryTpl(t *testing.T) {
 	}))
 }
 
+func TestGoBinaryStub(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub is a shell script")
+	}
+	folder := testlib.Mktmp(t)
+	writeGoodMain(t, folder)
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "mygo"), []byte(`#!/bin/sh
+echo "$@" > "$(dirname "$0")/args"
+while [ "$1" != "-o" ]; do shift; done
+mkdir -p "$(dirname "$2")"
+touch "$2"
+`), 0o755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx := testctx.NewWithCfg(config.Project{
+		Env: []string{"GO_VERSION=mygo"},
+		Builds: []config.Build{{
+			ID:       "foo",
+			Binary:   "foo",
+			Main:     ".",
+			Targets:  []string{runtimeTarget},
+			GoBinary: "{{ .Env.GO_VERSION }}",
+			Command:  "build",
+		}},
+	})
+	build := ctx.Config.Builds[0]
+	require.NoError(t, Default.Build(ctx, build, api.Options{
+		Target: runtimeTarget,
+		Name:   build.Binary,
+		Path:   filepath.Join(folder, "dist", "foo"),
+	}))
+	args, err := os.ReadFile(filepath.Join(bin, "args"))
+	require.NoError(t, err)
+	require.Equal(t, "build -o "+filepath.Join(folder, "dist", "foo")+" .\n", string(args))
+	require.FileExists(t, filepath.Join(folder, "dist", "foo"))
+}
+
+func TestGoBinaryNotFound(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	writeGoodMain(t, folder)
+	ctx := testctx.NewWithCfg(config.Project{
+		Builds: []config.Build{{
+			ID:       "foo",
+			Binary:   "foo",
+			Targets:  []string{runtimeTarget},
+			GoBinary: "go-does-not-exist",
+			Command:  "build",
+		}},
+	})
+	build := ctx.Config.Builds[0]
+	err := Default.Build(ctx, build, api.Options{
+		Target: runtimeTarget,
+		Name:   build.Binary,
+		Path:   filepath.Join(folder, "dist", "foo"),
+	})
+	require.ErrorIs(t, err, exec.ErrNotFound)
+	require.ErrorContains(t, err, `gobinary "go-does-not-exist" not found`)
+}
+
 //
 // Helpers
 //
@@ -4,6 +4,7 @@ package client
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/caarlos0/log"
 	"github.com/goreleaser/goreleaser/internal/artifact"
@@ -104,6 +105,13 @@ type ForkSyncer interface {
 	SyncFork(ctx *context.Context, head, base Repo) error
 }
 
+// ReleaseDeleter can delete releases.
+type ReleaseDeleter interface {
+	// DeleteRelease deletes the given release, but only if it was created by
+	// this client: pre-existing releases are never deleted.
+	DeleteRelease(ctx *context.Context, releaseID string) error
+}
+
 // PullRequestOpener can open pull requests.
 type PullRequestOpener interface {
 	OpenPullRequest(ctx *context.Context, base, head Repo, title string, draft bool) error
@@ -177,9 +185,22 @@ func (e ErrNoMilestoneFound) Error() string {
 	return fmt.Sprintf("no milestone found: %s", e.Title)
 }
 
+// assetsMode returns what to do with assets that already exist in the
+// release, honoring the older replace_existing_artifacts option.
+func assetsMode(release config.Release) config.ReleaseAssetsMode {
+	if release.AssetsMode == "" && release.ReplaceExistingArtifacts {
+		return config.ReleaseAssetsModeReplace
+	}
+	return release.AssetsMode
+}
+
 // RetriableError is an error that will cause the action to be retried.
+//
+// RetryAfter, when set, is how long the server asked us to wait before
+// trying again, e.g. because of rate limiting.
 type RetriableError struct {
-	Err error
+	Err        error
+	RetryAfter time.Duration
 }
 
 func (e RetriableError) Error() string {
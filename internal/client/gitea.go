@@ -329,7 +329,7 @@ func (c *giteaClient) Upload(
 
 	_, _, err = c.client.CreateReleaseAttachment(owner, repoName, giteaReleaseID, file, artifact.Name)
 	if err != nil {
-		return RetriableError{err}
+		return RetriableError{Err: err}
 	}
 	return nil
 }
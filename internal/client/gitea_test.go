@@ -98,7 +98,7 @@ func (s *GetInstanceURLSuite) TestTemplateMissingValue() {
 	t := s.T()
 	ctx := testctx.NewWithCfg(config.Project{
 		GiteaURLs: config.GiteaURLs{
-			API: "{{ .Env.GORELEASER_NOT_EXISTS }}",
+			API: "{{ .Nope }}",
 		},
 	})
 
@@ -494,7 +494,7 @@ func TestGiteaReleaseURLTemplate(t *testing.T) {
 		},
 		{
 			name:        "download_url_template_invalid_value",
-			downloadURL: "{{ .Env.GORELEASER_NOT_EXISTS }}",
+			downloadURL: "{{ .Nope }}",
 			wantErr:     true,
 		},
 		{
@@ -26,6 +26,7 @@ const DefaultGitHubDownloadURL = "https://github.com"
 
 var (
 	_ Client                = &githubClient{}
+	_ ReleaseDeleter        = &githubClient{}
 	_ ReleaseNotesGenerator = &githubClient{}
 	_ PullRequestOpener     = &githubClient{}
 	_ ForkSyncer            = &githubClient{}
@@ -33,6 +34,10 @@ var (
 
 type githubClient struct {
 	client *github.Client
+
+	// createdReleaseID is the ID of the release created by this client, if
+	// any.
+	createdReleaseID int64
 }
 
 // NewGitHubReleaseNotesGenerator returns a GitHub client that can generate
@@ -433,6 +438,34 @@ func (c *githubClient) PublishRelease(ctx *context.Context, releaseID string) er
 	return nil
 }
 
+func (c *githubClient) DeleteRelease(ctx *context.Context, releaseID string) error {
+	id, err := strconv.ParseInt(releaseID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("non-numeric release ID %q: %w", releaseID, err)
+	}
+	if id != c.createdReleaseID {
+		log.WithField("release-id", releaseID).
+			Info("release was not created by this run, keeping it")
+		return nil
+	}
+	c.checkRateLimit(ctx)
+	resp, err := c.client.Repositories.DeleteRelease(
+		ctx,
+		ctx.Config.Release.GitHub.Owner,
+		ctx.Config.Release.GitHub.Name,
+		id,
+	)
+	if err != nil {
+		githubErrLogger(resp, err).
+			WithField("release-id", releaseID).
+			Warn("could not delete release")
+		return err
+	}
+	c.createdReleaseID = 0
+	log.WithField("release-id", releaseID).Info("release deleted")
+	return nil
+}
+
 func (c *githubClient) createOrUpdateRelease(ctx *context.Context, data *github.RepositoryRelease, body string) (*github.RepositoryRelease, error) {
 	c.checkRateLimit(ctx)
 	release, _, err := c.client.Repositories.GetReleaseByTag(
@@ -449,6 +482,7 @@ func (c *githubClient) createOrUpdateRelease(ctx *context.Context, data *github.
 			data,
 		)
 		if err == nil {
+			c.createdReleaseID = release.GetID()
 			log.WithField("name", data.GetName()).
 				WithField("release-id", release.GetID()).
 				WithField("request-id", resp.Header.Get("X-GitHub-Request-Id")).
@@ -485,6 +519,10 @@ func (c *githubClient) ReleaseURLTemplate(ctx *context.Context) (string, error)
 	if err != nil {
 		return "", fmt.Errorf("templating GitHub download URL: %w", err)
 	}
+	if downloadURL == "" {
+		downloadURL = DefaultGitHubDownloadURL
+	}
+	downloadURL = strings.TrimSuffix(downloadURL, "/")
 
 	return fmt.Sprintf(
 		"%s/%s/%s/releases/download/{{ .Tag }}/{{ .ArtifactName }}",
@@ -570,17 +608,43 @@ func (c *githubClient) Upload(
 	}
 	// this status means the asset already exists
 	if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
-		if !ctx.Config.Release.ReplaceExistingArtifacts {
-			return err
-		}
-		// if the user allowed to delete assets, we delete it, and return a
-		// retriable error.
-		if err := c.deleteReleaseArtifact(ctx, githubReleaseID, artifact.Name, 1); err != nil {
+		switch assetsMode(ctx.Config.Release) {
+		case config.ReleaseAssetsModeKeep:
+			log.WithField("name", artifact.Name).
+				Info("asset already exists in the release, keeping it")
+			return nil
+		case config.ReleaseAssetsModeReplace:
+			// if the user allowed to delete assets, we delete it, and return a
+			// retriable error.
+			if err := c.deleteReleaseArtifact(ctx, githubReleaseID, artifact.Name, 1); err != nil {
+				return err
+			}
+			return RetriableError{Err: err}
+		default:
 			return err
 		}
-		return RetriableError{err}
 	}
-	return RetriableError{err}
+	return RetriableError{Err: err, RetryAfter: retryAfter(resp, err)}
+}
+
+// retryAfter returns how long GitHub asked us to wait before retrying, if it
+// did so.
+func retryAfter(resp *github.Response, err error) time.Duration {
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) && abuseErr.RetryAfter != nil {
+		return *abuseErr.RetryAfter
+	}
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return time.Until(rateErr.Rate.Reset.Time)
+	}
+	if resp == nil {
+		return 0
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
 }
 
 // getMilestoneByTitle returns a milestone by title.
@@ -619,31 +683,33 @@ func (c *githubClient) getMilestoneByTitle(ctx *context.Context, repo Repo, titl
 }
 
 func overrideGitHubClientAPI(ctx *context.Context, client *github.Client) error {
-	if ctx.Config.GitHubURLs.API == "" {
-		return nil
-	}
-
-	apiURL, err := tmpl.New(ctx).Apply(ctx.Config.GitHubURLs.API)
-	if err != nil {
-		return fmt.Errorf("templating GitHub API URL: %w", err)
-	}
-	api, err := url.Parse(apiURL)
-	if err != nil {
-		return err
+	if ctx.Config.GitHubURLs.API != "" {
+		apiURL, err := tmpl.New(ctx).Apply(ctx.Config.GitHubURLs.API)
+		if err != nil {
+			return fmt.Errorf("templating GitHub API URL: %w", err)
+		}
+		api, err := url.Parse(apiURL)
+		if err != nil {
+			return err
+		}
+		client.BaseURL = api
+		// GitHub Enterprise serves uploads from the same host, under
+		// /api/uploads/.
+		client.UploadURL = api.ResolveReference(&url.URL{Path: "/api/uploads/"})
 	}
 
-	uploadURL, err := tmpl.New(ctx).Apply(ctx.Config.GitHubURLs.Upload)
-	if err != nil {
-		return fmt.Errorf("templating GitHub upload URL: %w", err)
-	}
-	upload, err := url.Parse(uploadURL)
-	if err != nil {
-		return err
+	if ctx.Config.GitHubURLs.Upload != "" {
+		uploadURL, err := tmpl.New(ctx).Apply(ctx.Config.GitHubURLs.Upload)
+		if err != nil {
+			return fmt.Errorf("templating GitHub upload URL: %w", err)
+		}
+		upload, err := url.Parse(uploadURL)
+		if err != nil {
+			return err
+		}
+		client.UploadURL = upload
 	}
 
-	client.BaseURL = api
-	client.UploadURL = upload
-
 	return nil
 }
 
@@ -3,11 +3,14 @@ package client
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -18,6 +21,7 @@ import (
 	"github.com/goreleaser/goreleaser/internal/testctx"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/goreleaser/goreleaser/pkg/context"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,6 +41,40 @@ func TestNewGitHubClient(t *testing.T) {
 		require.Equal(t, githubURL+"/upload", client.client.UploadURL.String())
 	})
 
+	t.Run("default urls", func(t *testing.T) {
+		ctx := testctx.New()
+		client, err := newGitHub(ctx, ctx.Token)
+		require.NoError(t, err)
+		require.Equal(t, "https://api.github.com/", client.client.BaseURL.String())
+		require.Equal(t, "https://uploads.github.com/", client.client.UploadURL.String())
+	})
+
+	t.Run("only api url", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			GitHubURLs: config.GitHubURLs{
+				API: "https://github.mycompany.com/api/v3/",
+			},
+		})
+
+		client, err := newGitHub(ctx, ctx.Token)
+		require.NoError(t, err)
+		require.Equal(t, "https://github.mycompany.com/api/v3/", client.client.BaseURL.String())
+		require.Equal(t, "https://github.mycompany.com/api/uploads/", client.client.UploadURL.String())
+	})
+
+	t.Run("only upload url", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			GitHubURLs: config.GitHubURLs{
+				Upload: "https://uploads.mycompany.com/",
+			},
+		})
+
+		client, err := newGitHub(ctx, ctx.Token)
+		require.NoError(t, err)
+		require.Equal(t, "https://api.github.com/", client.client.BaseURL.String())
+		require.Equal(t, "https://uploads.mycompany.com/", client.client.UploadURL.String())
+	})
+
 	t.Run("bad api url", func(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
 			GitHubURLs: config.GitHubURLs{
@@ -80,10 +118,25 @@ func TestNewGitHubClient(t *testing.T) {
 		require.Equal(t, githubURL+"/upload", client.client.UploadURL.String())
 	})
 
+	t.Run("template missing env", func(t *testing.T) {
+		githubURL := "https://github.mycompany.com"
+		ctx := testctx.NewWithCfg(config.Project{
+			GitHubURLs: config.GitHubURLs{
+				API:    githubURL + "/api{{ .Env.GORELEASER_NOT_EXISTS }}",
+				Upload: githubURL + "/upload{{ .Env.GORELEASER_NOT_EXISTS }}",
+			},
+		})
+
+		client, err := newGitHub(ctx, ctx.Token)
+		require.NoError(t, err)
+		require.Equal(t, githubURL+"/api", client.client.BaseURL.String())
+		require.Equal(t, githubURL+"/upload", client.client.UploadURL.String())
+	})
+
 	t.Run("template invalid api", func(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
 			GitHubURLs: config.GitHubURLs{
-				API: "{{ .Env.GORELEASER_NOT_EXISTS }}",
+				API: "{{ .Nope }}",
 			},
 		})
 
@@ -95,7 +148,7 @@ func TestNewGitHubClient(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
 			GitHubURLs: config.GitHubURLs{
 				API:    "https://github.mycompany.com/api",
-				Upload: "{{ .Env.GORELEASER_NOT_EXISTS }}",
+				Upload: "{{ .Nope }}",
 			},
 		})
 
@@ -127,6 +180,208 @@ func TestGitHubUploadReleaseIDNotInt(t *testing.T) {
 	)
 }
 
+func TestGitHubUploadExistingAsset(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		release config.Release
+		check   func(tb testing.TB, err error)
+		deleted bool
+	}{
+		{
+			name:    "fail",
+			release: config.Release{AssetsMode: config.ReleaseAssetsModeFail},
+			check: func(tb testing.TB, err error) {
+				tb.Helper()
+				require.Error(tb, err)
+				require.False(tb, errors.As(err, &RetriableError{}))
+			},
+		},
+		{
+			name:    "default",
+			release: config.Release{},
+			check: func(tb testing.TB, err error) {
+				tb.Helper()
+				require.Error(tb, err)
+				require.False(tb, errors.As(err, &RetriableError{}))
+			},
+		},
+		{
+			name:    "keep",
+			release: config.Release{AssetsMode: config.ReleaseAssetsModeKeep},
+			check: func(tb testing.TB, err error) {
+				tb.Helper()
+				require.NoError(tb, err)
+			},
+		},
+		{
+			name:    "replace",
+			release: config.Release{AssetsMode: config.ReleaseAssetsModeReplace},
+			check: func(tb testing.TB, err error) {
+				tb.Helper()
+				require.ErrorAs(tb, err, &RetriableError{})
+			},
+			deleted: true,
+		},
+		{
+			name:    "replace existing artifacts",
+			release: config.Release{ReplaceExistingArtifacts: true},
+			check: func(tb testing.TB, err error) {
+				tb.Helper()
+				require.ErrorAs(tb, err, &RetriableError{})
+			},
+			deleted: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				switch {
+				case r.URL.Path == "/rate_limit":
+					fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+				case r.URL.Path == "/repos/someone/something/releases/1/assets" && r.Method == http.MethodPost:
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					fmt.Fprint(w, `{"message":"Validation Failed","errors":[{"resource":"ReleaseAsset","code":"already_exists","field":"name"}]}`)
+				case r.URL.Path == "/repos/someone/something/releases/1/assets" && r.Method == http.MethodGet:
+					fmt.Fprint(w, `[{"id": 2, "name": "other.tar.gz"}, {"id": 3, "name": "bin.tar.gz"}]`)
+				case r.URL.Path == "/repos/someone/something/releases/assets/3" && r.Method == http.MethodDelete:
+					deleted = true
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+				}
+			}))
+			defer srv.Close()
+
+			release := tt.release
+			release.GitHub = config.Repo{
+				Owner: "someone",
+				Name:  "something",
+			}
+			ctx := testctx.NewWithCfg(config.Project{
+				GitHubURLs: config.GitHubURLs{
+					API:    srv.URL + "/",
+					Upload: srv.URL + "/",
+				},
+				Release: release,
+			})
+			client, err := newGitHub(ctx, "test-token")
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "bin.tar.gz")
+			require.NoError(t, os.WriteFile(path, []byte("fake"), 0o644))
+			file, err := os.Open(path)
+			require.NoError(t, err)
+			defer file.Close()
+
+			tt.check(t, client.Upload(ctx, "1", &artifact.Artifact{Name: "bin.tar.gz", Path: path}, file))
+			require.Equal(t, tt.deleted, deleted)
+		})
+	}
+}
+
+func TestGitHubDeleteRelease(t *testing.T) {
+	for _, existing := range []bool{true, false} {
+		t.Run(fmt.Sprintf("existing=%v", existing), func(t *testing.T) {
+			var deleted bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				switch {
+				case r.URL.Path == "/rate_limit":
+					fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+				case r.URL.Path == "/repos/someone/something/releases/tags/v1.0.0":
+					if !existing {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					fmt.Fprint(w, `{"id": 1}`)
+				case r.URL.Path == "/repos/someone/something/releases" && r.Method == http.MethodPost:
+					fmt.Fprint(w, `{"id": 1}`)
+				case r.URL.Path == "/repos/someone/something/releases/1" && r.Method == http.MethodPatch:
+					fmt.Fprint(w, `{"id": 1}`)
+				case r.URL.Path == "/repos/someone/something/releases/1" && r.Method == http.MethodDelete:
+					deleted = true
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+				}
+			}))
+			defer srv.Close()
+
+			ctx := testctx.NewWithCfg(config.Project{
+				GitHubURLs: config.GitHubURLs{
+					API: srv.URL + "/",
+				},
+				Release: config.Release{
+					NameTemplate: "{{ .Tag }}",
+					GitHub: config.Repo{
+						Owner: "someone",
+						Name:  "something",
+					},
+				},
+			}, testctx.WithCurrentTag("v1.0.0"))
+			client, err := newGitHub(ctx, "test-token")
+			require.NoError(t, err)
+
+			id, err := client.CreateRelease(ctx, "notes")
+			require.NoError(t, err)
+			require.NoError(t, client.DeleteRelease(ctx, id))
+			require.Equal(t, !existing, deleted)
+		})
+	}
+
+	t.Run("invalid id", func(t *testing.T) {
+		ctx := testctx.New()
+		client, err := newGitHub(ctx, "test-token")
+		require.NoError(t, err)
+		require.ErrorContains(t, client.DeleteRelease(ctx, "nope"), `non-numeric release ID "nope"`)
+	})
+}
+
+func TestGitHubEnterpriseRequests(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v3/rate_limit":
+			fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+		case "/api/uploads/repos/someone/something/releases/1/assets":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := testctx.NewWithCfg(config.Project{
+		GitHubURLs: config.GitHubURLs{
+			API: srv.URL + "/api/v3/",
+		},
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "someone",
+				Name:  "something",
+			},
+		},
+	})
+	client, err := newGitHub(ctx, "test-token")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "bin.tar.gz")
+	require.NoError(t, os.WriteFile(path, []byte("fake"), 0o644))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	require.NoError(t, client.Upload(ctx, "1", &artifact.Artifact{Name: "bin.tar.gz", Path: path}, file))
+	require.Equal(t, []string{
+		"GET /api/v3/rate_limit",
+		"POST /api/uploads/repos/someone/something/releases/1/assets",
+	}, paths)
+}
+
 func TestGitHubReleaseURLTemplate(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -144,9 +399,19 @@ func TestGitHubReleaseURLTemplate(t *testing.T) {
 			downloadURL:     "{{ .Env.GORELEASER_TEST_GITHUB_URLS_DOWNLOAD }}",
 			wantDownloadURL: "https://github.mycompany.com/owner/name/releases/download/{{ .Tag }}/{{ .ArtifactName }}",
 		},
+		{
+			name:            "empty_download_url",
+			downloadURL:     "",
+			wantDownloadURL: "https://github.com/owner/name/releases/download/{{ .Tag }}/{{ .ArtifactName }}",
+		},
+		{
+			name:            "download_url_trailing_slash",
+			downloadURL:     "https://github.mycompany.com/",
+			wantDownloadURL: "https://github.mycompany.com/owner/name/releases/download/{{ .Tag }}/{{ .ArtifactName }}",
+		},
 		{
 			name:        "download_url_template_invalid_value",
-			downloadURL: "{{ .Env.GORELEASER_NOT_EXISTS }}",
+			downloadURL: "{{ .Nope }}",
 			wantErr:     true,
 		},
 		{
@@ -974,7 +1239,194 @@ func TestGitHubCheckRateLimit(t *testing.T) {
 	require.True(t, time.Now().UTC().After(reset))
 }
 
+func TestGitHubUploadRateLimited(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	for name, tt := range map[string]struct {
+		respond func(w http.ResponseWriter)
+		wait    func(tb testing.TB, d time.Duration)
+	}{
+		"secondary rate limit": {
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "3")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit.","documentation_url":"https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`)
+			},
+			wait: func(tb testing.TB, d time.Duration) {
+				tb.Helper()
+				require.Equal(tb, 3*time.Second, d)
+			},
+		},
+		"primary rate limit": {
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message":"API rate limit exceeded."}`)
+			},
+			wait: func(tb testing.TB, d time.Duration) {
+				tb.Helper()
+				require.InDelta(tb, time.Hour, d, float64(time.Minute))
+			},
+		},
+		"server error": {
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "2")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			wait: func(tb testing.TB, d time.Duration) {
+				tb.Helper()
+				require.Equal(tb, 2*time.Second, d)
+			},
+		},
+		"server error without retry-after": {
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wait: func(tb testing.TB, d time.Duration) {
+				tb.Helper()
+				require.Zero(tb, d)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				switch {
+				case r.URL.Path == "/rate_limit":
+					fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+				case r.URL.Path == "/repos/someone/something/releases/1/assets" && r.Method == http.MethodPost:
+					tt.respond(w)
+				default:
+					t.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+				}
+			}))
+			defer srv.Close()
+
+			ctx := testctx.NewWithCfg(config.Project{
+				GitHubURLs: config.GitHubURLs{
+					API:    srv.URL + "/",
+					Upload: srv.URL + "/",
+				},
+				Release: config.Release{
+					GitHub: config.Repo{
+						Owner: "someone",
+						Name:  "something",
+					},
+				},
+			})
+			client, err := newGitHub(ctx, "test-token")
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "bin.tar.gz")
+			require.NoError(t, os.WriteFile(path, []byte("fake"), 0o644))
+			file, err := os.Open(path)
+			require.NoError(t, err)
+			defer file.Close()
+
+			err = client.Upload(ctx, "1", &artifact.Artifact{Name: "bin.tar.gz", Path: path}, file)
+			var rerr RetriableError
+			require.ErrorAs(t, err, &rerr)
+			tt.wait(t, rerr.RetryAfter)
+		})
+	}
+}
+
 // TODO: test create release
 // TODO: test create upload file to release
 // TODO: test delete draft release
 // TODO: test create PR
+
+func TestGitHubCreateReleaseDraftAndPrerelease(t *testing.T) {
+	for _, draft := range []bool{true, false} {
+		t.Run(fmt.Sprintf("draft=%v", draft), func(t *testing.T) {
+			var created, published *github.RepositoryRelease
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				switch {
+				case r.URL.Path == "/rate_limit":
+					fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+				case r.URL.Path == "/repos/someone/something/releases/tags/v1.2.0-rc1":
+					w.WriteHeader(http.StatusNotFound)
+				case r.URL.Path == "/repos/someone/something/releases" && r.Method == http.MethodPost:
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+					fmt.Fprint(w, `{"id": 1}`)
+				case r.URL.Path == "/repos/someone/something/releases/1" && r.Method == http.MethodPatch:
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&published))
+					fmt.Fprint(w, `{"id": 1}`)
+				default:
+					t.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+				}
+			}))
+			defer srv.Close()
+
+			ctx := testctx.NewWithCfg(config.Project{
+				GitHubURLs: config.GitHubURLs{
+					API: srv.URL + "/",
+				},
+				Release: config.Release{
+					NameTemplate: "{{ .Tag }}",
+					Draft:        draft,
+					GitHub: config.Repo{
+						Owner: "someone",
+						Name:  "something",
+					},
+				},
+			}, testctx.WithCurrentTag("v1.2.0-rc1"), func(ctx *context.Context) { ctx.PreRelease = true })
+			client, err := newGitHub(ctx, "test-token")
+			require.NoError(t, err)
+
+			id, err := client.CreateRelease(ctx, "notes")
+			require.NoError(t, err)
+			require.True(t, created.GetDraft(), "releases are drafts while uploading")
+			require.True(t, created.GetPrerelease())
+
+			require.NoError(t, client.PublishRelease(ctx, id))
+			if draft {
+				require.Nil(t, published)
+				return
+			}
+			require.NotNil(t, published)
+			require.False(t, published.GetDraft())
+		})
+	}
+}
+
+func TestGitHubCreateReleaseNameTemplate(t *testing.T) {
+	var created *github.RepositoryRelease
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		switch {
+		case r.URL.Path == "/rate_limit":
+			fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+		case r.URL.Path == "/repos/someone/something/releases/tags/v1.2.3":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/repos/someone/something/releases" && r.Method == http.MethodPost:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			fmt.Fprint(w, `{"id": 1}`)
+		default:
+			t.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "foo",
+		GitHubURLs: config.GitHubURLs{
+			API: srv.URL + "/",
+		},
+		Release: config.Release{
+			NameTemplate: "{{ .ProjectName }} {{ .Version }}",
+			GitHub: config.Repo{
+				Owner: "someone",
+				Name:  "something",
+			},
+		},
+	}, testctx.WithCurrentTag("v1.2.3"), testctx.WithVersion("1.2.3"))
+	client, err := newGitHub(ctx, "test-token")
+	require.NoError(t, err)
+
+	_, err = client.CreateRelease(ctx, "notes")
+	require.NoError(t, err)
+	require.Equal(t, "foo 1.2.3", created.GetName())
+	require.Equal(t, "notes", created.GetBody())
+}
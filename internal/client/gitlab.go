@@ -519,7 +519,7 @@ func (c *gitlabClient) Upload(
 			FilePath: &filename,
 		})
 	if err != nil {
-		return RetriableError{err}
+		return RetriableError{Err: err}
 	}
 
 	log.WithField("id", releaseLink.ID).
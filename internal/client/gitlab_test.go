@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -133,7 +134,7 @@ func TestGitLabURLsAPITemplate(t *testing.T) {
 	t.Run("no_env_specified", func(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
 			GitLabURLs: config.GitLabURLs{
-				API: "{{ .Env.GORELEASER_NOT_EXISTS }}",
+				API: "{{ .Nope }}",
 			},
 		})
 
@@ -250,6 +251,79 @@ func TestGitLabURLsDownloadTemplate(t *testing.T) {
 	}
 }
 
+func TestGitLabCreateReleaseAndUpload(t *testing.T) {
+	var requests []string
+	var description, linkName, linkURL, linkPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		require.Equal(t, "test-token", r.Header.Get("Private-Token"))
+
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/releases/v1.0.0"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "{}")
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/releases"):
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			description = body["description"].(string)
+			fmt.Fprint(w, `{"tag_name": "v1.0.0"}`)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/uploads"):
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"url": "/uploads/abc/bin.tar.gz"}`)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/projects/owner/name"):
+			fmt.Fprint(w, `{"path_with_namespace": "owner/name"}`)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/assets/links"):
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			linkName = body["name"].(string)
+			linkURL = body["url"].(string)
+			linkPath = body["filepath"].(string)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1}`)
+		default:
+			require.FailNow(t, "unexpected request", r.Method+" "+r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := testctx.NewWithCfg(config.Project{
+		Release: config.Release{
+			GitLab: config.Repo{
+				Owner: "owner",
+				Name:  "name",
+			},
+		},
+		GitLabURLs: config.GitLabURLs{
+			API:      srv.URL,
+			Download: "https://gitlab.mycompany.com",
+		},
+	}, testctx.WithCurrentTag("v1.0.0"))
+	client, err := newGitLab(ctx, "test-token")
+	require.NoError(t, err)
+
+	releaseID, err := client.CreateRelease(ctx, "the changelog")
+	require.NoError(t, err)
+	require.Equal(t, "the changelog", description)
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "bin.tar.gz"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, file.Close()) })
+	require.NoError(t, client.Upload(ctx, releaseID, &artifact.Artifact{Name: "bin.tar.gz"}, file))
+
+	require.Equal(t, "bin.tar.gz", linkName)
+	require.Equal(t, "https://gitlab.mycompany.com/owner/name/uploads/abc/bin.tar.gz", linkURL)
+	require.Equal(t, "/bin.tar.gz", linkPath)
+	require.Equal(t, []string{
+		"GET /api/v4/projects/owner%2Fname/releases/v1%2E0%2E0",
+		"POST /api/v4/projects/owner%2Fname/releases",
+		"POST /api/v4/projects/owner%2Fname/uploads",
+		"GET /api/v4/projects/owner%2Fname",
+		"POST /api/v4/projects/owner%2Fname/releases/v1%2E0%2E0/assets/links",
+	}, requests)
+}
+
 func TestGitLabCreateReleaseUnknownHost(t *testing.T) {
 	ctx := testctx.NewWithCfg(config.Project{
 		Release: config.Release{
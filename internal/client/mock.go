@@ -14,6 +14,7 @@ import (
 
 var (
 	_ Client                = &Mock{}
+	_ ReleaseDeleter        = &Mock{}
 	_ ReleaseNotesGenerator = &Mock{}
 	_ PullRequestOpener     = &Mock{}
 	_ ForkSyncer            = &Mock{}
@@ -31,6 +32,8 @@ type Mock struct {
 	FailToCreateRelease  bool
 	FailToUpload         bool
 	CreatedRelease       bool
+	ExistingRelease      bool
+	DeletedRelease       bool
 	UploadedFile         bool
 	ReleasePublished     bool
 	UploadedFileNames    []string
@@ -89,6 +92,13 @@ func (c *Mock) CreateRelease(_ *context.Context, _ string) (string, error) {
 	return "", nil
 }
 
+func (c *Mock) DeleteRelease(_ *context.Context, _ string) error {
+	if c.CreatedRelease && !c.ExistingRelease {
+		c.DeletedRelease = true
+	}
+	return nil
+}
+
 func (c *Mock) PublishRelease(_ *context.Context, _ string /* releaseID */) (err error) {
 	c.ReleasePublished = true
 	return nil
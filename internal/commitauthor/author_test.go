@@ -23,11 +23,22 @@ func TestGet(t *testing.T) {
 		}, author)
 	})
 
+	t.Run("missing env", func(t *testing.T) {
+		author, err := Get(testctx.New(), config.CommitAuthor{
+			Name:  "{{.Env.NAME}}",
+			Email: "foo@bar",
+		})
+		require.NoError(t, err)
+		require.Equal(t, config.CommitAuthor{
+			Email: "foo@bar",
+		}, author)
+	})
+
 	t.Run("invalid name tmpl", func(t *testing.T) {
 		_, err := Get(
 			testctx.New(),
 			config.CommitAuthor{
-				Name:  "{{.Env.NOPE}}",
+				Name:  "{{.Nope}}",
 				Email: "a",
 			})
 		require.Error(t, err)
@@ -38,7 +49,7 @@ func TestGet(t *testing.T) {
 			testctx.New(),
 			config.CommitAuthor{
 				Name:  "a",
-				Email: "{{.Env.NOPE}}",
+				Email: "{{.Nope}}",
 			})
 		require.Error(t, err)
 	})
@@ -23,9 +23,20 @@ func TestTemplate(t *testing.T) {
 	require.Equal(t, "testdata/file1.golden", files["file1.golden"])
 }
 
+func TestTemplateMissingEnv(t *testing.T) {
+	globs := []config.ExtraFile{
+		{Glob: "./testdata/file1{{ .Env.NOPE }}.golden"},
+	}
+
+	files, err := Find(testctx.New(), globs)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "testdata/file1.golden", files["file1.golden"])
+}
+
 func TestBadTemplate(t *testing.T) {
 	globs := []config.ExtraFile{
-		{Glob: "./testdata/file{{ .Env.NOPE }}.golden"},
+		{Glob: "./testdata/file{{ .Nope }}.golden"},
 	}
 
 	ctx := testctx.New()
@@ -117,7 +128,7 @@ func TestTargetInvalidNameTemplate(t *testing.T) {
 	globs := []config.ExtraFile{
 		{
 			Glob:         "./testdata/file1.golden",
-			NameTemplate: "file1_{{.Env.HONK}}.golden",
+			NameTemplate: "file1_{{.Honk}}.golden",
 		},
 	}
 
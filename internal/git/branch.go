@@ -0,0 +1,75 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchBranch reports whether the given branch matches any of the given
+// patterns, as in path.Match, e.g. "main" or "release/*".
+// An empty list of patterns matches every branch.
+func MatchBranch(branch string, patterns []string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, branch)
+		if err != nil {
+			return false, fmt.Errorf("invalid branch pattern %q: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MatchReleaseBranch reports whether the branch being released matches any of
+// the given patterns.
+// On a detached HEAD, e.g. a CI job checking out the tag, the branch is
+// "HEAD", so the local and remote branches containing the commit are matched
+// instead; it fails if there are none, as the branch can't be known.
+func MatchReleaseBranch(ctx context.Context, branch string, patterns []string) (bool, error) {
+	if len(patterns) == 0 || branch != "HEAD" {
+		return MatchBranch(branch, patterns)
+	}
+	branches, err := containingBranches(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(branches) == 0 {
+		return false, errors.New("HEAD is detached and no branch contains it, fetch the branches to release from one of them")
+	}
+	for _, branch := range branches {
+		ok, err := MatchBranch(branch, patterns)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// containingBranches returns the names of the local and remote branches
+// containing HEAD.
+func containingBranches(ctx context.Context) ([]string, error) {
+	refs, err := CleanAllLines(Run(ctx, "for-each-ref", "--contains", "HEAD", "--format=%(refname)", "refs/heads", "refs/remotes"))
+	if err != nil {
+		return nil, fmt.Errorf("could not list the branches containing HEAD: %w", err)
+	}
+	var branches []string
+	for _, ref := range refs {
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			branches = append(branches, name)
+			continue
+		}
+		// refs/remotes/<remote>/<branch>
+		_, name, ok := strings.Cut(strings.TrimPrefix(ref, "refs/remotes/"), "/")
+		if ok && name != "HEAD" {
+			branches = append(branches, name)
+		}
+	}
+	return branches, nil
+}
@@ -0,0 +1,94 @@
+package git_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/internal/git"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchBranch(t *testing.T) {
+	for _, tt := range []struct {
+		branch   string
+		patterns []string
+		want     bool
+	}{
+		{"main", nil, true},
+		{"main", []string{"main"}, true},
+		{"feature", []string{"main"}, false},
+		{"release/v1", []string{"main", "release/*"}, true},
+		{"release/v1/fix", []string{"release/*"}, false},
+	} {
+		t.Run(tt.branch, func(t *testing.T) {
+			ok, err := git.MatchBranch(tt.branch, tt.patterns)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, ok)
+		})
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := git.MatchBranch("main", []string{"[main"})
+		require.EqualError(t, err, `invalid branch pattern "[main": syntax error in pattern`)
+	})
+}
+
+func TestMatchReleaseBranch(t *testing.T) {
+	ctx := context.Background()
+	detach := func(t *testing.T) {
+		t.Helper()
+		testlib.Mktmp(t)
+		testlib.GitInit(t)
+		testlib.GitCommit(t, "first")
+		testlib.GitTag(t, "v1.0.0")
+		testlib.GitCheckoutDetached(t, "v1.0.0")
+		branch, err := git.Clean(git.Run(ctx, "rev-parse", "--abbrev-ref", "HEAD"))
+		require.NoError(t, err)
+		require.Equal(t, "HEAD", branch)
+	}
+
+	t.Run("checked out branch", func(t *testing.T) {
+		ok, err := git.MatchReleaseBranch(ctx, "main", []string{"main"})
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("detached tag on local branch", func(t *testing.T) {
+		detach(t)
+		ok, err := git.MatchReleaseBranch(ctx, "HEAD", []string{"main"})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = git.MatchReleaseBranch(ctx, "HEAD", []string{"release/*"})
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("detached tag on remote branch", func(t *testing.T) {
+		detach(t)
+		_, err := git.Run(ctx, "update-ref", "refs/remotes/origin/release/v1", "HEAD")
+		require.NoError(t, err)
+		_, err = git.Run(ctx, "update-ref", "refs/remotes/origin/HEAD", "HEAD")
+		require.NoError(t, err)
+		_, err = git.Run(ctx, "branch", "-D", "main")
+		require.NoError(t, err)
+		ok, err := git.MatchReleaseBranch(ctx, "HEAD", []string{"release/*"})
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("detached tag without branches", func(t *testing.T) {
+		detach(t)
+		_, err := git.Run(ctx, "branch", "-D", "main")
+		require.NoError(t, err)
+		_, err = git.MatchReleaseBranch(ctx, "HEAD", []string{"main"})
+		require.EqualError(t, err, "HEAD is detached and no branch contains it, fetch the branches to release from one of them")
+	})
+
+	t.Run("detached without patterns", func(t *testing.T) {
+		ok, err := git.MatchReleaseBranch(ctx, "HEAD", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+}
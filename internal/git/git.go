@@ -18,6 +18,25 @@ func IsRepo(ctx context.Context) bool {
 }
 
 func RunWithEnv(ctx context.Context, env []string, args ...string) (string, error) {
+	stdout, stderr, err := run(ctx, env, args...)
+	if err != nil {
+		return "", errors.New(stderr)
+	}
+	return stdout, nil
+}
+
+// RunWithStderr runs a git command and returns both its output and its
+// stderr, e.g. for commands reporting on stderr even when they succeed.
+// The error, if any, contains the stderr.
+func RunWithStderr(ctx context.Context, args ...string) (string, string, error) {
+	stdout, stderr, err := run(ctx, []string{}, args...)
+	if err != nil {
+		return stdout, stderr, errors.New(stderr)
+	}
+	return stdout, stderr, nil
+}
+
+func run(ctx context.Context, env []string, args ...string) (string, string, error) {
 	extraArgs := []string{
 		"-c", "log.showSignature=false",
 	}
@@ -39,11 +58,7 @@ func RunWithEnv(ctx context.Context, env []string, args ...string) (string, erro
 		WithField("stderr", strings.TrimSpace(stderr.String())).
 		Debug("git command result")
 
-	if err != nil {
-		return "", errors.New(stderr.String())
-	}
-
-	return stdout.String(), nil
+	return stdout.String(), stderr.String(), err
 }
 
 // Run runs a git command and returns its output or errors.
@@ -2,22 +2,45 @@
 package http
 
 import (
+	"archive/tar"
+	"bytes"
+	stdctx "context"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	h "net/http"
+	"net/http/httptrace"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/caarlos0/log"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/git"
 	"github.com/goreleaser/goreleaser/internal/pipe"
 	"github.com/goreleaser/goreleaser/internal/semerrgroup"
 	"github.com/goreleaser/goreleaser/internal/tmpl"
+	"github.com/goreleaser/goreleaser/internal/yaml"
 	"github.com/goreleaser/goreleaser/pkg/config"
 	"github.com/goreleaser/goreleaser/pkg/context"
+	"github.com/goreleaser/goreleaser/pkg/delta"
 )
 
 const (
@@ -25,6 +48,46 @@ const (
 	ModeBinary = "binary"
 	// ModeArchive uploads release archives.
 	ModeArchive = "archive"
+
+	// NameTransformLowercase lowercases the artifact name before uploading.
+	NameTransformLowercase = "lowercase"
+	// NameTransformSlugify lowercases the artifact name and replaces
+	// anything that isn't a letter, digit, dot, dash or underscore with a
+	// dash.
+	NameTransformSlugify = "slugify"
+
+	// LayoutSimple uploads to <target>/<project>/<version>/.
+	LayoutSimple = "simple"
+	// LayoutGo uploads to <target>/<project>/<version>/<os>/<arch>/, for
+	// artifacts built for a platform.
+	LayoutGo = "go"
+
+	// AuthBasic sends the username and secret using basic authentication.
+	AuthBasic = "basic"
+	// AuthAPIKey sends the secret as an Artifactory API key.
+	AuthAPIKey = "apikey"
+	// AuthBearer sends the secret as a bearer token.
+	AuthBearer = "bearer"
+	// AuthGitLab sends the secret as a GitLab private token.
+	AuthGitLab = "gitlab"
+	// AuthGitLabJob sends the secret as a GitLab CI job token.
+	AuthGitLabJob = "gitlab-job"
+)
+
+// layouts are the target paths appended to the target of uploads using a
+// layout.
+// nolint: gochecknoglobals
+var layouts = map[string]string{
+	LayoutSimple: "{{ .ProjectName }}/{{ .Version }}/",
+	LayoutGo:     "{{ .ProjectName }}/{{ .Version }}/{{ if .Os }}{{ .Os }}/{{ .Arch }}{{ if .Arm }}v{{ .Arm }}{{ end }}/{{ end }}",
+}
+
+// nolint: gochecknoglobals
+var (
+	dialRetryDelay     = 250 * time.Millisecond
+	requestRetryDelay  = time.Second
+	progressInterval   = 10 * time.Second
+	lockReleaseTimeout = time.Minute
 )
 
 type asset struct {
@@ -47,6 +110,178 @@ func assetOpenReset() {
 	assetOpen = assetOpenDefault
 }
 
+// assetBuffers keeps the contents of the artifacts uploaded to several
+// instances in memory, so they are read from disk only once.
+// At most limit bytes are buffered at a time, and each artifact is dropped
+// from memory once every instance uploaded it.
+type assetBuffers struct {
+	lock    sync.Mutex
+	limit   int64
+	used    int64
+	entries map[string]*assetBuffer
+}
+
+type assetBuffer struct {
+	once sync.Once
+	// users are the instances that didn't upload the artifact yet.
+	users map[string]bool
+	data  []byte
+	err   error
+}
+
+// newAssetBuffers returns the buffers of the artifacts the given instances
+// upload, for those buffering them.
+// The smallest mirror_buffer_max_size of those instances is the memory
+// budget, so whichever instance reads an artifact first, it is buffered the
+// same way.
+// Artifacts of instances batching small files are always read from disk, as
+// the batched ones are not uploaded individually.
+func newAssetBuffers(ctx *context.Context, pending []filteredUpload) *assetBuffers {
+	b := &assetBuffers{entries: map[string]*assetBuffer{}}
+	for _, p := range pending {
+		if !p.upload.BufferForMirrors || p.upload.BatchSmallFiles.Threshold > 0 {
+			continue
+		}
+		if b.limit == 0 || p.upload.MirrorBufferMaxSize < b.limit {
+			b.limit = p.upload.MirrorBufferMaxSize
+		}
+		for _, a := range ctx.Artifacts.Filter(p.filter).List() {
+			entry, ok := b.entries[a.Path]
+			if !ok {
+				entry = &assetBuffer{users: map[string]bool{}}
+				b.entries[a.Path] = entry
+			}
+			entry.users[p.upload.Name] = true
+		}
+	}
+	return b
+}
+
+// opener returns an assetOpenFunc that reads the artifacts from memory for
+// the given instance, if they are buffered.
+func (b *assetBuffers) opener(instance string) assetOpenFunc {
+	return func(kind string, a *artifact.Artifact) (*asset, error) {
+		b.lock.Lock()
+		entry, ok := b.entries[a.Path]
+		ok = ok && entry.users[instance]
+		b.lock.Unlock()
+		if !ok {
+			return assetOpen(kind, a)
+		}
+
+		entry.once.Do(func() { b.fill(kind, a, entry) })
+		b.lock.Lock()
+		data, err := entry.data, entry.err
+		b.lock.Unlock()
+		if err != nil {
+			b.release(entry, instance)
+			return nil, err
+		}
+		if data == nil {
+			b.release(entry, instance)
+			return assetOpen(kind, a)
+		}
+		var once sync.Once
+		return &asset{
+			ReadCloser: bufferedAsset{
+				Reader: bytes.NewReader(data),
+				release: func() {
+					once.Do(func() { b.release(entry, instance) })
+				},
+			},
+			Size: int64(len(data)),
+		}, nil
+	}
+}
+
+// fill reads the artifact into memory if more than one instance uploads it
+// and it fits in what's left of the budget.
+func (b *assetBuffers) fill(kind string, a *artifact.Artifact, entry *assetBuffer) {
+	b.lock.Lock()
+	shared := len(entry.users) > 1
+	b.lock.Unlock()
+	if !shared {
+		return
+	}
+	asset, err := assetOpen(kind, a)
+	if err != nil {
+		b.lock.Lock()
+		entry.err = err
+		b.lock.Unlock()
+		return
+	}
+	defer asset.ReadCloser.Close()
+
+	b.lock.Lock()
+	fits := b.used+asset.Size <= b.limit
+	if fits {
+		b.used += asset.Size
+	}
+	b.lock.Unlock()
+	if !fits {
+		log.WithField("artifact", a.Name).
+			WithField("size", asset.Size).
+			Debug("artifact doesn't fit in the mirror buffer, reading from disk")
+		return
+	}
+
+	data, err := io.ReadAll(asset.ReadCloser)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.used -= asset.Size
+	if err != nil {
+		entry.err = err
+		return
+	}
+	b.used += int64(len(data))
+	entry.data = data
+}
+
+// release tells the given instance is done with the artifact, dropping it
+// from memory once every instance is.
+func (b *assetBuffers) release(entry *assetBuffer, instance string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(entry.users, instance)
+	if len(entry.users) == 0 && entry.data != nil {
+		b.used -= int64(len(entry.data))
+		entry.data = nil
+	}
+}
+
+// bufferedAsset reads an artifact from memory, releasing it once closed.
+type bufferedAsset struct {
+	*bytes.Reader
+	release func()
+}
+
+func (a bufferedAsset) Close() error {
+	a.release()
+	return nil
+}
+
+// nopSeekCloser is a seekable io.ReadCloser whose Close does nothing.
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+// close closes the asset, logging any failure instead of returning it so it
+// never replaces the error of the upload itself.
+func (a *asset) close(artifact *artifact.Artifact, err *error) {
+	cerr := a.ReadCloser.Close()
+	// the http transport closes the request body once it is sent.
+	if cerr == nil || errors.Is(cerr, os.ErrClosed) {
+		return
+	}
+	if *err != nil {
+		log.WithError(cerr).WithField("artifact", artifact.Name).Debug("failed to close asset after failed upload")
+		return
+	}
+	log.WithError(cerr).WithField("artifact", artifact.Name).Warn("failed to close asset")
+}
+
 func assetOpenDefault(kind string, a *artifact.Artifact) (*asset, error) {
 	f, err := os.Open(a.Path)
 	if err != nil {
@@ -80,10 +315,45 @@ func defaults(upload *config.Upload) {
 	if upload.Method == "" {
 		upload.Method = h.MethodPut
 	}
+	// a negative dial_retries disables them.
+	if upload.DialRetries == 0 {
+		upload.DialRetries = 2
+	}
+	if upload.Delta.Previous != "" && upload.Delta.Target == "" {
+		upload.Delta.Target = upload.Target
+	}
+	if upload.PropertiesFromSidecar && upload.SidecarSuffix == "" {
+		upload.SidecarSuffix = ".meta.json"
+	}
+	if upload.BatchSmallFiles.Threshold > 0 && upload.BatchSmallFiles.Size == 0 {
+		upload.BatchSmallFiles.Size = 50
+	}
+	if len(upload.Critical) > 0 && upload.CriticalGracePeriod == 0 {
+		upload.CriticalGracePeriod = 30 * time.Second
+	}
+	if upload.Lock.Path != "" && upload.Lock.TTL == 0 {
+		upload.Lock.TTL = time.Hour
+	}
+	if upload.PostUploadWait.URL != "" {
+		if upload.PostUploadWait.Interval == 0 {
+			upload.PostUploadWait.Interval = 5 * time.Second
+		}
+		if upload.PostUploadWait.Timeout == 0 {
+			upload.PostUploadWait.Timeout = 10 * time.Minute
+		}
+	}
+	if upload.BufferForMirrors && upload.MirrorBufferMaxSize == 0 {
+		upload.MirrorBufferMaxSize = 100 << 20
+	}
 }
 
 // CheckConfig validates an upload configuration returning a descriptive error when appropriate.
 func CheckConfig(ctx *context.Context, upload *config.Upload, kind string) error {
+	// disabled uploads are skipped later on, so there's nothing to check
+	if disabled, err := tmpl.New(ctx).Bool(upload.Disable); err != nil || disabled {
+		return err
+	}
+
 	if upload.Target == "" {
 		return misconfigured(kind, upload, "missing target")
 	}
@@ -96,9 +366,76 @@ func CheckConfig(ctx *context.Context, upload *config.Upload, kind string) error
 		return misconfigured(kind, upload, "mode must be 'binary' or 'archive'")
 	}
 
-	username := getUsername(ctx, upload, kind)
+	switch upload.NameTransform {
+	case "", NameTransformLowercase, NameTransformSlugify:
+	default:
+		return misconfigured(kind, upload, "name_transform must be 'lowercase' or 'slugify'")
+	}
+
+	if upload.Proxy != "" {
+		if proxy, err := url.Parse(upload.Proxy); err != nil || proxy.Host == "" {
+			return misconfigured(kind, upload, fmt.Sprintf("invalid proxy url '%s'", upload.Proxy))
+		}
+	}
+	if _, ok := layouts[upload.Layout]; upload.Layout != "" && !ok {
+		return misconfigured(kind, upload, "layout must be 'simple' or 'go'")
+	}
+	if upload.Layout != "" && upload.CustomArtifactName {
+		return misconfigured(kind, upload, "'layout' can't be used with 'custom_artifact_name'")
+	}
+
+	if upload.BatchSmallFiles.Threshold > 0 {
+		// batched artifacts are uploaded as a single tarball to the raw
+		// target, so the per artifact options can't apply to them.
+		for _, option := range []struct {
+			name string
+			set  bool
+		}{
+			{"custom_artifact_name", upload.CustomArtifactName},
+			{"layout", upload.Layout != ""},
+			{"target_map_file", upload.TargetMapFile != ""},
+			{"properties", len(upload.Properties) > 0},
+			{"properties_from_sidecar", upload.PropertiesFromSidecar},
+			{"checksum_deploy", upload.ChecksumDeploy},
+			{"verify_round_trip", len(upload.VerifyRoundTrip) > 0},
+			{"post_upload_wait", upload.PostUploadWait.URL != ""},
+			{"delta", upload.Delta.Previous != ""},
+			{"critical", len(upload.Critical) > 0},
+		} {
+			if option.set {
+				return misconfigured(kind, upload, fmt.Sprintf("'batch_small_files' can't be used with '%s'", option.name))
+			}
+		}
+	}
+
+	for _, pattern := range upload.VerifyRoundTrip {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return misconfigured(kind, upload, fmt.Sprintf("invalid verify_round_trip pattern '%s'", pattern))
+		}
+	}
+	for _, pattern := range upload.Critical {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return misconfigured(kind, upload, fmt.Sprintf("invalid critical pattern '%s'", pattern))
+		}
+	}
+
+	username, err := getUsername(ctx, upload, kind)
+	if err != nil {
+		return err
+	}
 	password := getPassword(ctx, upload, kind)
-	passwordEnv := fmt.Sprintf("%s_%s_SECRET", strings.ToUpper(kind), strings.ToUpper(upload.Name))
+	passwordEnv := getPasswordEnv(upload, kind)
+
+	switch upload.AuthType {
+	case "", AuthBasic:
+	case AuthAPIKey, AuthBearer, AuthGitLab, AuthGitLabJob:
+		if password == "" {
+			return misconfigured(kind, upload, fmt.Sprintf("environment variable '%s' is required when 'auth_type' is '%s'", passwordEnv, upload.AuthType))
+		}
+		return checkTLS(upload, kind)
+	default:
+		return misconfigured(kind, upload, "auth_type must be 'basic', 'apikey', 'bearer', 'gitlab' or 'gitlab-job'")
+	}
 
 	if password != "" && username == "" {
 		return misconfigured(kind, upload, fmt.Sprintf("'username' is required when '%s' environment variable is set", passwordEnv))
@@ -108,6 +445,23 @@ func CheckConfig(ctx *context.Context, upload *config.Upload, kind string) error
 		return misconfigured(kind, upload, fmt.Sprintf("environment variable '%s' is required when 'username' is set", passwordEnv))
 	}
 
+	return checkTLS(upload, kind)
+}
+
+// Validate checks all the given upload configurations, returning every problem
+// found, each one pointing to the offending section of the given field.
+func Validate(ctx *context.Context, uploads []config.Upload, kind, field string) error {
+	var errs []error
+	for i := range uploads {
+		upload := uploads[i]
+		if err := CheckConfig(ctx, &upload, kind); err != nil {
+			errs = append(errs, pipe.FieldErrorf(fmt.Sprintf("%s[%d]", field, i), "%s", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkTLS(upload *config.Upload, kind string) error {
 	if upload.TrustedCerts != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(upload.TrustedCerts)) {
 		return misconfigured(kind, upload, "no certificate could be added from the specified trusted_certificates configuration")
 	}
@@ -128,35 +482,120 @@ func CheckConfig(ctx *context.Context, upload *config.Upload, kind string) error
 	return nil
 }
 
-// username is optional
-func getUsername(ctx *context.Context, upload *config.Upload, kind string) string {
+// credentials authenticate the requests made to an upload instance.
+type credentials struct {
+	authType string
+	username string
+	secret   string
+}
+
+func getCredentials(ctx *context.Context, upload *config.Upload, kind string) (credentials, error) {
+	username, err := getUsername(ctx, upload, kind)
+	if err != nil {
+		return credentials{}, fmt.Errorf("%s: %s: failed to resolve username template: %w", upload.Name, kind, err)
+	}
+	return credentials{
+		authType: upload.AuthType,
+		username: username,
+		secret:   getPassword(ctx, upload, kind),
+	}, nil
+}
+
+// apply sets the credentials on the given request, if any.
+func (c credentials) apply(req *h.Request) {
+	switch c.authType {
+	case AuthAPIKey:
+		if c.secret != "" {
+			req.Header.Set("X-JFrog-Art-Api", c.secret)
+		}
+	case AuthBearer:
+		if c.secret != "" {
+			req.Header.Set("Authorization", "Bearer "+c.secret)
+		}
+	case AuthGitLab:
+		if c.secret != "" {
+			req.Header.Set("PRIVATE-TOKEN", c.secret)
+		}
+	case AuthGitLabJob:
+		if c.secret != "" {
+			req.Header.Set("JOB-TOKEN", c.secret)
+		}
+	default:
+		if c.username != "" && c.secret != "" {
+			req.SetBasicAuth(c.username, c.secret)
+		}
+	}
+}
+
+// username is optional, and may be a template
+func getUsername(ctx *context.Context, upload *config.Upload, kind string) (string, error) {
 	if upload.Username != "" {
-		return upload.Username
+		return tmpl.New(ctx).Apply(upload.Username)
 	}
 
 	key := fmt.Sprintf("%s_%s_USERNAME", strings.ToUpper(kind), strings.ToUpper(upload.Name))
-	return ctx.Env[key]
+	return ctx.Env[key], nil
 }
 
 // password is optional
 func getPassword(ctx *context.Context, upload *config.Upload, kind string) string {
-	key := fmt.Sprintf("%s_%s_SECRET", strings.ToUpper(kind), strings.ToUpper(upload.Name))
-	return ctx.Env[key]
+	return ctx.Env[getPasswordEnv(upload, kind)]
+}
+
+// getPasswordEnv returns the name of the environment variable holding the
+// password of the given upload.
+func getPasswordEnv(upload *config.Upload, kind string) string {
+	if upload.SecretEnv != "" {
+		return upload.SecretEnv
+	}
+	return fmt.Sprintf("%s_%s_SECRET", strings.ToUpper(kind), strings.ToUpper(upload.Name))
 }
 
 func misconfigured(kind string, upload *config.Upload, reason string) error {
-	return pipe.Skipf("%s section '%s' is not configured properly (%s)", kind, upload.Name, reason)
+	return pipe.SkipWithf(pipe.SkipNotConfigured, "%s section '%s' is not configured properly (%s)", kind, upload.Name, reason)
 }
 
 // ResponseChecker is a function capable of validating an http server response.
 // It must return and error when the response must be considered a failure.
-type ResponseChecker func(*h.Response) error
+// The artifact is nil when the request didn't upload a single artifact, e.g.
+// batches and deltas.
+type ResponseChecker func(*h.Response, *config.Upload, *artifact.Artifact) error
+
+// bind binds the given checker to an upload and artifact.
+func (check ResponseChecker) bind(upload *config.Upload, artifact *artifact.Artifact) func(*h.Response) error {
+	return func(res *h.Response) error {
+		return check(res, upload, artifact)
+	}
+}
 
 // Upload does the actual uploading work.
 func Upload(ctx *context.Context, uploads []config.Upload, kind string, check ResponseChecker) error {
-	// Handle every configured upload
-	for _, upload := range uploads {
+	skips := pipe.SkipMemento{}
+	var plan []PlanEntry
+	var pending []filteredUpload
+	// Handle every configured upload, higher priorities first
+	for _, upload := range byPriority(uploads) {
 		upload := upload
+		disabled, err := tmpl.New(ctx).Bool(upload.Disable)
+		if err != nil {
+			return err
+		}
+		if disabled {
+			log.WithField("instance", upload.Name).Info("skipping disabled instance")
+			skips.Remember(pipe.SkipWithf(pipe.SkipDisabled, "%s: %s is disabled", kind, upload.Name))
+			continue
+		}
+		allowed, err := git.MatchReleaseBranch(ctx, ctx.Git.Branch, upload.Branches)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", kind, upload.Name, err)
+		}
+		if !allowed {
+			log.WithField("instance", upload.Name).
+				WithField("branch", ctx.Git.Branch).
+				Info("skipping instance, branch not allowed")
+			skips.Remember(pipe.SkipWithf(pipe.SkipDisabled, "%s: %s is disabled on branch %q", kind, upload.Name, ctx.Git.Branch))
+			continue
+		}
 		filters := []artifact.Filter{}
 		if upload.Checksum {
 			filters = append(filters, artifact.ByType(artifact.Checksum))
@@ -167,6 +606,9 @@ func Upload(ctx *context.Context, uploads []config.Upload, kind string, check Re
 		if upload.Signature {
 			filters = append(filters, artifact.ByType(artifact.Signature), artifact.ByType(artifact.Certificate))
 		}
+		if upload.SBOM {
+			filters = append(filters, artifact.ByType(artifact.SBOM))
+		}
 		// We support two different modes
 		//	- "archive": Upload all artifacts
 		//	- "binary": Upload only the raw binaries
@@ -190,130 +632,1513 @@ func Upload(ctx *context.Context, uploads []config.Upload, kind string, check Re
 		if len(upload.Exts) > 0 {
 			filter = artifact.And(filter, artifact.ByExt(upload.Exts...))
 		}
-		if err := uploadWithFilter(ctx, &upload, filter, kind, check); err != nil {
+		if ctx.PrintUploadPlan {
+			entries, err := planUpload(ctx, &upload, filter, kind)
+			if err != nil {
+				return err
+			}
+			plan = append(plan, entries...)
+			continue
+		}
+		if upload.DryRun {
+			if err := dryRun(ctx, &upload, filter, kind); err != nil {
+				return err
+			}
+			skips.Remember(pipe.SkipWithf(pipe.SkipDisabled, "%s: %s is a dry run", kind, upload.Name))
+			continue
+		}
+		pending = append(pending, filteredUpload{upload: upload, filter: filter})
+	}
+
+	if ctx.PrintUploadPlan {
+		if err := writePlan(ctx, kind, plan); err != nil {
 			return err
 		}
+		return pipe.SkipWithf(pipe.SkipDisabled, "%s: upload plan written, not uploading", kind)
+	}
+	if grace := criticalGracePeriod(pending); grace > 0 {
+		defer ctx.CriticalUploads.Start(grace)()
+	}
+	published, err := uploadAll(ctx, pending, kind, check, newAssetBuffers(ctx, pending))
+	if err != nil {
+		return err
+	}
+	if err := addPublishTags(ctx, published, kind); err != nil {
+		return err
 	}
+	return skips.Evaluate()
+}
 
-	return nil
+type filteredUpload struct {
+	upload config.Upload
+	filter artifact.Filter
+}
+
+// uploadAll uploads the artifacts to every given instance, returning the ones
+// that succeeded.
+// Instances with the same priority are handled concurrently, and a priority is
+// only handled after all the higher ones are done.
+// Once ctx is done, only the instances with critical uploads keep going, even
+// when failing fast.
+func uploadAll(ctx *context.Context, pending []filteredUpload, kind string, check ResponseChecker, buffers *assetBuffers) ([]config.Upload, error) {
+	var published []config.Upload
+	var errs []error
+	for len(pending) > 0 {
+		n := 1
+		for n < len(pending) && pending[n].upload.Priority == pending[0].upload.Priority {
+			n++
+		}
+		group := pending[:n]
+		pending = pending[n:]
+
+		results := make([]error, len(group))
+		var failed atomic.Bool
+		g := semerrgroup.New(ctx.Parallelism)
+		for i := range group {
+			i := i
+			g.Go(func() error {
+				if ctx.FailFast && failed.Load() && !criticalAfterDone(ctx, &group[i].upload) {
+					return nil
+				}
+				results[i] = uploadWithFilter(ctx, &group[i].upload, group[i].filter, kind, check, buffers)
+				if results[i] != nil {
+					failed.Store(true)
+				}
+				return nil
+			})
+		}
+		_ = g.Wait()
+
+		for i, err := range results {
+			if err == nil {
+				published = append(published, group[i].upload)
+				continue
+			}
+			if ctx.FailFast && ctx.Err() == nil {
+				return nil, err
+			}
+			errs = append(errs, err)
+		}
+		if ctx.Err() != nil {
+			pending = slices.DeleteFunc(pending, func(f filteredUpload) bool {
+				return !criticalAfterDone(ctx, &f.upload)
+			})
+		}
+	}
+	return published, joinErrors(errs)
+}
+
+// criticalAfterDone tells whether ctx is done and the given instance must still
+// be handled because it has critical uploads.
+func criticalAfterDone(ctx *context.Context, upload *config.Upload) bool {
+	return ctx.Err() != nil && len(upload.Critical) > 0
 }
 
-func uploadWithFilter(ctx *context.Context, upload *config.Upload, filter artifact.Filter, kind string, check ResponseChecker) error {
+func uploadWithFilter(ctx *context.Context, upload *config.Upload, filter artifact.Filter, kind string, check ResponseChecker, buffers *assetBuffers) error {
 	artifacts := ctx.Artifacts.Filter(filter).List()
 	if len(artifacts) == 0 {
 		log.Info("no artifacts found")
 	}
 	log.Debugf("will upload %d artifacts", len(artifacts))
-	g := semerrgroup.New(ctx.Parallelism)
+	if len(artifacts) < upload.MinArtifacts {
+		return fmt.Errorf(
+			"%s: %s: expected at least %d artifacts to upload, found %d",
+			upload.Name, kind, upload.MinArtifacts, len(artifacts),
+		)
+	}
+	targets, err := resolveTargetURLs(ctx, upload, artifacts, kind)
+	if err != nil {
+		return err
+	}
+	budget := newRetryBudget(ctx, upload, kind)
+	client, err := getHTTPClient(upload, budget)
+	if err != nil {
+		return err
+	}
+	defer closeIdleConnections(client)
+	// The lock and the critical uploads outlive ctx for the grace period, so
+	// the critical uploads can still start and finish after it is done.
+	criticalCtx := ctx
+	if len(upload.Critical) > 0 {
+		graced, cancel := graceContext(ctx, upload.CriticalGracePeriod)
+		defer cancel()
+		criticalCtx = graced
+	}
+	if upload.Lock.Path != "" {
+		if err := acquireLock(criticalCtx, upload, kind); err != nil {
+			return err
+		}
+	}
+	g := &uploadGroup{ctx: ctx, g: semerrgroup.New(ctx.Parallelism), failFast: ctx.FailFast}
+	if upload.BatchSmallFiles.Threshold > 0 {
+		batches, rest, err := batchSmallArtifacts(ctx, upload, artifacts, kind)
+		if err != nil {
+			return err
+		}
+		for _, b := range batches {
+			b := b
+			g.Go(upload, b.target, false, func() error {
+				return uploadBatch(ctx, upload, client, budget, b, kind, check)
+			})
+		}
+		artifacts = rest
+	}
 	for _, artifact := range artifacts {
 		artifact := artifact
-		g.Go(func() error {
-			return uploadAsset(ctx, upload, artifact, kind, check)
+		if matchesAny(upload.Critical, artifact.Name) {
+			g.Go(upload, targets[artifact], true, func() error {
+				return uploadAsset(criticalCtx, upload, client, budget, artifact, targets[artifact], kind, check, buffers)
+			})
+			continue
+		}
+		g.Go(upload, targets[artifact], false, func() error {
+			return uploadAsset(ctx, upload, client, budget, artifact, targets[artifact], kind, check, buffers)
 		})
 	}
 	return g.Wait()
 }
 
-// uploadAsset uploads file to target and logs all actions.
-func uploadAsset(ctx *context.Context, upload *config.Upload, artifact *artifact.Artifact, kind string, check ResponseChecker) error {
-	// username and secret are optional since the server may not support/need
-	// basic authentication always
-	username := getUsername(ctx, upload, kind)
-	secret := getPassword(ctx, upload, kind)
+// uploadGroup runs the uploads of an instance.
+// With fail fast, the first failure is returned and the uploads not started
+// yet are skipped, except for the critical ones once ctx is done; otherwise,
+// all the uploads run and every failure is returned.
+type uploadGroup struct {
+	ctx      *context.Context
+	g        semerrgroup.Group
+	failFast bool
+	failed   atomic.Bool
+	lock     sync.Mutex
+	errs     []error
+}
 
-	// Generate the target url
-	targetURL, err := tmpl.New(ctx).WithArtifact(artifact).Apply(upload.Target)
-	if err != nil {
-		return fmt.Errorf("%s: %s: error while building target URL: %w", upload.Name, kind, err)
-	}
+func (g *uploadGroup) Go(upload *config.Upload, target string, critical bool, fn func() error) {
+	g.g.Go(func() error {
+		if g.failFast && g.failed.Load() && (!critical || g.ctx.Err() == nil) {
+			return nil
+		}
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		g.failed.Store(true)
+		if g.failFast {
+			return err
+		}
+		log.WithField("instance", upload.Name).
+			WithField("target", target).
+			WithError(err).
+			Error("upload failed")
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		g.errs = append(g.errs, err)
+		return nil
+	})
+}
 
-	// Handle the artifact
-	asset, err := assetOpen(kind, artifact)
-	if err != nil {
+func (g *uploadGroup) Wait() error {
+	if err := g.g.Wait(); err != nil {
 		return err
 	}
-	defer asset.ReadCloser.Close()
+	return joinErrors(g.errs)
+}
 
-	// target url need to contain the artifact name unless the custom
-	// artifact name is used
-	if !upload.CustomArtifactName {
-		if !strings.HasSuffix(targetURL, "/") {
-			targetURL += "/"
-		}
-		targetURL += artifact.Name
-	}
-	log.Debugf("generated target url: %s", targetURL)
+// joinErrors joins the given errors sorted by their messages, so the result
+// doesn't depend on the order they happened in.
+func joinErrors(errs []error) error {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+	return errors.Join(errs...)
+}
 
-	headers := make(map[string]string, len(upload.CustomHeaders))
-	for name, value := range upload.CustomHeaders {
-		resolvedValue, err := tmpl.New(ctx).WithArtifact(artifact).Apply(value)
-		if err != nil {
-			return fmt.Errorf("%s: %s: failed to resolve custom_headers template: %w", upload.Name, kind, err)
+// addPublishTags adds the tags of the given instances, which uploaded every
+// artifact successfully, to the ones created once publishing is done.
+func addPublishTags(ctx *context.Context, uploads []config.Upload, kind string) error {
+	for _, upload := range uploads {
+		if upload.TagAfterPublish == "" {
+			continue
 		}
-		headers[name] = resolvedValue
-	}
-	if upload.ChecksumHeader != "" {
-		sum, err := artifact.Checksum("sha256")
+		tag, err := tmpl.New(ctx).Apply(upload.TagAfterPublish)
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %s: failed to resolve tag_after_publish template: %w", upload.Name, kind, err)
+		}
+		if !slices.Contains(ctx.PublishTags, tag) {
+			ctx.PublishTags = append(ctx.PublishTags, tag)
 		}
-		headers[upload.ChecksumHeader] = sum
 	}
+	return nil
+}
 
-	res, err := uploadAssetToServer(ctx, upload, targetURL, username, secret, headers, asset, check)
-	if err != nil {
-		return fmt.Errorf("%s: %s: upload failed: %w", upload.Name, kind, err)
+// TagPublished creates and pushes the tags marking the release commit as
+// published.
+// It should be called once every publisher succeeded.
+// Tags already pointing to the release commit, e.g. when a release is run
+// again, are only pushed.
+func TagPublished(ctx *context.Context) error {
+	if ctx.Snapshot {
+		return nil
 	}
-	if err := res.Body.Close(); err != nil {
-		log.WithError(err).Warn("failed to close response body")
+	for _, tag := range ctx.PublishTags {
+		existing, err := git.Clean(git.Run(ctx, "tag", "--list", tag))
+		if err != nil {
+			return fmt.Errorf("failed to check tag %s: %w", tag, err)
+		}
+		if existing == "" {
+			if _, err := git.Clean(git.Run(ctx, "tag", tag, ctx.Git.FullCommit)); err != nil {
+				return fmt.Errorf("failed to create tag %s: %w", tag, err)
+			}
+		} else {
+			commit, err := git.Clean(git.Run(ctx, "rev-list", "-n1", "refs/tags/"+tag))
+			if err != nil {
+				return fmt.Errorf("failed to check tag %s: %w", tag, err)
+			}
+			if commit != ctx.Git.FullCommit {
+				return fmt.Errorf("tag %s already exists on commit %s", tag, commit)
+			}
+		}
+		if _, err := git.Clean(git.Run(ctx, "push", "origin", "refs/tags/"+tag)); err != nil {
+			return fmt.Errorf("failed to push tag %s: %w", tag, err)
+		}
+		log.WithField("tag", tag).Info("tagged published commit")
 	}
+	return nil
+}
 
-	log.WithField("instance", upload.Name).
-		WithField("mode", upload.Mode).
-		Info("uploaded successful")
+// byPriority returns the uploads sorted by priority, from the highest to the
+// lowest, keeping the configuration order for equal priorities.
+func byPriority(uploads []config.Upload) []config.Upload {
+	result := make([]config.Upload, len(uploads))
+	copy(result, uploads)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Priority > result[j].Priority
+	})
+	return result
+}
 
-	return nil
+// PlanEntry is a single upload of the upload plan.
+type PlanEntry struct {
+	Instance string `json:"instance"`
+	Artifact string `json:"artifact"`
+	Path     string `json:"path"`
+	Target   string `json:"target"`
 }
 
-// uploadAssetToServer uploads the asset file to target.
-func uploadAssetToServer(ctx *context.Context, upload *config.Upload, target, username, secret string, headers map[string]string, a *asset, check ResponseChecker) (*h.Response, error) {
-	req, err := newUploadRequest(ctx, upload.Method, target, username, secret, headers, a)
+// planUpload resolves where each artifact would be uploaded to, without
+// uploading anything.
+func planUpload(ctx *context.Context, upload *config.Upload, filter artifact.Filter, kind string) ([]PlanEntry, error) {
+	artifacts := ctx.Artifacts.Filter(filter).List()
+	targets, err := resolveTargetURLs(ctx, upload, artifacts, kind)
 	if err != nil {
 		return nil, err
 	}
-
-	return executeHTTPRequest(ctx, upload, req, check)
+	entries := make([]PlanEntry, 0, len(artifacts))
+	for _, a := range artifacts {
+		entries = append(entries, PlanEntry{
+			Instance: upload.Name,
+			Artifact: a.Name,
+			Path:     a.Path,
+			Target:   targets[a],
+		})
+	}
+	return entries, nil
 }
 
-// newUploadRequest creates a new h.Request for uploading.
-func newUploadRequest(ctx *context.Context, method, target, username, secret string, headers map[string]string, a *asset) (*h.Request, error) {
-	req, err := h.NewRequestWithContext(ctx, method, target, a.ReadCloser)
+// dryRun logs where each artifact would be uploaded to, without uploading
+// anything.
+func dryRun(ctx *context.Context, upload *config.Upload, filter artifact.Filter, kind string) error {
+	entries, err := planUpload(ctx, upload, filter, kind)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req.ContentLength = a.Size
-
-	if username != "" && secret != "" {
-		req.SetBasicAuth(username, secret)
+	for _, entry := range entries {
+		stat, err := os.Stat(entry.Path)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", upload.Name, kind, err)
+		}
+		log.WithField("instance", upload.Name).
+			WithField("artifact", entry.Artifact).
+			WithField("target", entry.Target).
+			WithField("size", stat.Size()).
+			Info("dry run, would upload")
 	}
+	return nil
+}
 
-	for k, v := range headers {
-		req.Header.Add(k, v)
+// writePlan writes the upload plan to the dist directory, both as JSON and
+// as a graphviz dot graph.
+func writePlan(ctx *context.Context, kind string, plan []PlanEntry) error {
+	bts, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(ctx.Config.Dist, kind+"-plan.json")
+	if err := os.WriteFile(path, bts, 0o644); err != nil {
+		return fmt.Errorf("%s: failed to write upload plan: %w", kind, err)
 	}
 
-	return req, err
+	var dot strings.Builder
+	dot.WriteString("digraph plan {\n\trankdir=LR;\n")
+	for _, e := range plan {
+		fmt.Fprintf(&dot, "\t%q -> %q [label=%q];\n", e.Artifact, e.Target, e.Instance)
+	}
+	dot.WriteString("}\n")
+	dotPath := filepath.Join(ctx.Config.Dist, kind+"-plan.dot")
+	if err := os.WriteFile(dotPath, []byte(dot.String()), 0o644); err != nil {
+		return fmt.Errorf("%s: failed to write upload plan: %w", kind, err)
+	}
+	log.WithField("json", path).
+		WithField("dot", dotPath).
+		WithField("uploads", len(plan)).
+		Info("wrote upload plan")
+	return nil
 }
 
-func getHTTPClient(upload *config.Upload) (*h.Client, error) {
-	if upload.TrustedCerts == "" && upload.ClientX509Cert == "" && upload.ClientX509Key == "" {
-		return h.DefaultClient, nil
-	}
-	transport := &h.Transport{
-		Proxy:           h.ProxyFromEnvironment,
-		TLSClientConfig: &tls.Config{},
+// resolveTargetURLs resolves the target URL of every artifact, checking that
+// no two artifacts would be uploaded to the same URL.
+func resolveTargetURLs(ctx *context.Context, upload *config.Upload, artifacts []*artifact.Artifact, kind string) (map[*artifact.Artifact]string, error) {
+	targetMap, err := loadTargetMap(upload)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", upload.Name, kind, err)
 	}
-	if upload.TrustedCerts != "" {
-		pool, err := x509.SystemCertPool()
+	targets := make(map[*artifact.Artifact]string, len(artifacts))
+	seen := map[string][]string{}
+	var collisions []string
+	for _, a := range artifacts {
+		target, err := resolveTargetURL(ctx, upload, a, targetMap[a.Name], kind)
+		if err != nil {
+			return nil, err
+		}
+		targets[a] = target
+		// artifacts deployed with different properties still overwrite
+		// each other.
+		deployed := DeployedURL(target)
+		seen[deployed] = append(seen[deployed], a.Path)
+		if len(seen[deployed]) == 2 {
+			collisions = append(collisions, deployed)
+		}
+	}
+	for _, target := range collisions {
+		log.WithField("instance", upload.Name).
+			WithField("target", target).
+			WithField("artifacts", strings.Join(seen[target], ", ")).
+			Warn("multiple artifacts resolve to the same target")
+	}
+	if len(collisions) > 0 && !upload.AllowTargetCollisions {
+		return nil, fmt.Errorf(
+			"%s: %s: %d target(s) would be overwritten by another artifact, check your target template or set 'allow_target_collisions'",
+			upload.Name, kind, len(collisions),
+		)
+	}
+	return targets, nil
+}
+
+// DeployedURL returns the URL an artifact is deployed to, without the query
+// and the matrix parameters of its target.
+func DeployedURL(target string) string {
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		target = target[:i]
+	}
+	if i := strings.LastIndex(target, "/"); i >= 0 {
+		if j := strings.Index(target[i:], ";"); j >= 0 {
+			target = target[:i+j]
+		}
+	}
+	return target
+}
+
+// loadTargetMap loads the artifact name to target mapping of the given upload,
+// if any.
+// The file can be either YAML or JSON, duplicated keys are not allowed.
+func loadTargetMap(upload *config.Upload) (map[string]string, error) {
+	if upload.TargetMapFile == "" {
+		return nil, nil
+	}
+	bts, err := os.ReadFile(upload.TargetMapFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target map: %w", err)
+	}
+	var targets map[string]string
+	if err := yaml.UnmarshalStrict(bts, &targets); err != nil {
+		return nil, fmt.Errorf("failed to parse target map %s: %w", upload.TargetMapFile, err)
+	}
+	for name, target := range targets {
+		if strings.TrimSpace(target) == "" {
+			return nil, fmt.Errorf("target map %s: empty target for %s", upload.TargetMapFile, name)
+		}
+	}
+	return targets, nil
+}
+
+// resolveTargetURL builds the URL the given artifact should be uploaded to.
+// The target template is used unless a mapped target is given.
+func resolveTargetURL(ctx *context.Context, upload *config.Upload, artifact *artifact.Artifact, mapped, kind string) (string, error) {
+	target := upload.Target
+	if layout, ok := layouts[upload.Layout]; ok {
+		target = strings.TrimSuffix(target, "/") + "/" + layout
+	}
+	if mapped != "" {
+		target = mapped
+	}
+	targetURL, err := tmpl.New(ctx).WithArtifact(artifact).Apply(target)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s: error while building target URL: %w", upload.Name, kind, err)
+	}
+
+	// target url need to contain the artifact name unless the custom
+	// artifact name is used
+	if !upload.CustomArtifactName {
+		if !strings.HasSuffix(targetURL, "/") {
+			targetURL += "/"
+		}
+		targetURL += transformName(upload, artifact.Name)
+	}
+
+	props := make(map[string]string, len(upload.Properties))
+	for key, value := range upload.Properties {
+		resolved, err := tmpl.New(ctx).WithArtifact(artifact).Apply(value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s: failed to resolve properties template: %w", upload.Name, kind, err)
+		}
+		props[key] = resolved
+	}
+	if upload.PropertiesFromSidecar {
+		sidecar, err := sidecarProperties(upload, artifact)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s: %w", upload.Name, kind, err)
+		}
+		for key, value := range sidecar {
+			props[key] = value
+		}
+	}
+	return targetURL + matrixParams(props), nil
+}
+
+// sidecarProperties reads the properties of the given artifact from its
+// sidecar file, if any.
+// The sidecar must be a flat JSON object, nested values are ignored.
+func sidecarProperties(upload *config.Upload, artifact *artifact.Artifact) (map[string]string, error) {
+	path := artifact.Path + upload.SidecarSuffix
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidecar: %w", err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(bts, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse sidecar %s: %w", path, err)
+	}
+	props := make(map[string]string, len(values))
+	for k, v := range values {
+		switch v := v.(type) {
+		case map[string]any, []any:
+			log.WithField("sidecar", path).
+				WithField("key", k).
+				Warn("ignoring nested sidecar value")
+		case nil:
+			props[k] = ""
+		default:
+			props[k] = fmt.Sprint(v)
+		}
+	}
+	return props, nil
+}
+
+// matrixParams formats the given properties as matrix parameters, sorted by
+// key, e.g. ";a=1;b=2".
+func matrixParams(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(";")
+		sb.WriteString(matrixEscape(k))
+		sb.WriteString("=")
+		sb.WriteString(matrixEscape(props[k]))
+	}
+	return sb.String()
+}
+
+func matrixEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// batch is a group of small artifacts uploaded as a single tarball.
+type batch struct {
+	target    string
+	artifacts []*artifact.Artifact
+}
+
+// batchSmallArtifacts groups the artifacts smaller than the configured
+// threshold by their target, and splits each group into batches of the
+// configured size.
+// The remaining artifacts should be uploaded individually.
+func batchSmallArtifacts(ctx *context.Context, upload *config.Upload, artifacts []*artifact.Artifact, kind string) ([]batch, []*artifact.Artifact, error) {
+	var rest []*artifact.Artifact
+	var targets []string
+	groups := map[string][]*artifact.Artifact{}
+	for _, a := range artifacts {
+		stat, err := os.Stat(a.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if stat.Size() >= upload.BatchSmallFiles.Threshold {
+			rest = append(rest, a)
+			continue
+		}
+		target, err := tmpl.New(ctx).WithArtifact(a).Apply(upload.Target)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s: error while building target URL: %w", upload.Name, kind, err)
+		}
+		if !strings.HasSuffix(target, "/") {
+			target += "/"
+		}
+		if _, ok := groups[target]; !ok {
+			targets = append(targets, target)
+		}
+		groups[target] = append(groups[target], a)
+	}
+
+	var batches []batch
+	for _, target := range targets {
+		group := groups[target]
+		for i := 0; i < len(group); i += upload.BatchSmallFiles.Size {
+			batches = append(batches, batch{
+				target:    fmt.Sprintf("%sbatch-%d.tar", target, i/upload.BatchSmallFiles.Size),
+				artifacts: group[i:min(i+upload.BatchSmallFiles.Size, len(group))],
+			})
+		}
+	}
+	return batches, rest, nil
+}
+
+// uploadBatch packs the artifacts of the given batch in a tarball and uploads
+// it, asking the server to extract it into the target directory.
+func uploadBatch(ctx *context.Context, upload *config.Upload, client *h.Client, budget *retryBudget, b batch, kind string, check ResponseChecker) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, a := range b.artifacts {
+		asset, err := assetOpen(kind, a)
+		if err != nil {
+			return err
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Name:    transformName(upload, a.Name),
+			Mode:    0o644,
+			Size:    asset.Size,
+			ModTime: ctx.Date,
+		})
+		if err == nil {
+			_, err = io.Copy(tw, asset.ReadCloser)
+		}
+		asset.ReadCloser.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %s: failed to add %s to batch: %w", upload.Name, kind, a.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("%s: %s: failed to create batch: %w", upload.Name, kind, err)
+	}
+
+	headers := map[string]string{"X-Explode-Archive": "true"}
+	for name, value := range upload.CustomHeaders {
+		resolvedValue, err := tmpl.New(ctx).Apply(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s: failed to resolve custom_headers template: %w", upload.Name, kind, err)
+		}
+		headers[name] = resolvedValue
+	}
+
+	creds, err := getCredentials(ctx, upload, kind)
+	if err != nil {
+		return err
+	}
+	res, err := uploadAssetToServer(ctx, upload, client, budget, b.target, creds, headers, &asset{
+		ReadCloser: nopSeekCloser{bytes.NewReader(buf.Bytes())},
+		Size:       int64(buf.Len()),
+	}, check.bind(upload, nil))
+	if err != nil {
+		return fmt.Errorf("%s: %s: upload failed: %w", upload.Name, kind, err)
+	}
+	if err := res.Body.Close(); err != nil {
+		log.WithError(err).Warn("failed to close response body")
+	}
+
+	log.WithField("instance", upload.Name).
+		WithField("target", b.target).
+		WithField("artifacts", len(b.artifacts)).
+		Info("uploaded batch successful")
+	return nil
+}
+
+// uploadAsset uploads file to target and logs all actions.
+func uploadAsset(ctx *context.Context, upload *config.Upload, client *h.Client, budget *retryBudget, artifact *artifact.Artifact, targetURL, kind string, check ResponseChecker, buffers *assetBuffers) (err error) {
+	// username and secret are optional since the server may not support/need
+	// basic authentication always
+	creds, err := getCredentials(ctx, upload, kind)
+	if err != nil {
+		return err
+	}
+
+	// Handle the artifact
+	open := assetOpen
+	if upload.BufferForMirrors {
+		open = buffers.opener(upload.Name)
+	}
+	asset, err := open(kind, artifact)
+	if err != nil {
+		return err
+	}
+	defer asset.close(artifact, &err)
+
+	log.Debugf("generated target url: %s", targetURL)
+
+	headers := make(map[string]string, len(upload.CustomHeaders))
+	for name, value := range upload.CustomHeaders {
+		resolvedValue, err := tmpl.New(ctx).WithArtifact(artifact).Apply(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s: failed to resolve custom_headers template: %w", upload.Name, kind, err)
+		}
+		headers[name] = resolvedValue
+	}
+	if upload.ChecksumHeader != "" {
+		sum, err := artifact.Hash("sha256")
+		if err != nil {
+			return err
+		}
+		headers[upload.ChecksumHeader] = sum
+	}
+	if !hasHeader(headers, "Content-Type") {
+		contentType, err := tmpl.New(ctx).WithArtifact(artifact).Apply(upload.ContentType)
+		if err != nil {
+			return fmt.Errorf("%s: %s: failed to resolve content_type template: %w", upload.Name, kind, err)
+		}
+		if contentType == "" {
+			contentType = detectContentType(artifact.Name)
+		}
+		log.WithField("artifact", artifact.Name).
+			WithField("content-type", contentType).
+			Debug("content type")
+		headers["Content-Type"] = contentType
+	}
+
+	if upload.ChecksumDeploy {
+		for header, algorithm := range map[string]string{
+			"X-Checksum-Sha256": "sha256",
+			"X-Checksum-Sha1":   "sha1",
+		} {
+			if strings.EqualFold(header, upload.ChecksumHeader) {
+				continue
+			}
+			sum, err := artifact.Hash(algorithm)
+			if err != nil {
+				return err
+			}
+			headers[header] = sum
+		}
+		hit, err := checksumDeploy(ctx, upload, client, targetURL, creds, headers, check.bind(upload, artifact))
+		if err != nil {
+			return fmt.Errorf("%s: %s: checksum deploy failed: %w", upload.Name, kind, err)
+		}
+		if hit {
+			log.WithField("instance", upload.Name).
+				WithField("artifact", artifact.Name).
+				Info("checksum deploy hit, upload skipped")
+			return afterUpload(ctx, upload, client, budget, artifact, targetURL, creds, kind, check)
+		}
+		log.WithField("instance", upload.Name).
+			WithField("artifact", artifact.Name).
+			Debug("checksum deploy miss, uploading")
+	}
+
+	res, err := uploadAssetToServer(ctx, upload, client, budget, targetURL, creds, headers, asset, check.bind(upload, artifact))
+	if err != nil {
+		return fmt.Errorf("%s: %s: upload failed: %w", upload.Name, kind, err)
+	}
+	if err := res.Body.Close(); err != nil {
+		log.WithError(err).Warn("failed to close response body")
+	}
+	// the server may have redirected the upload somewhere else.
+	targetURL = res.Request.URL.String()
+
+	log.WithField("instance", upload.Name).
+		WithField("artifact", artifact.Name).
+		WithField("mode", upload.Mode).
+		Info("uploaded successful")
+
+	return afterUpload(ctx, upload, client, budget, artifact, targetURL, creds, kind, check)
+}
+
+// afterUpload runs the steps that follow a successful upload of the given
+// artifact.
+func afterUpload(ctx *context.Context, upload *config.Upload, client *h.Client, budget *retryBudget, artifact *artifact.Artifact, targetURL string, creds credentials, kind string, check ResponseChecker) error {
+	if matchesAny(upload.VerifyRoundTrip, artifact.Name) {
+		if err := verifyRoundTrip(ctx, client, artifact, targetURL, creds); err != nil {
+			return fmt.Errorf("%s: %s: round trip verification failed: %w", upload.Name, kind, err)
+		}
+	}
+
+	if upload.PostUploadWait.URL != "" {
+		if err := waitProcessing(ctx, upload, client, artifact, targetURL, creds); err != nil {
+			return fmt.Errorf("%s: %s: %w", upload.Name, kind, err)
+		}
+	}
+
+	if upload.Delta.Previous != "" {
+		return uploadDelta(ctx, upload, client, budget, artifact, creds, kind, check)
+	}
+	return nil
+}
+
+// contentTypes are the content types of the artifacts which extensions
+// aren't always known by the system.
+// nolint: gochecknoglobals
+var contentTypes = map[string]string{
+	".tar.gz": "application/gzip",
+	".tgz":    "application/gzip",
+	".gz":     "application/gzip",
+	".tar":    "application/x-tar",
+	".xz":     "application/x-xz",
+	".txz":    "application/x-xz",
+	".zst":    "application/zstd",
+	".tzst":   "application/zstd",
+	".zip":    "application/zip",
+	".deb":    "application/vnd.debian.binary-package",
+	".rpm":    "application/x-rpm",
+	".txt":    "text/plain; charset=utf-8",
+	".json":   "application/json",
+}
+
+// detectContentType returns the content type of an artifact based on its
+// extension, falling back to application/octet-stream.
+func detectContentType(name string) string {
+	for _, ext := range []string{".tar.gz", filepath.Ext(name)} {
+		if !strings.HasSuffix(name, ext) || ext == "" {
+			continue
+		}
+		if contentType, ok := contentTypes[ext]; ok {
+			return contentType
+		}
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// hasHeader reports whether headers has the given header, ignoring case.
+func hasHeader(headers map[string]string, header string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// checksumDeploy asks the server to deploy the artifact from a blob it already
+// has with the same checksums, without sending its contents.
+// It returns false if the server doesn't have it, in which case the artifact
+// should be uploaded as usual.
+//
+// Docs: https://jfrog.com/help/r/jfrog-rest-apis/deploy-artifact-by-checksum
+func checksumDeploy(ctx *context.Context, upload *config.Upload, client *h.Client, targetURL string, creds credentials, headers map[string]string, check func(*h.Response) error) (bool, error) {
+	req, err := h.NewRequestWithContext(ctx, upload.Method, targetURL, nil)
+	if err != nil {
+		return false, err
+	}
+	creds.apply(req)
+	for k, v := range headers {
+		req.Header.Add(k, v)
+	}
+	req.Header.Set("X-Checksum-Deploy", "true")
+
+	var miss bool
+	_, err = executeHTTPRequest(ctx, upload, client, req, func(r *h.Response) error {
+		if r.StatusCode == h.StatusNotFound {
+			miss = true
+			return nil
+		}
+		return check(r)
+	})
+	if err != nil {
+		return false, err
+	}
+	return !miss, nil
+}
+
+// waitProcessing polls the post upload status URL of the given artifact until
+// the server reports its processing is done.
+// The server should answer 202 while processing, and 200 once done; any other
+// status is considered a processing error.
+func waitProcessing(ctx *context.Context, upload *config.Upload, client *h.Client, artifact *artifact.Artifact, targetURL string, creds credentials) error {
+	statusURL, err := tmpl.New(ctx).
+		WithArtifact(artifact).
+		WithExtraFields(tmpl.Fields{"Target": targetURL}).
+		Apply(upload.PostUploadWait.URL)
+	if err != nil {
+		return fmt.Errorf("failed to resolve post_upload_wait url template: %w", err)
+	}
+
+	wctx, cancel := stdctx.WithTimeout(ctx, upload.PostUploadWait.Timeout)
+	defer cancel()
+	for {
+		done, err := processingStatus(wctx, client, statusURL, creds)
+		if err != nil && wctx.Err() != nil {
+			return fmt.Errorf("timed out waiting for the processing of %s: %w", artifact.Name, wctx.Err())
+		}
+		if err != nil {
+			return fmt.Errorf("processing of %s failed: %w", artifact.Name, err)
+		}
+		if done {
+			log.WithField("artifact", artifact.Name).Info("server-side processing finished")
+			return nil
+		}
+		log.WithField("artifact", artifact.Name).Debug("waiting for server-side processing")
+		select {
+		case <-wctx.Done():
+			return fmt.Errorf("timed out waiting for the processing of %s: %w", artifact.Name, wctx.Err())
+		case <-time.After(upload.PostUploadWait.Interval):
+		}
+	}
+}
+
+func processingStatus(ctx stdctx.Context, client *h.Client, statusURL string, creds credentials) (bool, error) {
+	req, err := h.NewRequestWithContext(ctx, h.MethodGet, statusURL, nil)
+	if err != nil {
+		return false, err
+	}
+	creds.apply(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case h.StatusOK:
+		return true, nil
+	case h.StatusAccepted:
+		return false, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, fmt.Errorf("unexpected http response status: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+// lease is the content of the lock file.
+type lease struct {
+	Owner   string    `json:"owner"`
+	Token   string    `json:"token,omitempty"`
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// errLockTaken is returned when the server refuses to create a lock file
+// that was created meanwhile.
+var errLockTaken = errors.New("lock was taken by another run")
+
+// acquireLock holds the lock of the given upload until publishing is done,
+// acquiring it unless this release holds it already.
+// The lock is advisory: it only protects against other runs also using it.
+func acquireLock(ctx *context.Context, upload *config.Upload, kind string) error {
+	lockURL, err := tmpl.New(ctx).Apply(upload.Lock.Path)
+	if err != nil {
+		return fmt.Errorf("%s: %s: failed to resolve lock path template: %w", upload.Name, kind, err)
+	}
+	if !strings.HasPrefix(lockURL, "http://") && !strings.HasPrefix(lockURL, "https://") {
+		return fmt.Errorf("%s: %s: lock path must be an URL, got %s", upload.Name, kind, lockURL)
+	}
+	return ctx.UploadLocks.Hold(lockURL, func() (func(), error) {
+		return createLock(ctx, upload, lockURL, kind)
+	})
+}
+
+// createLock creates the lock file at lockURL, failing if another run holds
+// a lease that's not older than the lock TTL, and returns the function that
+// removes it.
+func createLock(ctx *context.Context, upload *config.Upload, lockURL, kind string) (func(), error) {
+	creds, err := getCredentials(ctx, upload, kind)
+	if err != nil {
+		return nil, err
+	}
+	client, err := getHTTPClient(upload, newRetryBudget(ctx, upload, kind))
+	if err != nil {
+		return nil, err
+	}
+	acquired := false
+	defer func() {
+		if !acquired {
+			closeIdleConnections(client)
+		}
+	}()
+
+	held, err := readLease(ctx, client, lockURL, creds)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: failed to check lock: %w", upload.Name, kind, err)
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	if held == nil {
+		// only create the lock file if nobody did meanwhile, on servers
+		// supporting conditional requests.
+		headers["If-None-Match"] = "*"
+	} else {
+		if age := time.Since(held.Created); age < upload.Lock.TTL {
+			return nil, lockedError(upload, kind, lockURL, held)
+		}
+		log.WithField("instance", upload.Name).
+			WithField("owner", held.Owner).
+			WithField("created", held.Created.Format(time.RFC3339)).
+			Warn("breaking stale lock")
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	ours := lease{
+		Owner:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+		Token:   hex.EncodeToString(token),
+		Version: ctx.Version,
+		Created: time.Now().UTC(),
+	}
+	body, err := json.Marshal(ours)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockRequest(ctx, client, h.MethodPut, lockURL, creds, headers, body); err != nil {
+		return nil, fmt.Errorf("%s: %s: failed to acquire lock: %w", upload.Name, kind, err)
+	}
+	// read the lease back, in case another run wrote its own at the same
+	// time and the server doesn't support conditional requests.
+	held, err = readLease(ctx, client, lockURL, creds)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: failed to check lock: %w", upload.Name, kind, err)
+	}
+	if held == nil || held.Token != ours.Token {
+		return nil, lockedError(upload, kind, lockURL, held)
+	}
+	log.WithField("instance", upload.Name).WithField("lock", lockURL).Debug("acquired lock")
+
+	acquired = true
+	return func() {
+		defer closeIdleConnections(client)
+		// release the lock even if the release was canceled.
+		releaseCtx, cancel := stdctx.WithTimeout(stdctx.WithoutCancel(ctx), lockReleaseTimeout)
+		defer cancel()
+		held, err := readLease(releaseCtx, client, lockURL, creds)
+		if err == nil && (held == nil || held.Token != ours.Token) {
+			log.WithField("instance", upload.Name).
+				WithField("lock", lockURL).
+				Warn("lock is not held by this run anymore, leaving it alone")
+			return
+		}
+		if err == nil {
+			err = lockRequest(releaseCtx, client, h.MethodDelete, lockURL, creds, nil, nil)
+		}
+		if err != nil {
+			log.WithField("instance", upload.Name).
+				WithField("lock", lockURL).
+				WithError(err).
+				Warn("failed to release lock")
+			return
+		}
+		log.WithField("instance", upload.Name).WithField("lock", lockURL).Debug("released lock")
+	}, nil
+}
+
+// lockedError is the error returned when the lock at lockURL is held by
+// another run.
+func lockedError(upload *config.Upload, kind, lockURL string, held *lease) error {
+	if held == nil {
+		return fmt.Errorf("%s: %s: %w, try again later", upload.Name, kind, errLockTaken)
+	}
+	return fmt.Errorf(
+		"%s: %s: locked by %s since %s (version %s), try again later or remove %s",
+		upload.Name, kind, held.Owner, held.Created.Format(time.RFC3339), held.Version, lockURL,
+	)
+}
+
+// readLease returns the lease in the lock file at lockURL, or nil if there is
+// none.
+func readLease(ctx stdctx.Context, client *h.Client, lockURL string, creds credentials) (*lease, error) {
+	current, err := fetchIfExists(ctx, client, lockURL, creds)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	var held lease
+	if err := json.Unmarshal(current, &held); err != nil {
+		return nil, fmt.Errorf("invalid lock %s: %w", lockURL, err)
+	}
+	return &held, nil
+}
+
+func lockRequest(ctx stdctx.Context, client *h.Client, method, lockURL string, creds credentials, headers map[string]string, body []byte) error {
+	req, err := h.NewRequestWithContext(ctx, method, lockURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	creds.apply(req)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == h.StatusPreconditionFailed {
+		return errLockTaken
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected http response status: %s", resp.Status)
+	}
+	return nil
+}
+
+// graceContext returns a copy of ctx that is only canceled once the given
+// grace period passes after ctx is done, so critical uploads can still finish
+// when the release times out or is canceled.
+func graceContext(ctx *context.Context, grace time.Duration) (*context.Context, stdctx.CancelFunc) {
+	detached, cancel := stdctx.WithCancel(stdctx.WithoutCancel(ctx))
+	stop := stdctx.AfterFunc(ctx, func() {
+		log.WithField("grace", grace).Warn("context is done, letting critical uploads finish")
+		select {
+		case <-time.After(grace):
+			cancel()
+		case <-detached.Done():
+		}
+	})
+	graced := *ctx
+	graced.Context = detached
+	return &graced, func() {
+		stop()
+		cancel()
+	}
+}
+
+// WaitCritical waits for the uploads of critical artifacts that are still
+// running, for at most their grace period.
+// It should be called when the release is done or interrupted, so the process
+// doesn't exit while they are running.
+func WaitCritical(ctx *context.Context) {
+	grace, running := ctx.CriticalUploads.Running()
+	if !running {
+		return
+	}
+	log.WithField("grace", grace).Info("waiting for critical uploads to finish")
+	if !ctx.CriticalUploads.Wait() {
+		log.WithField("grace", grace).Warn("critical uploads did not finish within the grace period")
+	}
+}
+
+// criticalGracePeriod returns the longest grace period of the given uploads
+// with critical artifacts, or zero if there are none.
+func criticalGracePeriod(pending []filteredUpload) time.Duration {
+	var grace time.Duration
+	for _, f := range pending {
+		if len(f.upload.Critical) > 0 {
+			grace = max(grace, f.upload.CriticalGracePeriod)
+		}
+	}
+	return grace
+}
+
+// matchesAny tells whether name matches any of the given glob patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyRoundTrip downloads the uploaded artifact the same way a consumer
+// would and checks its sha256 against the local one.
+func verifyRoundTrip(ctx *context.Context, client *h.Client, artifact *artifact.Artifact, targetURL string, creds credentials) error {
+	// matrix parameters are only used when deploying
+	if i := strings.LastIndex(targetURL, "/"); i >= 0 {
+		if j := strings.Index(targetURL[i:], ";"); j >= 0 {
+			targetURL = targetURL[:i+j]
+		}
+	}
+	req, err := h.NewRequestWithContext(ctx, h.MethodGet, targetURL, nil)
+	if err != nil {
+		return err
+	}
+	creds.apply(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected http response status: %s", resp.Status)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return err
+	}
+	expected, err := artifact.Hash("sha256")
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != expected {
+		return fmt.Errorf("%s: checksum mismatch: expected sha256 %s, got %s", targetURL, expected, got)
+	}
+	log.WithField("artifact", artifact.Name).Debug("round trip verification successful")
+	return nil
+}
+
+// uploadDelta uploads a binary patch between the previous version of the
+// artifact and the current one.
+func uploadDelta(ctx *context.Context, upload *config.Upload, client *h.Client, budget *retryBudget, artifact *artifact.Artifact, creds credentials, kind string, check ResponseChecker) error {
+	if ctx.Git.PreviousTag == "" {
+		log.WithField("artifact", artifact.Name).Info("no previous version, skipping delta")
+		return nil
+	}
+
+	t := tmpl.New(ctx).WithArtifact(artifact)
+	previous, err := t.Apply(upload.Delta.Previous)
+	if err != nil {
+		return fmt.Errorf("%s: %s: error while building delta previous: %w", upload.Name, kind, err)
+	}
+	targetURL, err := t.Apply(upload.Delta.Target)
+	if err != nil {
+		return fmt.Errorf("%s: %s: error while building delta target URL: %w", upload.Name, kind, err)
+	}
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+	targetURL += transformName(upload, artifact.Name) + ".patch"
+
+	old, err := fetchIfExists(ctx, client, previous, creds)
+	if err != nil {
+		return fmt.Errorf("%s: %s: failed to fetch previous version: %w", upload.Name, kind, err)
+	}
+	if old == nil {
+		log.WithField("artifact", artifact.Name).
+			WithField("previous", previous).
+			Info("previous version not found, skipping delta")
+		return nil
+	}
+
+	current, err := assetOpen(kind, artifact)
+	if err != nil {
+		return err
+	}
+	defer current.ReadCloser.Close()
+	bts, err := io.ReadAll(current.ReadCloser)
+	if err != nil {
+		return err
+	}
+
+	patch := delta.Diff(old, bts)
+	res, err := uploadAssetToServer(ctx, upload, client, budget, targetURL, creds, map[string]string{}, &asset{
+		ReadCloser: nopSeekCloser{bytes.NewReader(patch)},
+		Size:       int64(len(patch)),
+	}, check.bind(upload, nil))
+	if err != nil {
+		return fmt.Errorf("%s: %s: delta upload failed: %w", upload.Name, kind, err)
+	}
+	if err := res.Body.Close(); err != nil {
+		log.WithError(err).Warn("failed to close response body")
+	}
+
+	log.WithField("instance", upload.Name).
+		WithField("artifact", artifact.Name).
+		WithField("size", len(patch)).
+		Info("uploaded delta successful")
+	return nil
+}
+
+// fetchIfExists reads a file, e.g. the previous version of an artifact, from
+// either an URL or a local path.
+// It returns nil if the file doesn't exist.
+func fetchIfExists(ctx stdctx.Context, client *h.Client, previous string, creds credentials) ([]byte, error) {
+	if !strings.HasPrefix(previous, "http://") && !strings.HasPrefix(previous, "https://") {
+		bts, err := os.ReadFile(previous)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return bts, err
+	}
+
+	req, err := h.NewRequestWithContext(ctx, h.MethodGet, previous, nil)
+	if err != nil {
+		return nil, err
+	}
+	creds.apply(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == h.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected http response status: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// nolint: gochecknoglobals
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// transformName applies the configured name transformation to the given
+// artifact name.
+func transformName(upload *config.Upload, name string) string {
+	var result string
+	switch upload.NameTransform {
+	case NameTransformLowercase:
+		result = strings.ToLower(name)
+	case NameTransformSlugify:
+		result = slugInvalidChars.ReplaceAllString(strings.ToLower(name), "-")
+		result = strings.Trim(strings.ReplaceAll(result, "-.", "."), "-")
+	default:
+		return name
+	}
+	if result != name {
+		log.WithField("instance", upload.Name).
+			WithField("name", name).
+			WithField("transformed", result).
+			Info("transformed artifact name")
+	}
+	return result
+}
+
+// Send sends a request with the given body to the given URL, using the
+// credentials and TLS settings of the given upload configuration.
+func Send(ctx *context.Context, upload *config.Upload, kind, method, target string, headers map[string]string, body []byte, check func(*h.Response) error) error {
+	creds, err := getCredentials(ctx, upload, kind)
+	if err != nil {
+		return err
+	}
+	req, err := newUploadRequest(ctx, method, target, creds, headers, &asset{
+		ReadCloser: nopSeekCloser{bytes.NewReader(body)},
+		Size:       int64(len(body)),
+	})
+	if err != nil {
+		return err
+	}
+	client, err := getHTTPClient(upload, newRetryBudget(ctx, upload, kind))
+	if err != nil {
+		return err
+	}
+	defer closeIdleConnections(client)
+	res, err := executeHTTPRequest(ctx, upload, client, req, check)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// uploadAssetToServer uploads the asset file to target.
+func uploadAssetToServer(ctx *context.Context, upload *config.Upload, client *h.Client, budget *retryBudget, target string, creds credentials, headers map[string]string, a *asset, check func(*h.Response) error) (*h.Response, error) {
+	retries := upload.Retries
+	body, seekable := a.ReadCloser.(io.ReadSeeker)
+	if retries > 0 && !seekable {
+		log.WithField("instance", upload.Name).Debug("asset can't be rewound, not retrying")
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := newUploadRequest(ctx, upload.Method, target, creds, headers, a)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := executeHTTPRequest(ctx, upload, client, req, check)
+		if err == nil || attempt >= retries || !retryable(ctx, resp) {
+			return resp, err
+		}
+		if !budget.take() {
+			log.WithField("instance", upload.Name).Warn("retry budget exhausted, not retrying")
+			return resp, err
+		}
+		delay := requestRetryDelay << attempt
+		log.WithField("instance", upload.Name).
+			WithError(err).
+			Warnf("upload failed, retrying in %s (%d/%d)", delay, attempt+1, retries)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind asset: %w", err)
+		}
+	}
+}
+
+// retryable reports whether a failed request is worth retrying: network
+// errors and server errors are, client errors are not.
+func retryable(ctx *context.Context, resp *h.Response) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return resp == nil || resp.StatusCode >= 500
+}
+
+// newUploadRequest creates a new h.Request for uploading.
+//
+// Seekable assets can be sent again, so the client follows 307 and 308
+// redirects with them.
+func newUploadRequest(ctx *context.Context, method, target string, creds credentials, headers map[string]string, a *asset) (*h.Request, error) {
+	progress := func(read int64) {
+		if a.Size <= 0 {
+			return
+		}
+		log.WithField("target", target).Infof("uploaded %d%%", read*100/a.Size)
+	}
+	body, seekable := a.ReadCloser.(io.ReadSeeker)
+	if !seekable {
+		req, err := h.NewRequestWithContext(ctx, method, target, newProgressReader(a.ReadCloser, progress))
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = a.Size
+		return prepareRequest(req, creds, headers), nil
+	}
+	// the transport closes the body once sent, which would prevent us from
+	// rewinding it to retry or follow a redirect.
+	// The asset itself is closed by the caller.
+	req, err := h.NewRequestWithContext(ctx, method, target, newProgressReader(nopSeekCloser{body}, progress))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = a.Size
+	req.GetBody = func() (io.ReadCloser, error) {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind asset: %w", err)
+		}
+		return newProgressReader(nopSeekCloser{body}, progress), nil
+	}
+	return prepareRequest(req, creds, headers), nil
+}
+
+// prepareRequest sets the credentials and headers of req.
+func prepareRequest(req *h.Request, creds credentials, headers map[string]string) *h.Request {
+
+	creds.apply(req)
+
+	for k, v := range headers {
+		req.Header.Add(k, v)
+	}
+
+	return req
+}
+
+// progressReader reports how much of the underlying reader was read, at most
+// once every progressInterval, so uploads of big artifacts don't look stuck.
+type progressReader struct {
+	io.ReadCloser
+	report func(read int64)
+	read   int64
+	last   time.Time
+}
+
+func newProgressReader(r io.ReadCloser, report func(read int64)) *progressReader {
+	return &progressReader{ReadCloser: r, report: report, last: time.Now()}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if now := time.Now(); now.Sub(r.last) >= progressInterval {
+		r.last = now
+		r.report(r.read)
+	}
+	return n, err
+}
+
+type dialFunc func(ctx stdctx.Context, network, addr string) (net.Conn, error)
+
+// retryBudget limits the number of retries shared by all the requests made
+// to an instance during the release.
+// A nil budget is unlimited.
+type retryBudget struct {
+	budgets  *context.RetryBudgets
+	instance string
+	limit    int
+}
+
+func newRetryBudget(ctx *context.Context, upload *config.Upload, kind string) *retryBudget {
+	if upload.RetryBudget <= 0 {
+		return nil
+	}
+	return &retryBudget{
+		budgets:  ctx.RetryBudgets,
+		instance: kind + "/" + upload.Name,
+		limit:    upload.RetryBudget,
+	}
+}
+
+// take consumes one retry from the budget, returning false if it is
+// exhausted.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	return b.budgets.Take(b.instance, b.limit)
+}
+
+// retryDial wraps dial so that refused connections, e.g. while the server
+// behind a load balancer is being restarted, are retried a few times before
+// giving up.
+// Only the dial is retried, so no request body needs to be sent again.
+func retryDial(dial dialFunc, retries int, budget *retryBudget) dialFunc {
+	return func(ctx stdctx.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		for i := 0; i < retries && errors.Is(err, syscall.ECONNREFUSED); i++ {
+			if !budget.take() {
+				log.WithField("addr", addr).Warn("retry budget exhausted, not retrying")
+				break
+			}
+			log.WithField("addr", addr).
+				WithError(err).
+				Debugf("connection refused, retrying (%d/%d)", i+1, retries)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(dialRetryDelay):
+			}
+			conn, err = dial(ctx, network, addr)
+		}
+		return conn, err
+	}
+}
+
+func getHTTPClient(upload *config.Upload, budget *retryBudget) (*h.Client, error) {
+	if upload.TrustedCerts == "" && upload.ClientX509Cert == "" && upload.ClientX509Key == "" &&
+		upload.DialRetries <= 0 && upload.Timeout <= 0 && !upload.InsecureSkipVerify && upload.Proxy == "" {
+		return h.DefaultClient, nil
+	}
+	// keep the default timeouts, connection pooling and HTTP/2 support.
+	transport := h.DefaultTransport.(*h.Transport).Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	if upload.Proxy != "" {
+		proxy, err := url.Parse(upload.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = h.ProxyURL(proxy)
+	}
+	if upload.DialRetries > 0 {
+		transport.DialContext = retryDial((&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext, upload.DialRetries, budget)
+	}
+	if upload.TrustedCerts != "" {
+		pool, err := x509.SystemCertPool()
 		if err != nil {
 			if runtime.GOOS == "windows" {
 				// on windows ignore errors until golang issues #16736 & #18609 get fixed
@@ -325,6 +2150,11 @@ func getHTTPClient(upload *config.Upload) (*h.Client, error) {
 		pool.AppendCertsFromPEM([]byte(upload.TrustedCerts)) // already validated certs checked by CheckConfig
 		transport.TLSClientConfig.RootCAs = pool
 	}
+	if upload.InsecureSkipVerify {
+		log.WithField("instance", upload.Name).
+			Warn("TLS certificate verification is disabled, do not use this in production")
+		transport.TLSClientConfig.InsecureSkipVerify = true // nolint: gosec
+	}
 	if upload.ClientX509Cert != "" && upload.ClientX509Key != "" {
 		cert, err := tls.LoadX509KeyPair(upload.ClientX509Cert, upload.ClientX509Key)
 		if err != nil {
@@ -332,14 +2162,103 @@ func getHTTPClient(upload *config.Upload) (*h.Client, error) {
 		}
 		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
 	}
-	return &h.Client{Transport: transport}, nil
+	return &h.Client{Transport: transport, Timeout: upload.Timeout}, nil
+}
+
+// closeIdleConnections closes the idle connections of a client built by
+// getHTTPClient, leaving the shared default client alone.
+func closeIdleConnections(client *h.Client) {
+	if client != h.DefaultClient {
+		client.CloseIdleConnections()
+	}
+}
+
+// requestTrace records the timings of each phase of a request.
+type requestTrace struct {
+	lock      sync.Mutex
+	target    string
+	start     time.Time
+	dns       time.Duration
+	connect   time.Duration
+	tls       time.Duration
+	firstByte time.Duration
+	phase     map[string]time.Time
+}
+
+// traceRequest attaches a client trace to the given request.
+func traceRequest(req *h.Request) (*h.Request, *requestTrace) {
+	rt := &requestTrace{
+		target: req.URL.String(),
+		start:  time.Now(),
+		phase:  map[string]time.Time{},
+	}
+	begin := func(phase string) {
+		rt.lock.Lock()
+		defer rt.lock.Unlock()
+		rt.phase[phase] = time.Now()
+	}
+	end := func(phase string, d *time.Duration) {
+		rt.lock.Lock()
+		defer rt.lock.Unlock()
+		if start, ok := rt.phase[phase]; ok {
+			*d = time.Since(start)
+		}
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { begin("dns") },
+		DNSDone:           func(httptrace.DNSDoneInfo) { end("dns", &rt.dns) },
+		ConnectStart:      func(string, string) { begin("connect") },
+		ConnectDone:       func(string, string, error) { end("connect", &rt.connect) },
+		TLSHandshakeStart: func() { begin("tls") },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { end("tls", &rt.tls) },
+		GotFirstResponseByte: func() {
+			rt.lock.Lock()
+			defer rt.lock.Unlock()
+			rt.firstByte = time.Since(rt.start)
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), rt
+}
+
+func (rt *requestTrace) log(upload *config.Upload) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	log.WithField("instance", upload.Name).
+		WithField("target", rt.target).
+		WithField("dns", rt.dns).
+		WithField("connect", rt.connect).
+		WithField("tls", rt.tls).
+		WithField("first-byte", rt.firstByte).
+		WithField("total", time.Since(rt.start)).
+		Info("request timings")
+}
+
+// checkRedirect detects requests the server redirected somewhere else, which
+// may mean the artifact landed in a different place than the target.
+func checkRedirect(upload *config.Upload, req *h.Request, resp *h.Response) error {
+	landed := resp.Request
+	if landed.URL.String() == req.URL.String() {
+		return nil
+	}
+	if landed.Method != req.Method {
+		return fmt.Errorf("%s %s was redirected to %s %s, the artifact was not uploaded", req.Method, req.URL, landed.Method, landed.URL)
+	}
+	if upload.FailOnRedirect {
+		return fmt.Errorf("%s %s was redirected to %s", req.Method, req.URL, landed.URL)
+	}
+	log.WithField("instance", upload.Name).
+		WithField("target", req.URL.String()).
+		WithField("landed", landed.URL.String()).
+		Warn("request was redirected")
+	return nil
 }
 
 // executeHTTPRequest processes the http call with respect of context ctx.
-func executeHTTPRequest(ctx *context.Context, upload *config.Upload, req *h.Request, check ResponseChecker) (*h.Response, error) {
-	client, err := getHTTPClient(upload)
-	if err != nil {
-		return nil, err
+func executeHTTPRequest(ctx *context.Context, upload *config.Upload, client *h.Client, req *h.Request, check func(*h.Response) error) (*h.Response, error) {
+	if upload.Trace {
+		var trace *requestTrace
+		req, trace = traceRequest(req)
+		defer trace.log(upload)
 	}
 	log.Debugf("executing request: %s %s (headers: %v)", req.Method, req.URL, req.Header)
 	resp, err := client.Do(req)
@@ -356,6 +2275,10 @@ func executeHTTPRequest(ctx *context.Context, upload *config.Upload, req *h.Requ
 
 	defer resp.Body.Close()
 
+	if err := checkRedirect(upload, req, resp); err != nil {
+		return resp, err
+	}
+
 	err = check(resp)
 	if err != nil {
 		// even though there was an error, we still return the response
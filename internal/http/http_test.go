@@ -1,24 +1,37 @@
 package http
 
 import (
+	"archive/tar"
 	"bytes"
+	stdctx "context"
 	"crypto/tls"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	h "net/http"
 	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/git"
+	"github.com/goreleaser/goreleaser/internal/pipe"
 	"github.com/goreleaser/goreleaser/internal/testctx"
+	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pkg/config"
 	"github.com/goreleaser/goreleaser/pkg/context"
+	"github.com/goreleaser/goreleaser/pkg/delta"
 	"github.com/stretchr/testify/require"
 )
 
@@ -81,6 +94,111 @@ func TestDefaults(t *testing.T) {
 	}
 }
 
+func TestDefaultsDialRetries(t *testing.T) {
+	uploads := []config.Upload{{Name: "a"}, {Name: "b", DialRetries: 5}, {Name: "c", DialRetries: -1}}
+	require.NoError(t, Defaults(uploads))
+	require.Equal(t, 2, uploads[0].DialRetries)
+	require.Equal(t, 5, uploads[1].DialRetries)
+	require.Equal(t, -1, uploads[2].DialRetries)
+
+	client, err := getHTTPClient(&uploads[2], nil)
+	require.NoError(t, err)
+	require.Same(t, h.DefaultClient, client)
+}
+
+func TestGetHTTPClientKeepsDefaultTransport(t *testing.T) {
+	uploads := []config.Upload{{Name: "a"}}
+	require.NoError(t, Defaults(uploads))
+	client, err := getHTTPClient(&uploads[0], nil)
+	require.NoError(t, err)
+	require.NotSame(t, h.DefaultClient, client)
+	transport, ok := client.Transport.(*h.Transport)
+	require.True(t, ok)
+	defaults := h.DefaultTransport.(*h.Transport)
+	require.True(t, transport.ForceAttemptHTTP2)
+	require.Equal(t, defaults.TLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	require.Equal(t, defaults.IdleConnTimeout, transport.IdleConnTimeout)
+	require.Equal(t, defaults.MaxIdleConns, transport.MaxIdleConns)
+	require.NotNil(t, transport.Proxy)
+	require.NotNil(t, transport.DialContext)
+}
+
+func TestRetryDial(t *testing.T) {
+	dialRetryDelay = time.Millisecond
+	t.Cleanup(func() { dialRetryDelay = 250 * time.Millisecond })
+
+	fakeDial := func(fails int, err error) (dialFunc, *int) {
+		var calls int
+		return func(_ stdctx.Context, _, _ string) (net.Conn, error) {
+			calls++
+			if calls <= fails {
+				return nil, &net.OpError{Op: "dial", Err: err}
+			}
+			c, _ := net.Pipe()
+			return c, nil
+		}, &calls
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		dial, calls := fakeDial(2, syscall.ECONNREFUSED)
+		conn, err := retryDial(dial, 2, nil)(stdctx.Background(), "tcp", "localhost:80")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+		require.Equal(t, 3, *calls)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		dial, calls := fakeDial(5, syscall.ECONNREFUSED)
+		_, err := retryDial(dial, 2, nil)(stdctx.Background(), "tcp", "localhost:80")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		require.Equal(t, 3, *calls)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		dial, calls := fakeDial(5, syscall.EHOSTUNREACH)
+		_, err := retryDial(dial, 2, nil)(stdctx.Background(), "tcp", "localhost:80")
+		require.ErrorIs(t, err, syscall.EHOSTUNREACH)
+		require.Equal(t, 1, *calls)
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := stdctx.WithCancel(stdctx.Background())
+		cancel()
+		dial, calls := fakeDial(5, syscall.ECONNREFUSED)
+		_, err := retryDial(dial, 2, nil)(ctx, "tcp", "localhost:80")
+		require.ErrorIs(t, err, stdctx.Canceled)
+		require.Equal(t, 1, *calls)
+	})
+
+	t.Run("shared budget", func(t *testing.T) {
+		budget := newRetryBudget(testctx.New(), &config.Upload{Name: "a", RetryBudget: 3}, "test")
+		dial, calls := fakeDial(100, syscall.ECONNREFUSED)
+		retrying := retryDial(dial, 2, budget)
+		for i := 0; i < 3; i++ {
+			_, err := retrying(stdctx.Background(), "tcp", "localhost:80")
+			require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		}
+		// 2 retries on the first dial, 1 on the second, none on the third.
+		require.Equal(t, 6, *calls)
+	})
+}
+
+func TestRetryBudget(t *testing.T) {
+	ctx := testctx.New()
+	require.Nil(t, newRetryBudget(ctx, &config.Upload{Name: "a"}, "test"))
+	require.True(t, (*retryBudget)(nil).take())
+
+	upload := &config.Upload{Name: "a", RetryBudget: 2}
+	budget := newRetryBudget(ctx, upload, "test")
+	require.True(t, budget.take())
+	require.True(t, budget.take())
+	require.False(t, budget.take())
+
+	// the budget is shared by the whole release.
+	require.False(t, newRetryBudget(ctx, upload, "test").take())
+	require.True(t, newRetryBudget(ctx, upload, "other").take())
+}
+
 func TestCheckConfig(t *testing.T) {
 	ctx := testctx.NewWithCfg(config.Project{
 		ProjectName: "blah",
@@ -105,6 +223,37 @@ func TestCheckConfig(t *testing.T) {
 		{"mode missing", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe"}, "test"}, true},
 		{"mode invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: "blabla"}, "test"}, true},
 		{"cert invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, TrustedCerts: "bad cert!"}, "test"}, true},
+		{"name transform", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, NameTransform: NameTransformSlugify}, "test"}, false},
+		{"batch with custom artifact name", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, CustomArtifactName: true, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with layout", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Layout: LayoutGo, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with target map file", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, TargetMapFile: "targets.yaml", BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with properties", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Properties: map[string]string{"a": "b"}, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with sidecar properties", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, PropertiesFromSidecar: true, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with checksum deploy", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, ChecksumDeploy: true, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with verify round trip", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, VerifyRoundTrip: []string{"*"}, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with post upload wait", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, PostUploadWait: config.UploadWait{URL: "http://blabla/status"}, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with delta", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Delta: config.UploadDelta{Previous: "http://blabla/old"}, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"batch with critical", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Critical: []string{"*"}, BatchSmallFiles: config.UploadBatch{Threshold: 10}}, "test"}, true},
+		{"disabled", args{ctx, &config.Upload{Name: "a", Disable: "true"}, "test"}, false},
+		{"username template", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "{{ .ProjectName }}", Mode: ModeArchive}, "test"}, false},
+		{"username template empty", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "{{ .Env.NOPE }}", Mode: ModeArchive}, "test"}, true},
+		{"username template invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "{{ .Nope }}", Mode: ModeArchive}, "test"}, true},
+		{"disable invalid", args{ctx, &config.Upload{Name: "a", Disable: "{{ .Nope }}"}, "test"}, true},
+		{"name transform invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, NameTransform: "upper"}, "test"}, true},
+		{"verify round trip", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, VerifyRoundTrip: []string{"*.deb"}}, "test"}, false},
+		{"verify round trip invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, VerifyRoundTrip: []string{"[*.deb"}}, "test"}, true},
+		{"apikey without username", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Mode: ModeBinary, AuthType: AuthAPIKey}, "test"}, false},
+		{"bearer without username", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Mode: ModeBinary, AuthType: AuthBearer}, "test"}, false},
+		{"gitlab without username", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Mode: ModeBinary, AuthType: AuthGitLab}, "test"}, false},
+		{"gitlab secret missing", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Mode: ModeBinary, AuthType: AuthGitLabJob, SecretEnv: "CI_JOB_TOKEN"}, "test"}, true},
+		{"secret env", args{ctx, &config.Upload{Name: "b", Target: "http://blabla", Username: "pepe", Mode: ModeArchive, SecretEnv: "TEST_A_SECRET"}, "test"}, false},
+		{"secret env missing", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeArchive, SecretEnv: "PROD_TOKEN"}, "test"}, true},
+		{"layout", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Layout: LayoutGo}, "test"}, false},
+		{"layout invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Layout: "maven"}, "test"}, true},
+		{"layout with custom artifact name", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Layout: LayoutGo, CustomArtifactName: true}, "test"}, true},
+		{"proxy", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Proxy: "http://proxy.local:3128"}, "test"}, false},
+		{"proxy invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, Proxy: "proxy.local"}, "test"}, true},
+		{"auth type invalid", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeBinary, AuthType: "digest"}, "test"}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -123,6 +272,7 @@ func TestCheckConfig(t *testing.T) {
 	}{
 		{"username missing", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Mode: ModeArchive}, "test"}, false},
 		{"username present", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Username: "pepe", Mode: ModeArchive}, "test"}, true},
+		{"apikey secret missing", args{ctx, &config.Upload{Name: "a", Target: "http://blabla", Mode: ModeArchive, AuthType: AuthAPIKey}, "test"}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -133,6 +283,31 @@ func TestCheckConfig(t *testing.T) {
 	}
 }
 
+func TestGetPassword(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Env: []string{
+			"TEST_PRODUCTION_SECRET=indexed",
+			"PROD_TOKEN=named",
+		},
+	})
+	require.Equal(t, "indexed", getPassword(ctx, &config.Upload{Name: "production"}, "test"))
+	require.Equal(t, "named", getPassword(ctx, &config.Upload{Name: "production", SecretEnv: "PROD_TOKEN"}, "test"))
+	require.Empty(t, getPassword(ctx, &config.Upload{Name: "production", SecretEnv: "NOPE"}, "test"))
+}
+
+func TestTransformName(t *testing.T) {
+	for transform, expected := range map[string]string{
+		"":                     "My App_1.0 (Linux).tar.gz",
+		NameTransformLowercase: "my app_1.0 (linux).tar.gz",
+		NameTransformSlugify:   "my-app_1.0-linux.tar.gz",
+	} {
+		t.Run(transform, func(t *testing.T) {
+			upload := &config.Upload{Name: "a", NameTransform: transform}
+			require.Equal(t, expected, transformName(upload, "My App_1.0 (Linux).tar.gz"))
+		})
+	}
+}
+
 type check struct {
 	path    string
 	user    string
@@ -221,7 +396,7 @@ func TestUpload(t *testing.T) {
 		}, nil
 	}
 	defer assetOpenReset()
-	var is2xx ResponseChecker = func(r *h.Response) error {
+	var is2xx ResponseChecker = func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
 		if r.StatusCode/100 == 2 {
 			return nil
 		}
@@ -249,6 +424,7 @@ func TestUpload(t *testing.T) {
 		{"meta", artifact.Metadata},
 		{"sig", artifact.Signature},
 		{"pem", artifact.Certificate},
+		{"sbom.json", artifact.SBOM},
 	} {
 		file := filepath.Join(folder, "a."+a.ext)
 		require.NoError(t, os.WriteFile(file, []byte("lorem ipsum"), 0o644))
@@ -467,6 +643,25 @@ func TestUpload(t *testing.T) {
 				check{"/blah/2.1.0/a.meta", "u3", "x", content, map[string]string{}},
 			),
 		},
+		{
+			"sbom", true, true, false, false,
+			func(s *httptest.Server) (*context.Context, config.Upload) {
+				return ctx, config.Upload{
+					Mode:         ModeArchive,
+					Name:         "a",
+					Target:       s.URL + "/{{.ProjectName}}/{{.Version}}/",
+					Username:     "u3",
+					SBOM:         true,
+					TrustedCerts: cert(s),
+				}
+			},
+			checks(
+				check{"/blah/2.1.0/a.deb", "u3", "x", content, map[string]string{}},
+				check{"/blah/2.1.0/a.tar", "u3", "x", content, map[string]string{}},
+				check{"/blah/2.1.0/a.tar.gz", "u3", "x", content, map[string]string{}},
+				check{"/blah/2.1.0/a.sbom.json", "u3", "x", content, map[string]string{}},
+			),
+		},
 		{
 			"bad-template", true, true, true, true,
 			func(s *httptest.Server) (*context.Context, config.Upload) {
@@ -526,6 +721,31 @@ func TestUpload(t *testing.T) {
 			},
 			checks(check{"/blah/2.1.0/a.ubi", "u2", "x", content, map[string]string{"-x-sha256": "5e2bf57d3f40c4b6df69daf1936cb766f832374b4fc0259a7cbff06e2f70f269"}}),
 		},
+		{
+			"untrusted-cert", false, true, false, true,
+			func(s *httptest.Server) (*context.Context, config.Upload) {
+				return ctx, config.Upload{
+					Mode:     ModeBinary,
+					Name:     "a",
+					Target:   s.URL + "/{{.ProjectName}}/{{.Version}}/",
+					Username: "u2",
+				}
+			},
+			checks(),
+		},
+		{
+			"insecure-skip-verify", false, true, false, false,
+			func(s *httptest.Server) (*context.Context, config.Upload) {
+				return ctx, config.Upload{
+					Mode:               ModeBinary,
+					Name:               "a",
+					Target:             s.URL + "/{{.ProjectName}}/{{.Version}}/",
+					Username:           "u2",
+					InsecureSkipVerify: true,
+				}
+			},
+			checks(check{"/blah/2.1.0/a.ubi", "u2", "x", content, map[string]string{}}),
+		},
 		{
 			"custom-headers", true, true, false, false,
 			func(s *httptest.Server) (*context.Context, config.Upload) {
@@ -574,6 +794,39 @@ func TestUpload(t *testing.T) {
 			},
 			checks(),
 		},
+		{
+			"target-collision", true, true, true, true,
+			func(s *httptest.Server) (*context.Context, config.Upload) {
+				return ctx, config.Upload{
+					Mode:               ModeArchive,
+					Name:               "a",
+					Target:             s.URL + "/{{.ProjectName}}/{{.Version}}/release",
+					Username:           "u1",
+					CustomArtifactName: true,
+					TrustedCerts:       cert(s),
+				}
+			},
+			checks(),
+		},
+		{
+			"target-collision-allowed", true, true, false, false,
+			func(s *httptest.Server) (*context.Context, config.Upload) {
+				return ctx, config.Upload{
+					Mode:                  ModeArchive,
+					Name:                  "a",
+					Target:                s.URL + "/{{.ProjectName}}/{{.Version}}/release",
+					Username:              "u1",
+					CustomArtifactName:    true,
+					AllowTargetCollisions: true,
+					TrustedCerts:          cert(s),
+				}
+			},
+			checks(
+				check{"/blah/2.1.0/release", "u1", "x", content, map[string]string{}},
+				check{"/blah/2.1.0/release", "u1", "x", content, map[string]string{}},
+				check{"/blah/2.1.0/release", "u1", "x", content, map[string]string{}},
+			),
+		},
 		{
 			"filtering-by-ext", true, true, false, false,
 			func(s *httptest.Server) (*context.Context, config.Upload) {
@@ -680,3 +933,2305 @@ func cert(srv *httptest.Server) string {
 	}
 	return string(pem.EncodeToMemory(block))
 }
+
+// failingCloser fails every close after the first one, which is done by the
+// http transport.
+type failingCloser struct {
+	io.Reader
+	closed bool
+}
+
+func (c *failingCloser) Close() error {
+	if c.closed {
+		return errors.New("close failed")
+	}
+	c.closed = true
+	return nil
+}
+
+func TestUploadCloseErrorDoesNotMaskUploadError(t *testing.T) {
+	status := h.StatusInternalServerError
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	content := []byte("blah!")
+	assetOpen = func(_ string, _ *artifact.Artifact) (*asset, error) {
+		return &asset{
+			ReadCloser: &failingCloser{Reader: bytes.NewReader(content)},
+			Size:       int64(len(content)),
+		}, nil
+	}
+	defer assetOpenReset()
+
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: "/a/a.deb", Type: artifact.LinuxPackage})
+	uploads := []config.Upload{{
+		Name:     "a",
+		Mode:     ModeArchive,
+		Username: "u1",
+		Target:   srv.URL + "/{{.ProjectName}}/{{.Version}}/",
+	}}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+
+	t.Run("upload fails", func(t *testing.T) {
+		err := Upload(ctx, uploads, "test", check)
+		require.ErrorContains(t, err, "unexpected http status code: 500")
+		require.NotContains(t, err.Error(), "close failed")
+	})
+
+	t.Run("upload succeeds", func(t *testing.T) {
+		status = h.StatusCreated
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+	})
+}
+
+func TestUploadBufferForMirrors(t *testing.T) {
+	var m sync.Mutex
+	received := map[string]string{}
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		bts, _ := io.ReadAll(r.Body)
+		m.Lock()
+		received[r.URL.Path] = string(bts)
+		m.Unlock()
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	content := []byte("blah!")
+	var opened atomic.Int32
+	assetOpen = func(_ string, _ *artifact.Artifact) (*asset, error) {
+		opened.Add(1)
+		return &asset{
+			ReadCloser: io.NopCloser(bytes.NewReader(content)),
+			Size:       int64(len(content)),
+		}, nil
+	}
+	defer assetOpenReset()
+
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x", "TEST_B_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: "/a/a.deb", Type: artifact.LinuxPackage})
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+
+	for name, tt := range map[string]struct {
+		maxSize int64
+		opened  int32
+	}{
+		"buffered":  {maxSize: 1024, opened: 1},
+		"oversized": {maxSize: 2, opened: 3},
+		"smallest":  {maxSize: -1, opened: 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opened.Store(0)
+			first, second := tt.maxSize, tt.maxSize
+			if tt.maxSize < 0 {
+				// the smallest limit applies, whichever instance is first.
+				first, second = 1024, 2
+			}
+			uploads := []config.Upload{
+				{Name: "a", Username: "u1", Target: srv.URL + "/a/", BufferForMirrors: true, MirrorBufferMaxSize: first},
+				{Name: "b", Username: "u1", Target: srv.URL + "/b/", BufferForMirrors: true, MirrorBufferMaxSize: second},
+			}
+			require.NoError(t, Defaults(uploads))
+			require.NoError(t, Upload(ctx, uploads, "test", check))
+			require.Equal(t, tt.opened, opened.Load())
+			require.Equal(t, map[string]string{
+				"/a/a.deb": "blah!",
+				"/b/a.deb": "blah!",
+			}, received)
+		})
+	}
+}
+
+func TestAssetBuffers(t *testing.T) {
+	var opened atomic.Int32
+	assetOpen = func(_ string, a *artifact.Artifact) (*asset, error) {
+		opened.Add(1)
+		return &asset{
+			ReadCloser: io.NopCloser(strings.NewReader(a.Name)),
+			Size:       int64(len(a.Name)),
+		}, nil
+	}
+	defer assetOpenReset()
+
+	ctx := testctx.New()
+	for _, name := range []string{"first", "other", "alone"} {
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: "/" + name, Type: artifact.LinuxPackage})
+	}
+	byName := func(names ...string) artifact.Filter {
+		return func(a *artifact.Artifact) bool { return slices.Contains(names, a.Name) }
+	}
+	shared := byName("first", "other")
+	buffers := newAssetBuffers(ctx, []filteredUpload{
+		{upload: config.Upload{Name: "a", BufferForMirrors: true, MirrorBufferMaxSize: 8}, filter: byName("first", "other", "alone")},
+		{upload: config.Upload{Name: "b", BufferForMirrors: true, MirrorBufferMaxSize: 1024}, filter: shared},
+		{upload: config.Upload{Name: "c"}, filter: shared},
+	})
+	require.Equal(t, int64(8), buffers.limit)
+
+	read := func(instance, name string) *asset {
+		t.Helper()
+		a, err := buffers.opener(instance)("test", ctx.Artifacts.Filter(byName(name)).List()[0])
+		require.NoError(t, err)
+		bts, err := io.ReadAll(a.ReadCloser)
+		require.NoError(t, err)
+		require.Equal(t, name, string(bts))
+		return a
+	}
+
+	first := read("a", "first")
+	require.Equal(t, int64(5), buffers.used)
+	require.Equal(t, int32(1), opened.Load())
+
+	// doesn't fit in what's left of the budget.
+	require.NoError(t, read("a", "other").ReadCloser.Close())
+	require.Equal(t, int32(3), opened.Load())
+
+	// only uploaded to one instance.
+	require.NoError(t, read("a", "alone").ReadCloser.Close())
+	require.Equal(t, int32(4), opened.Load())
+
+	// not buffering.
+	require.NoError(t, read("c", "first").ReadCloser.Close())
+	require.Equal(t, int32(5), opened.Load())
+
+	require.NoError(t, first.ReadCloser.Close())
+	require.NoError(t, first.ReadCloser.Close())
+	require.Equal(t, int64(5), buffers.used, "still to be uploaded to b")
+	second := read("b", "first")
+	require.Equal(t, int32(5), opened.Load())
+	require.NoError(t, second.ReadCloser.Close())
+	require.Zero(t, buffers.used)
+	require.Nil(t, buffers.entries["/first"].data)
+}
+
+func TestUploadMinArtifacts(t *testing.T) {
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	for _, name := range []string{"a.tar.gz", "b.tar.gz"} {
+		file := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(file, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: file, Type: artifact.UploadableArchive})
+	}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+	upload := func(minArtifacts int) config.Upload {
+		return config.Upload{
+			Name:         "a",
+			Mode:         ModeArchive,
+			Username:     "u1",
+			Target:       srv.URL + "/",
+			MinArtifacts: minArtifacts,
+		}
+	}
+
+	require.NoError(t, Upload(ctx, []config.Upload{upload(0)}, "test", check))
+	require.NoError(t, Upload(ctx, []config.Upload{upload(2)}, "test", check))
+	require.EqualError(
+		t,
+		Upload(ctx, []config.Upload{upload(3)}, "test", check),
+		"a: test: expected at least 3 artifacts to upload, found 2",
+	)
+}
+
+func TestUploadPrintPlan(t *testing.T) {
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		w.WriteHeader(h.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	dist := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Dist:        dist,
+		Env:         []string{"TEST_A_SECRET=x", "TEST_B_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.PrintUploadPlan = true
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: "/a/a.deb", Type: artifact.LinuxPackage})
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "bin", Path: "/a/bin", Type: artifact.UploadableBinary})
+
+	err := Upload(ctx, []config.Upload{
+		{Name: "a", Mode: ModeArchive, Username: "u1", Target: srv.URL + "/{{.ProjectName}}/{{.Version}}/"},
+		{Name: "b", Mode: ModeBinary, Username: "u1", Target: srv.URL + "/bin/"},
+	}, "test", func(_ *h.Response, _ *config.Upload, _ *artifact.Artifact) error { return nil })
+	testlib.AssertSkipped(t, err)
+
+	bts, err := os.ReadFile(filepath.Join(dist, "test-plan.json"))
+	require.NoError(t, err)
+	var plan []PlanEntry
+	require.NoError(t, json.Unmarshal(bts, &plan))
+	require.Equal(t, []PlanEntry{
+		{Instance: "a", Artifact: "a.deb", Path: "/a/a.deb", Target: srv.URL + "/blah/2.1.0/a.deb"},
+		{Instance: "b", Artifact: "bin", Path: "/a/bin", Target: srv.URL + "/bin/bin"},
+	}, plan)
+
+	dot, err := os.ReadFile(filepath.Join(dist, "test-plan.dot"))
+	require.NoError(t, err)
+	require.Contains(t, string(dot), fmt.Sprintf("\t%q -> %q [label=%q];\n", "a.deb", srv.URL+"/blah/2.1.0/a.deb", "a"))
+	require.Contains(t, string(dot), fmt.Sprintf("\t%q -> %q [label=%q];\n", "bin", srv.URL+"/bin/bin", "b"))
+}
+
+func TestUploadVerifyRoundTrip(t *testing.T) {
+	var m sync.Mutex
+	stored := map[string][]byte{}
+	corrupt := false
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		m.Lock()
+		defer m.Unlock()
+		switch r.Method {
+		case h.MethodPut:
+			path, _, _ := strings.Cut(r.URL.Path, ";")
+			bts, _ := io.ReadAll(r.Body)
+			stored[path] = bts
+			w.WriteHeader(h.StatusCreated)
+		case h.MethodGet:
+			bts, ok := stored[r.URL.Path]
+			if !ok {
+				w.WriteHeader(h.StatusNotFound)
+				return
+			}
+			if corrupt {
+				bts = append(bts, '!')
+			}
+			_, _ = w.Write(bts)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	for _, name := range []string{"a.deb", "b.tar.gz"} {
+		file := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(file, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: file, Type: artifact.UploadableArchive})
+	}
+	uploads := []config.Upload{{
+		Name:            "a",
+		Mode:            ModeArchive,
+		Method:          h.MethodPut,
+		Username:        "u1",
+		Target:          srv.URL + "/{{.ProjectName}}/",
+		Properties:      map[string]string{"version": "{{ .Version }}"},
+		VerifyRoundTrip: []string{"*.deb"},
+	}}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		corrupt = true
+		t.Cleanup(func() { corrupt = false })
+		err := Upload(ctx, uploads, "test", check)
+		require.ErrorContains(t, err, "a: test: round trip verification failed")
+		require.ErrorContains(t, err, "/blah/a.deb: checksum mismatch")
+	})
+}
+
+func TestByPriority(t *testing.T) {
+	uploads := []config.Upload{
+		{Name: "mirror-1"},
+		{Name: "primary", Priority: 10},
+		{Name: "mirror-2"},
+		{Name: "fallback", Priority: -1},
+		{Name: "secondary", Priority: 5},
+	}
+	var names []string
+	for _, upload := range byPriority(uploads) {
+		names = append(names, upload.Name)
+	}
+	require.Equal(t, []string{"primary", "secondary", "mirror-1", "mirror-2", "fallback"}, names)
+	require.Equal(t, "mirror-1", uploads[0].Name, "should not change the original order")
+}
+
+func TestUploadPostUploadWait(t *testing.T) {
+	var polls atomic.Int32
+	var status atomic.Int32
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		switch r.Method {
+		case h.MethodPut:
+			w.WriteHeader(h.StatusCreated)
+		case h.MethodGet:
+			require.Equal(t, "/status/blah/a.deb", r.URL.Path)
+			if polls.Add(1) < 3 {
+				w.WriteHeader(h.StatusAccepted)
+				return
+			}
+			w.WriteHeader(int(status.Load()))
+			fmt.Fprint(w, "virus found")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	file := filepath.Join(folder, "a.deb")
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0o644))
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: file, Type: artifact.LinuxPackage})
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+	uploads := func(timeout time.Duration) []config.Upload {
+		uploads := []config.Upload{{
+			Name:     "a",
+			Username: "u1",
+			Target:   srv.URL + "/{{.ProjectName}}/",
+			PostUploadWait: config.UploadWait{
+				URL:      `{{ replace .Target "/blah/" "/status/blah/" }}`,
+				Interval: time.Millisecond,
+				Timeout:  timeout,
+			},
+		}}
+		require.NoError(t, Defaults(uploads))
+		return uploads
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		wait := uploads(0)[0].PostUploadWait
+		require.Equal(t, time.Millisecond, wait.Interval)
+		require.Equal(t, 10*time.Minute, wait.Timeout)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		polls.Store(0)
+		status.Store(h.StatusOK)
+		require.NoError(t, Upload(ctx, uploads(time.Minute), "test", check))
+		require.Equal(t, int32(3), polls.Load())
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		polls.Store(0)
+		status.Store(h.StatusUnprocessableEntity)
+		err := Upload(ctx, uploads(time.Minute), "test", check)
+		require.ErrorContains(t, err, "a: test: processing of a.deb failed")
+		require.ErrorContains(t, err, "virus found")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		polls.Store(-1000)
+		err := Upload(ctx, uploads(20*time.Millisecond), "test", check)
+		require.ErrorContains(t, err, "a: test: timed out waiting for the processing of a.deb")
+		require.ErrorIs(t, err, stdctx.DeadlineExceeded)
+	})
+}
+
+func TestUploadLock(t *testing.T) {
+	var m sync.Mutex
+	var lock []byte
+	var uploaded []string
+	var puts int
+	// takeOver replaces the lease on the next PUT, as another run would.
+	var takeOver []byte
+	// missed hides the lease from the next GET, as if it was created right
+	// after it.
+	var missed bool
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		m.Lock()
+		defer m.Unlock()
+		if r.URL.Path != "/locks/blah-2.1.0.lock" {
+			require.NotNil(t, lock, "uploaded without holding the lock")
+			uploaded = append(uploaded, r.URL.Path)
+			w.WriteHeader(h.StatusCreated)
+			return
+		}
+		switch r.Method {
+		case h.MethodGet:
+			if lock == nil || missed {
+				missed = false
+				w.WriteHeader(h.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(lock)
+		case h.MethodPut:
+			puts++
+			if r.Header.Get("If-None-Match") == "*" && lock != nil {
+				w.WriteHeader(h.StatusPreconditionFailed)
+				return
+			}
+			lock, _ = io.ReadAll(r.Body)
+			if takeOver != nil {
+				lock, takeOver = takeOver, nil
+			}
+			w.WriteHeader(h.StatusCreated)
+		case h.MethodDelete:
+			lock = nil
+			w.WriteHeader(h.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	file := filepath.Join(folder, "a.deb")
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0o644))
+	newCtx := func() *context.Context {
+		ctx := testctx.NewWithCfg(config.Project{
+			ProjectName: "blah",
+			Env:         []string{"TEST_A_SECRET=x", "TEST_B_SECRET=x"},
+		}, testctx.WithVersion("2.1.0"))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: file, Type: artifact.LinuxPackage})
+		return ctx
+	}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+	uploads := []config.Upload{{
+		Name:     "a",
+		Username: "u1",
+		Target:   srv.URL + "/{{.ProjectName}}/",
+		Lock: config.UploadLock{
+			Path: srv.URL + "/locks/{{ .ProjectName }}-{{ .Version }}.lock",
+		},
+	}}
+	require.NoError(t, Defaults(uploads))
+	require.Equal(t, time.Hour, uploads[0].Lock.TTL)
+
+	hold := func(created time.Time) []byte {
+		m.Lock()
+		defer m.Unlock()
+		lock, _ = json.Marshal(lease{Owner: "other:1", Token: "other", Version: "2.1.0", Created: created})
+		return lock
+	}
+	reset := func() {
+		m.Lock()
+		defer m.Unlock()
+		lock, uploaded, puts, takeOver, missed = nil, nil, 0, nil, false
+	}
+
+	t.Run("free", func(t *testing.T) {
+		reset()
+		ctx := newCtx()
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, []string{"/blah/a.deb"}, uploaded)
+		require.NotNil(t, lock, "lock should be held until publishing is done")
+		ctx.UploadLocks.Release()
+		require.Nil(t, lock, "lock should be released")
+	})
+
+	t.Run("held once per release", func(t *testing.T) {
+		reset()
+		ctx := newCtx()
+		second := uploads[0]
+		second.Name = "b"
+		require.NoError(t, Upload(ctx, append(slices.Clone(uploads), second), "test", check))
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, []string{"/blah/a.deb", "/blah/a.deb", "/blah/a.deb"}, uploaded)
+		require.Equal(t, 1, puts)
+		ctx.UploadLocks.Release()
+		require.Nil(t, lock, "lock should be released")
+	})
+
+	t.Run("held", func(t *testing.T) {
+		reset()
+		hold(time.Now().Add(-time.Minute))
+		err := Upload(newCtx(), uploads, "test", check)
+		require.ErrorContains(t, err, "a: test: locked by other:1 since")
+		require.Empty(t, uploaded)
+		require.NotNil(t, lock, "lock held by another run should be kept")
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		reset()
+		hold(time.Now().Add(-2 * time.Hour))
+		ctx := newCtx()
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, []string{"/blah/a.deb"}, uploaded)
+		ctx.UploadLocks.Release()
+		require.Nil(t, lock, "lock should be released")
+	})
+
+	t.Run("created meanwhile", func(t *testing.T) {
+		reset()
+		theirs := hold(time.Now())
+		m.Lock()
+		// the lease is created by another run right after we checked.
+		missed = true
+		m.Unlock()
+		err := Upload(newCtx(), uploads, "test", check)
+		require.ErrorIs(t, err, errLockTaken)
+		require.Empty(t, uploaded)
+		require.Equal(t, theirs, lock, "lock held by another run should be kept")
+	})
+
+	t.Run("taken over", func(t *testing.T) {
+		reset()
+		ctx := newCtx()
+		theirs := hold(time.Now().Add(-2 * time.Hour))
+		m.Lock()
+		takeOver = theirs
+		m.Unlock()
+		err := Upload(ctx, uploads, "test", check)
+		require.ErrorContains(t, err, "a: test: locked by other:1 since")
+		require.Empty(t, uploaded)
+		ctx.UploadLocks.Release()
+		require.Equal(t, theirs, lock, "lock held by another run should be kept")
+	})
+
+	t.Run("not released when taken over", func(t *testing.T) {
+		reset()
+		ctx := newCtx()
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		theirs := hold(time.Now())
+		ctx.UploadLocks.Release()
+		require.Equal(t, theirs, lock, "lock held by another run should be kept")
+	})
+
+	t.Run("not an url", func(t *testing.T) {
+		err := Upload(newCtx(), []config.Upload{{
+			Name:     "a",
+			Mode:     ModeArchive,
+			Username: "u1",
+			Target:   srv.URL + "/{{.ProjectName}}/",
+			Lock:     config.UploadLock{Path: "./my.lock", TTL: time.Hour},
+		}}, "test", check)
+		require.EqualError(t, err, "a: test: lock path must be an URL, got ./my.lock")
+	})
+}
+
+func TestGraceContext(t *testing.T) {
+	t.Run("outlives parent", func(t *testing.T) {
+		ctx := testctx.New()
+		parent, cancelParent := stdctx.WithCancel(stdctx.Background())
+		ctx.Context = parent
+		graced, cancel := graceContext(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		cancelParent()
+		select {
+		case <-graced.Done():
+			t.Fatal("graced context should outlive its parent")
+		case <-time.After(10 * time.Millisecond):
+		}
+		select {
+		case <-graced.Done():
+		case <-time.After(time.Second):
+			t.Fatal("graced context should be done after the grace period")
+		}
+		require.Equal(t, ctx.Config, graced.Config)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		graced, cancel := graceContext(testctx.New(), time.Hour)
+		cancel()
+		require.ErrorIs(t, graced.Err(), stdctx.Canceled)
+	})
+}
+
+func TestUploadCritical(t *testing.T) {
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	release := make(chan struct{})
+	var m sync.Mutex
+	var uploaded []string
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.ReadAll(r.Body)
+		arrived.Done()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		m.Lock()
+		uploaded = append(uploaded, r.URL.Path)
+		m.Unlock()
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	for _, name := range []string{"release-failed", "a.tar.gz"} {
+		file := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(file, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: file, Type: artifact.UploadableArchive})
+	}
+	parent, cancel := stdctx.WithCancel(stdctx.Background())
+	ctx.Context = parent
+	go func() {
+		arrived.Wait()
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+
+	uploads := []config.Upload{{
+		Name:     "a",
+		Mode:     ModeArchive,
+		Username: "u1",
+		Target:   srv.URL + "/",
+		Critical: []string{"release-*"},
+	}}
+	require.NoError(t, Defaults(uploads))
+	err := Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	})
+	require.ErrorIs(t, err, stdctx.Canceled)
+	require.Equal(t, []string{"/release-failed"}, uploaded)
+}
+
+func TestUploadCriticalAfterDone(t *testing.T) {
+	// the context is done before the lower priority instance and its lock are
+	// reached, but its critical uploads still happen.
+	var m sync.Mutex
+	var lock []byte
+	var uploaded []string
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		body, _ := io.ReadAll(r.Body)
+		m.Lock()
+		defer m.Unlock()
+		if r.URL.Path == "/b/blah.lock" {
+			switch r.Method {
+			case h.MethodGet:
+				if lock == nil {
+					w.WriteHeader(h.StatusNotFound)
+					return
+				}
+				_, _ = w.Write(lock)
+			case h.MethodPut:
+				lock = body
+				w.WriteHeader(h.StatusCreated)
+			case h.MethodDelete:
+				lock = nil
+				w.WriteHeader(h.StatusNoContent)
+			}
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/b/") {
+			require.NotNil(t, lock, "uploaded without holding the lock")
+		}
+		uploaded = append(uploaded, r.URL.Path)
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x", "TEST_B_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.FailFast = true
+	for _, name := range []string{"release-failed", "a.tar.gz"} {
+		file := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(file, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: file, Type: artifact.UploadableArchive})
+	}
+	parent, cancel := stdctx.WithCancel(stdctx.Background())
+	cancel()
+	ctx.Context = parent
+
+	uploads := []config.Upload{
+		{
+			Name:     "a",
+			Mode:     ModeArchive,
+			Username: "u1",
+			Target:   srv.URL + "/a/",
+			Priority: 2,
+		},
+		{
+			Name:     "b",
+			Mode:     ModeArchive,
+			Username: "u1",
+			Target:   srv.URL + "/b/",
+			Priority: 1,
+			Critical: []string{"release-*"},
+			Lock:     config.UploadLock{Path: srv.URL + "/b/{{ .ProjectName }}.lock"},
+		},
+	}
+	require.NoError(t, Defaults(uploads))
+	err := Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	})
+	require.ErrorIs(t, err, stdctx.Canceled)
+	require.Equal(t, []string{"/b/release-failed"}, uploaded)
+	ctx.UploadLocks.Release()
+	require.Nil(t, lock, "lock should be released")
+}
+
+func TestWaitCritical(t *testing.T) {
+	t.Run("nothing running", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			WaitCritical(testctx.New())
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("should not wait when no critical upload is running")
+		}
+	})
+
+	t.Run("waits for running uploads", func(t *testing.T) {
+		ctx := testctx.New()
+		finish := ctx.CriticalUploads.Start(time.Hour)
+		done := make(chan struct{})
+		go func() {
+			WaitCritical(ctx)
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Fatal("should wait for the running upload")
+		case <-time.After(10 * time.Millisecond):
+		}
+		finish()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("should be done once the upload finished")
+		}
+	})
+
+	t.Run("new context", func(t *testing.T) {
+		// a new release doesn't wait for the uploads of an earlier one.
+		defer testctx.New().CriticalUploads.Start(time.Hour)()
+		_, running := testctx.New().CriticalUploads.Running()
+		require.False(t, running)
+	})
+}
+
+func TestUploadBuildsConcurrently(t *testing.T) {
+	// artifacts from every build share the same bounded group, so uploads of
+	// different builds run at the same time.
+	var inflight sync.WaitGroup
+	inflight.Add(2)
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.ReadAll(r.Body)
+		inflight.Done()
+		done := make(chan struct{})
+		go func() {
+			inflight.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			w.WriteHeader(h.StatusCreated)
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(h.StatusRequestTimeout)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.Parallelism = 2
+	for _, id := range []string{"server", "client"} {
+		file := filepath.Join(folder, id)
+		require.NoError(t, os.WriteFile(file, []byte(id), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Name:  id,
+			Path:  file,
+			Type:  artifact.UploadableBinary,
+			Extra: map[string]interface{}{artifact.ExtraID: id},
+		})
+	}
+
+	require.NoError(t, Upload(ctx, []config.Upload{{
+		Name:     "a",
+		Mode:     ModeBinary,
+		Method:   h.MethodPut,
+		Username: "u1",
+		Target:   srv.URL + "/",
+	}}, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}))
+}
+
+func TestUploadInstancesConcurrently(t *testing.T) {
+	// each server waits for the other one to get a request, so the uploads
+	// only succeed if both instances are handled at the same time.
+	started := map[string]chan struct{}{"a": make(chan struct{}), "b": make(chan struct{})}
+	var m sync.Mutex
+	received := map[string][]string{}
+	newServer := func(name, other string) *httptest.Server {
+		var once sync.Once
+		srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+			_, _ = io.ReadAll(r.Body)
+			once.Do(func() { close(started[name]) })
+			select {
+			case <-started[other]:
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(h.StatusRequestTimeout)
+				return
+			}
+			m.Lock()
+			received[name] = append(received[name], path.Base(r.URL.Path))
+			m.Unlock()
+			w.WriteHeader(h.StatusCreated)
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	srvA := newServer("a", "b")
+	srvB := newServer("b", "a")
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	ctx.Parallelism = 2
+	for _, name := range []string{"a.deb", "b.deb"} {
+		file := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(file, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: file, Type: artifact.LinuxPackage})
+	}
+
+	require.NoError(t, Upload(ctx, []config.Upload{
+		{Name: "a", Mode: ModeArchive, Method: h.MethodPut, Target: srvA.URL + "/"},
+		{Name: "b", Mode: ModeArchive, Method: h.MethodPut, Target: srvB.URL + "/"},
+	}, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}))
+	for _, name := range []string{"a", "b"} {
+		require.ElementsMatch(t, []string{"a.deb", "b.deb"}, received[name], name)
+	}
+}
+
+func TestUploadTagAfterPublish(t *testing.T) {
+	status := h.StatusCreated
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := testlib.Mktmp(t)
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	remote := testlib.GitMakeBareRepository(t)
+	testlib.GitRemoteAdd(t, remote)
+	commit, err := git.Clean(git.Run(stdctx.Background(), "rev-parse", "HEAD"))
+	require.NoError(t, err)
+
+	file := filepath.Join(folder, "a.deb")
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0o644))
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x", "TEST_B_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"), testctx.WithCommit(commit))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: file, Type: artifact.LinuxPackage})
+	uploads := []config.Upload{
+		{Name: "a", Username: "u1", Target: srv.URL + "/a/", TagAfterPublish: "published/{{ .Version }}"},
+		{Name: "b", Username: "u1", Target: srv.URL + "/b/", TagAfterPublish: "published/{{ .Version }}"},
+	}
+	require.NoError(t, Defaults(uploads))
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+	remoteTags := func() []string {
+		tags, err := git.CleanAllLines(git.Run(stdctx.Background(), "-C", remote, "tag", "-l"))
+		require.NoError(t, err)
+		return tags
+	}
+
+	t.Run("failed upload", func(t *testing.T) {
+		status = h.StatusInternalServerError
+		t.Cleanup(func() { status = h.StatusCreated })
+		require.Error(t, Upload(ctx, uploads, "test", check))
+		require.Empty(t, ctx.PublishTags)
+		require.NoError(t, TagPublished(ctx))
+		require.Empty(t, remoteTags())
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		ctx.Snapshot = true
+		t.Cleanup(func() { ctx.Snapshot = false })
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.NoError(t, TagPublished(ctx))
+		require.Empty(t, remoteTags())
+	})
+
+	t.Run("published", func(t *testing.T) {
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.NoError(t, Upload(ctx, uploads, "other", check))
+		require.Equal(t, []string{"published/2.1.0"}, ctx.PublishTags)
+		require.Empty(t, remoteTags(), "only tagged once publishing is done")
+		require.NoError(t, TagPublished(ctx))
+		require.Equal(t, []string{"published/2.1.0"}, remoteTags())
+		tagged, err := git.Clean(git.Run(stdctx.Background(), "-C", remote, "rev-list", "-n1", "published/2.1.0"))
+		require.NoError(t, err)
+		require.Equal(t, commit, tagged)
+	})
+
+	t.Run("run again", func(t *testing.T) {
+		require.NoError(t, TagPublished(ctx))
+		require.Equal(t, []string{"published/2.1.0"}, remoteTags())
+	})
+
+	t.Run("tagged on another commit", func(t *testing.T) {
+		testlib.GitCommit(t, "second")
+		testlib.GitTag(t, "published/2.2.0")
+		ctx.PublishTags = []string{"published/2.2.0"}
+		t.Cleanup(func() { ctx.PublishTags = nil })
+		require.ErrorContains(t, TagPublished(ctx), "tag published/2.2.0 already exists on commit ")
+		require.Equal(t, []string{"published/2.1.0"}, remoteTags())
+	})
+}
+
+func TestUploadRedirected(t *testing.T) {
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if strings.HasPrefix(r.URL.Path, "/a/") {
+			h.Redirect(w, r, "/b/"+path.Base(r.URL.Path), h.StatusFound)
+			return
+		}
+		w.WriteHeader(h.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	file := filepath.Join(folder, "a.deb")
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0o644))
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: file, Type: artifact.LinuxPackage})
+	uploads := []config.Upload{{Name: "a", Username: "u1", Target: srv.URL + "/a/"}}
+	require.NoError(t, Defaults(uploads))
+
+	err := Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		return nil
+	})
+	require.ErrorContains(t, err, "PUT "+srv.URL+"/a/a.deb was redirected to GET "+srv.URL+"/b/a.deb, the artifact was not uploaded")
+}
+
+func TestUploadRedirectedTemporarily(t *testing.T) {
+	for _, status := range []int{h.StatusTemporaryRedirect, h.StatusPermanentRedirect} {
+		for _, retries := range []int{0, 2} {
+			t.Run(fmt.Sprintf("%d retries %d", status, retries), func(t *testing.T) {
+				var lock sync.Mutex
+				received := map[string]string{}
+				srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+					if strings.HasPrefix(r.URL.Path, "/a/") {
+						h.Redirect(w, r, "/b/"+path.Base(r.URL.Path), status)
+						return
+					}
+					bts, err := io.ReadAll(r.Body)
+					if err != nil {
+						w.WriteHeader(h.StatusInternalServerError)
+						return
+					}
+					lock.Lock()
+					defer lock.Unlock()
+					received[r.Method+" "+r.URL.Path] = string(bts)
+					w.WriteHeader(h.StatusCreated)
+				}))
+				t.Cleanup(srv.Close)
+
+				folder := t.TempDir()
+				file := filepath.Join(folder, "a.deb")
+				require.NoError(t, os.WriteFile(file, []byte("a"), 0o644))
+				ctx := testctx.NewWithCfg(config.Project{
+					ProjectName: "blah",
+					Env:         []string{"TEST_A_SECRET=x"},
+				}, testctx.WithVersion("2.1.0"))
+				ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: file, Type: artifact.LinuxPackage})
+				uploads := []config.Upload{{Name: "a", Username: "u1", Target: srv.URL + "/a/", Retries: retries}}
+				require.NoError(t, Defaults(uploads))
+
+				var landed string
+				require.NoError(t, Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+					landed = r.Request.URL.String()
+					return nil
+				}))
+				require.Equal(t, srv.URL+"/b/a.deb", landed)
+				require.Equal(t, map[string]string{"PUT /b/a.deb": "a"}, received)
+
+				uploads[0].FailOnRedirect = true
+				require.ErrorContains(t, Upload(ctx, uploads, "test", func(*h.Response, *config.Upload, *artifact.Artifact) error {
+					return nil
+				}), "PUT "+srv.URL+"/a/a.deb was redirected to "+srv.URL+"/b/a.deb")
+			})
+		}
+	}
+}
+
+func TestUploadAuthType(t *testing.T) {
+	for authType, check := range map[string]func(r *h.Request) error{
+		"": func(r *h.Request) error {
+			if u, p, ok := r.BasicAuth(); !ok || u != "u" || p != "x" {
+				return fmt.Errorf("unexpected basic auth: %q %q", u, p)
+			}
+			return nil
+		},
+		AuthAPIKey: func(r *h.Request) error {
+			if r.Header.Get("X-JFrog-Art-Api") != "x" || r.Header.Get("Authorization") != "" {
+				return fmt.Errorf("unexpected headers: %v", r.Header)
+			}
+			return nil
+		},
+		AuthBearer: func(r *h.Request) error {
+			if r.Header.Get("Authorization") != "Bearer x" {
+				return fmt.Errorf("unexpected headers: %v", r.Header)
+			}
+			return nil
+		},
+		AuthGitLab: func(r *h.Request) error {
+			if r.Header.Get("PRIVATE-TOKEN") != "x" || r.Header.Get("Authorization") != "" {
+				return fmt.Errorf("unexpected headers: %v", r.Header)
+			}
+			return nil
+		},
+		AuthGitLabJob: func(r *h.Request) error {
+			if r.Header.Get("JOB-TOKEN") != "x" || r.Header.Get("Authorization") != "" {
+				return fmt.Errorf("unexpected headers: %v", r.Header)
+			}
+			return nil
+		},
+	} {
+		t.Run("auth type "+authType, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+				hits.Add(1)
+				if err := check(r); err != nil {
+					w.WriteHeader(h.StatusUnauthorized)
+					_, _ = io.WriteString(w, err.Error())
+					return
+				}
+				w.WriteHeader(h.StatusCreated)
+			}))
+			t.Cleanup(srv.Close)
+
+			folder := t.TempDir()
+			path := filepath.Join(folder, "a.deb")
+			require.NoError(t, os.WriteFile(path, []byte("a"), 0o644))
+			ctx := testctx.NewWithCfg(config.Project{
+				ProjectName: "blah",
+				Env:         []string{"TEST_A_SECRET=x"},
+			}, testctx.WithVersion("2.1.0"))
+			ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: path, Type: artifact.LinuxPackage})
+			uploads := []config.Upload{{
+				Name:     "a",
+				Target:   srv.URL + "/",
+				Username: "u",
+				AuthType: authType,
+				Mode:     ModeArchive,
+				Method:   h.MethodPut,
+			}}
+			require.NoError(t, Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+				if r.StatusCode != h.StatusCreated {
+					return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+				}
+				return nil
+			}))
+			require.Equal(t, int32(1), hits.Load())
+		})
+	}
+}
+
+func TestUploadUsernameTemplate(t *testing.T) {
+	var user atomic.Value
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || p != "x" {
+			w.WriteHeader(h.StatusUnauthorized)
+			return
+		}
+		user.Store(u)
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	path := filepath.Join(folder, "a.deb")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0o644))
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x", "DEPLOY_USER=deployer"},
+	}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: path, Type: artifact.LinuxPackage})
+
+	t.Run("ok", func(t *testing.T) {
+		uploads := []config.Upload{{
+			Name:     "a",
+			Target:   srv.URL + "/",
+			Username: "{{ .Env.DEPLOY_USER }}-{{ .Version }}",
+			Mode:     ModeArchive,
+			Method:   h.MethodPut,
+		}}
+		require.NoError(t, CheckConfig(ctx, &uploads[0], "test"))
+		require.NoError(t, Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+			if r.StatusCode != h.StatusCreated {
+				return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+			}
+			return nil
+		}))
+		require.Equal(t, "deployer-2.1.0", user.Load())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		upload := config.Upload{
+			Name:     "a",
+			Target:   srv.URL + "/",
+			Username: "{{ .Env.NOPE }}",
+			Mode:     ModeArchive,
+		}
+		err := CheckConfig(ctx, &upload, "test")
+		testlib.AssertSkipped(t, err)
+		require.ErrorContains(t, err, "'username' is required")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		uploads := []config.Upload{{
+			Name:     "a",
+			Target:   srv.URL + "/",
+			Username: "{{ .Nope }}",
+			Mode:     ModeArchive,
+			Method:   h.MethodPut,
+		}}
+		testlib.RequireTemplateError(t, Upload(ctx, uploads, "test", func(_ *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+			return nil
+		}))
+	})
+}
+
+func TestUploadRetries(t *testing.T) {
+	requestRetryDelay = time.Millisecond
+	t.Cleanup(func() { requestRetryDelay = time.Second })
+
+	setup := func(t *testing.T, handler func(attempt int32, w h.ResponseWriter)) (*context.Context, []config.Upload, *atomic.Int32) {
+		t.Helper()
+		var attempts atomic.Int32
+		srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+			attempt := attempts.Add(1)
+			bts, err := io.ReadAll(r.Body)
+			if err != nil || string(bts) != "some content" {
+				w.WriteHeader(h.StatusBadRequest)
+				return
+			}
+			handler(attempt, w)
+		}))
+		t.Cleanup(srv.Close)
+
+		folder := t.TempDir()
+		path := filepath.Join(folder, "a.deb")
+		require.NoError(t, os.WriteFile(path, []byte("some content"), 0o644))
+		ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: path, Type: artifact.LinuxPackage})
+		return ctx, []config.Upload{{
+			Name:    "a",
+			Target:  srv.URL + "/",
+			Mode:    ModeArchive,
+			Method:  h.MethodPut,
+			Retries: 3,
+		}}, &attempts
+	}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode != h.StatusCreated {
+			return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+		}
+		return nil
+	}
+
+	t.Run("server errors", func(t *testing.T) {
+		ctx, uploads, attempts := setup(t, func(attempt int32, w h.ResponseWriter) {
+			if attempt <= 2 {
+				w.WriteHeader(h.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(h.StatusCreated)
+		})
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		ctx, uploads, attempts := setup(t, func(_ int32, w h.ResponseWriter) {
+			w.WriteHeader(h.StatusBadGateway)
+		})
+		require.ErrorContains(t, Upload(ctx, uploads, "test", check), "unexpected status code: 502")
+		require.Equal(t, int32(4), attempts.Load())
+	})
+
+	t.Run("client errors", func(t *testing.T) {
+		ctx, uploads, attempts := setup(t, func(_ int32, w h.ResponseWriter) {
+			w.WriteHeader(h.StatusForbidden)
+		})
+		require.ErrorContains(t, Upload(ctx, uploads, "test", check), "unexpected status code: 403")
+		require.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		ctx, uploads, attempts := setup(t, func(_ int32, w h.ResponseWriter) {
+			w.WriteHeader(h.StatusServiceUnavailable)
+		})
+		uploads[0].RetryBudget = 1
+		require.Error(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, int32(2), attempts.Load())
+
+		// the budget is used up for the rest of the release.
+		require.Error(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, uploads, attempts := setup(t, func(attempt int32, w h.ResponseWriter) {
+			if attempt == 1 {
+				time.Sleep(500 * time.Millisecond)
+			}
+			w.WriteHeader(h.StatusCreated)
+		})
+		uploads[0].Timeout = 100 * time.Millisecond
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, uploads, attempts := setup(t, func(_ int32, w h.ResponseWriter) {
+			w.WriteHeader(h.StatusServiceUnavailable)
+		})
+		requestRetryDelay = time.Minute
+		t.Cleanup(func() { requestRetryDelay = time.Millisecond })
+		cctx, cancel := stdctx.WithTimeout(ctx.Context, 100*time.Millisecond)
+		defer cancel()
+		ctx.Context = cctx
+		require.ErrorIs(t, Upload(ctx, uploads, "test", check), stdctx.DeadlineExceeded)
+		require.Equal(t, int32(1), attempts.Load())
+	})
+}
+
+func TestUploadChecksumDeploy(t *testing.T) {
+	const (
+		sha256sum = "43d250d92b5dbb47f75208de8e9a9a321d23e85eed0dc3d5dfa83bc3cc5aa68c"
+		sha1sum   = "a465b6fe36f51a8c521de658820ce161b8d208fa"
+	)
+	var m sync.Mutex
+	var requests []string
+	blobs := map[string]bool{}
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.Header.Get("X-Checksum-Sha256") != sha256sum || r.Header.Get("X-Checksum-Sha1") != sha1sum {
+			w.WriteHeader(h.StatusBadRequest)
+			return
+		}
+		bts, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(h.StatusBadRequest)
+			return
+		}
+		m.Lock()
+		defer m.Unlock()
+		if r.Header.Get("X-Checksum-Deploy") == "true" {
+			requests = append(requests, "checksum")
+			if len(bts) != 0 {
+				w.WriteHeader(h.StatusBadRequest)
+				return
+			}
+			if !blobs[sha256sum] {
+				w.WriteHeader(h.StatusNotFound)
+				return
+			}
+			w.WriteHeader(h.StatusOK)
+			return
+		}
+		requests = append(requests, "full")
+		blobs[sha256sum] = true
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "a.deb")
+	require.NoError(t, os.WriteFile(path, []byte("hello\ngo\n"), 0o644))
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: path, Type: artifact.LinuxPackage})
+	uploads := []config.Upload{{
+		Name:           "a",
+		Target:         srv.URL + "/",
+		Mode:           ModeArchive,
+		Method:         h.MethodPut,
+		ChecksumHeader: "X-Checksum-SHA256",
+		ChecksumDeploy: true,
+	}}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode != h.StatusOK && r.StatusCode != h.StatusCreated {
+			return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+		}
+		return nil
+	}
+
+	t.Run("miss", func(t *testing.T) {
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, []string{"checksum", "full"}, requests)
+	})
+
+	t.Run("hit", func(t *testing.T) {
+		requests = nil
+		require.NoError(t, Upload(ctx, uploads, "test", check))
+		require.Equal(t, []string{"checksum"}, requests)
+	})
+}
+
+func TestUploadChecksumDeployKeepsArtifactChecksum(t *testing.T) {
+	// concurrent instances checksum the same artifact, which should neither
+	// race nor overwrite its recorded checksum.
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.WriteHeader(h.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "a.deb")
+	require.NoError(t, os.WriteFile(path, []byte("hello\ngo\n"), 0o644))
+	const checksum = "sha256:43d250d92b5dbb47f75208de8e9a9a321d23e85eed0dc3d5dfa83bc3cc5aa68c"
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name: "a.deb",
+		Path: path,
+		Type: artifact.LinuxPackage,
+		Extra: map[string]any{
+			artifact.ExtraChecksum: checksum,
+		},
+	})
+	var uploads []config.Upload
+	for _, name := range []string{"a", "b", "c", "d"} {
+		uploads = append(uploads, config.Upload{
+			Name:           name,
+			Target:         srv.URL + "/" + name + "/",
+			Mode:           ModeArchive,
+			Method:         h.MethodPut,
+			ChecksumDeploy: true,
+		})
+	}
+	require.NoError(t, Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode != h.StatusOK {
+			return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+		}
+		return nil
+	}))
+	deb := ctx.Artifacts.List()[0]
+	require.Equal(t, checksum, artifact.ExtraOr(*deb, artifact.ExtraChecksum, ""))
+}
+
+func TestUploadFailFast(t *testing.T) {
+	setup := func(t *testing.T, failFast bool) (*context.Context, []config.Upload, *sync.Map) {
+		t.Helper()
+		var requests sync.Map
+		srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+			requests.Store(r.URL.Path, true)
+			if strings.HasPrefix(path.Base(r.URL.Path), "bad") {
+				w.WriteHeader(h.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(h.StatusCreated)
+		}))
+		t.Cleanup(srv.Close)
+
+		folder := t.TempDir()
+		ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+		ctx.Parallelism = 1
+		ctx.FailFast = failFast
+		for _, name := range []string{"bad1.deb", "bad2.deb", "good.deb"} {
+			path := filepath.Join(folder, name)
+			require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+			ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: path, Type: artifact.LinuxPackage})
+		}
+		var uploads []config.Upload
+		for _, name := range []string{"a", "b"} {
+			uploads = append(uploads, config.Upload{
+				Name:   name,
+				Target: srv.URL + "/" + name + "/",
+				Mode:   ModeArchive,
+				Method: h.MethodPut,
+			})
+		}
+		return ctx, uploads, &requests
+	}
+	check := func(r *h.Response, _ *config.Upload, a *artifact.Artifact) error {
+		if r.StatusCode != h.StatusCreated {
+			return fmt.Errorf("%s: unexpected status code: %d", a.Name, r.StatusCode)
+		}
+		return nil
+	}
+	count := func(m *sync.Map) int {
+		var n int
+		m.Range(func(_, _ any) bool {
+			n++
+			return true
+		})
+		return n
+	}
+
+	t.Run("best effort", func(t *testing.T) {
+		ctx, uploads, requests := setup(t, false)
+		err := Upload(ctx, uploads, "test", check)
+		require.EqualError(t, err, strings.Join([]string{
+			"a: test: upload failed: bad1.deb: unexpected status code: 500",
+			"a: test: upload failed: bad2.deb: unexpected status code: 500",
+			"b: test: upload failed: bad1.deb: unexpected status code: 500",
+			"b: test: upload failed: bad2.deb: unexpected status code: 500",
+		}, "\n"))
+		require.Equal(t, 6, count(requests))
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		ctx, uploads, requests := setup(t, true)
+		err := Upload(ctx, uploads, "test", check)
+		require.EqualError(t, err, "a: test: upload failed: bad1.deb: unexpected status code: 500")
+		require.Equal(t, 1, count(requests))
+	})
+}
+
+// countingAsset counts how many times it was closed.
+type countingAsset struct {
+	io.ReadSeeker
+	closes *atomic.Int32
+}
+
+func (a countingAsset) Close() error {
+	a.closes.Add(1)
+	return nil
+}
+
+func TestUploadClosesEveryAsset(t *testing.T) {
+	requestRetryDelay = time.Millisecond
+	t.Cleanup(func() { requestRetryDelay = time.Second })
+
+	var opens, closes atomic.Int32
+	assetOpen = func(_ string, a *artifact.Artifact) (*asset, error) {
+		opens.Add(1)
+		return &asset{
+			ReadCloser: countingAsset{bytes.NewReader([]byte(a.Name)), &closes},
+			Size:       int64(len(a.Name)),
+		}, nil
+	}
+	defer assetOpenReset()
+
+	var failed sync.Map
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		bts, _ := io.ReadAll(r.Body)
+		if string(bts) != path.Base(r.URL.Path) {
+			w.WriteHeader(h.StatusBadRequest)
+			return
+		}
+		// fail the first attempt of every upload, so they are all retried
+		if _, retry := failed.LoadOrStore(r.URL.Path, true); !retry {
+			w.WriteHeader(h.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	for _, name := range []string{"a.deb", "b.deb", "c.deb"} {
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: name, Type: artifact.LinuxPackage})
+	}
+	var uploads []config.Upload
+	for _, name := range []string{"a", "b"} {
+		uploads = append(uploads, config.Upload{
+			Name:    name,
+			Target:  srv.URL + "/" + name + "/",
+			Mode:    ModeArchive,
+			Method:  h.MethodPut,
+			Retries: 1,
+		})
+	}
+	require.NoError(t, Upload(ctx, uploads, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode != h.StatusCreated {
+			return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+		}
+		return nil
+	}))
+	// every artifact is opened once per instance, and closed once it is
+	// uploaded, no matter the retries.
+	require.Equal(t, int32(6), opens.Load())
+	require.Equal(t, int32(6), closes.Load())
+}
+
+func TestProgressReader(t *testing.T) {
+	progressInterval = 0
+	t.Cleanup(func() { progressInterval = 10 * time.Second })
+
+	content := bytes.Repeat([]byte("a"), 1024*1024)
+	var reports []int64
+	r := newProgressReader(io.NopCloser(bytes.NewReader(content)), func(read int64) {
+		reports = append(reports, read)
+	})
+	n, err := io.CopyBuffer(io.Discard, struct{ io.Reader }{r}, make([]byte, 64*1024))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), n)
+	require.GreaterOrEqual(t, len(reports), 16)
+	require.IsIncreasing(t, reports[:16])
+	require.Equal(t, int64(len(content)), reports[len(reports)-1])
+
+	progressInterval = time.Hour
+	reports = nil
+	r = newProgressReader(io.NopCloser(bytes.NewReader(content)), func(read int64) {
+		reports = append(reports, read)
+	})
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	require.Empty(t, reports)
+}
+
+func TestUploadProxy(t *testing.T) {
+	var m sync.Mutex
+	var hosts []string
+	proxy := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.ReadAll(r.Body)
+		m.Lock()
+		hosts = append(hosts, r.URL.Host)
+		m.Unlock()
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(proxy.Close)
+	t.Setenv("HTTP_PROXY", "http://127.0.0.1:1")
+
+	path := filepath.Join(t.TempDir(), "a.deb")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0o644))
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "a.deb", Path: path, Type: artifact.LinuxPackage})
+
+	require.NoError(t, Upload(ctx, []config.Upload{{
+		Name:   "a",
+		Target: "http://artifacts.example.com/repo/",
+		Mode:   ModeArchive,
+		Method: h.MethodPut,
+		Proxy:  proxy.URL,
+	}}, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode != h.StatusCreated {
+			return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+		}
+		return nil
+	}))
+	require.Equal(t, []string{"artifacts.example.com"}, hosts)
+}
+
+func TestDetectContentType(t *testing.T) {
+	for name, want := range map[string]string{
+		"blah_1.0_linux_amd64.tar.gz":  "application/gzip",
+		"blah_1.0_windows_amd64.zip":   "application/zip",
+		"blah_1.0_linux_amd64.tar.xz":  "application/x-xz",
+		"blah_1.0_linux_amd64.tar.zst": "application/zstd",
+		"blah_1.0_amd64.deb":           "application/vnd.debian.binary-package",
+		"checksums.txt":                "text/plain; charset=utf-8",
+		"blah":                         "application/octet-stream",
+		"blah_1.0_linux_amd64.nope":    "application/octet-stream",
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, want, detectContentType(name))
+		})
+	}
+}
+
+func TestUploadContentType(t *testing.T) {
+	var m sync.Mutex
+	contentTypes := map[string]string{}
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.ReadAll(r.Body)
+		m.Lock()
+		contentTypes[path.Base(r.URL.Path)] = r.Header.Get("Content-Type")
+		m.Unlock()
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	for name, typ := range map[string]artifact.Type{
+		"blah.tar.gz": artifact.UploadableArchive,
+		"blah.zip":    artifact.UploadableArchive,
+		"blah":        artifact.UploadableBinary,
+	} {
+		path := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: path, Type: typ})
+	}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode != h.StatusCreated {
+			return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+		}
+		return nil
+	}
+
+	t.Run("detected", func(t *testing.T) {
+		require.NoError(t, Upload(ctx, []config.Upload{
+			{Name: "a", Target: srv.URL + "/", Mode: ModeArchive, Method: h.MethodPut},
+			{Name: "b", Target: srv.URL + "/", Mode: ModeBinary, Method: h.MethodPut},
+		}, "test", check))
+		require.Equal(t, map[string]string{
+			"blah.tar.gz": "application/gzip",
+			"blah.zip":    "application/zip",
+			"blah":        "application/octet-stream",
+		}, contentTypes)
+	})
+
+	t.Run("override", func(t *testing.T) {
+		require.NoError(t, Upload(ctx, []config.Upload{{
+			Name:        "a",
+			Target:      srv.URL + "/",
+			Mode:        ModeBinary,
+			Method:      h.MethodPut,
+			ContentType: "application/x-{{ .ArtifactName }}",
+		}}, "test", check))
+		require.Equal(t, "application/x-blah", contentTypes["blah"])
+	})
+
+	t.Run("custom header", func(t *testing.T) {
+		require.NoError(t, Upload(ctx, []config.Upload{{
+			Name:          "a",
+			Target:        srv.URL + "/",
+			Mode:          ModeBinary,
+			Method:        h.MethodPut,
+			ContentType:   "application/x-blah",
+			CustomHeaders: map[string]string{"content-type": "text/plain"},
+		}}, "test", check))
+		require.Equal(t, "text/plain", contentTypes["blah"])
+	})
+}
+
+func TestUploadDryRun(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, _ *h.Request) {
+		requests.Add(1)
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	for _, name := range []string{"a.deb", "b.deb"} {
+		path := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: path, Type: artifact.LinuxPackage})
+	}
+	check := func(*h.Response, *config.Upload, *artifact.Artifact) error { return nil }
+
+	t.Run("skips uploading", func(t *testing.T) {
+		err := Upload(ctx, []config.Upload{{
+			Name:   "a",
+			Target: srv.URL + "/{{ .Version }}/",
+			Mode:   ModeArchive,
+			Method: h.MethodPut,
+			DryRun: true,
+		}}, "test", check)
+		require.True(t, pipe.IsSkip(err), err)
+		require.EqualError(t, err, "test: a is a dry run")
+		require.Zero(t, requests.Load())
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		err := Upload(ctx, []config.Upload{{
+			Name:   "a",
+			Target: srv.URL + "/{{ .Nope }}/",
+			Mode:   ModeArchive,
+			Method: h.MethodPut,
+			DryRun: true,
+		}}, "test", check)
+		require.ErrorContains(t, err, "a: test: error while building target URL")
+		require.Zero(t, requests.Load())
+	})
+
+	t.Run("missing artifact", func(t *testing.T) {
+		ctx.Artifacts.Add(&artifact.Artifact{Name: "c.deb", Path: filepath.Join(folder, "c.deb"), Type: artifact.LinuxPackage})
+		err := Upload(ctx, []config.Upload{{
+			Name:   "a",
+			Target: srv.URL + "/",
+			Mode:   ModeArchive,
+			Method: h.MethodPut,
+			DryRun: true,
+		}}, "test", check)
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Zero(t, requests.Load())
+	})
+}
+
+func TestCheckRedirect(t *testing.T) {
+	req, err := h.NewRequest(h.MethodPut, "https://example.com/a/a.deb", nil)
+	require.NoError(t, err)
+	landed, err := h.NewRequest(h.MethodPut, "https://example.com/b/a.deb", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, checkRedirect(&config.Upload{}, req, &h.Response{Request: req}))
+	require.NoError(t, checkRedirect(&config.Upload{}, req, &h.Response{Request: landed}))
+	require.EqualError(
+		t,
+		checkRedirect(&config.Upload{FailOnRedirect: true}, req, &h.Response{Request: landed}),
+		"PUT https://example.com/a/a.deb was redirected to https://example.com/b/a.deb",
+	)
+}
+
+func TestUploadBatchSmallFiles(t *testing.T) {
+	var m sync.Mutex
+	uploads := map[string][]string{}
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		var entries []string
+		if r.Header.Get("X-Explode-Archive") == "true" {
+			tr := tar.NewReader(r.Body)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					w.WriteHeader(h.StatusBadRequest)
+					return
+				}
+				entries = append(entries, hdr.Name)
+			}
+		}
+		m.Lock()
+		uploads[r.URL.Path] = entries
+		m.Unlock()
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "blah",
+		Env:         []string{"TEST_A_SECRET=x"},
+	}, testctx.WithVersion("2.1.0"))
+	folder := t.TempDir()
+	for name, size := range map[string]int{
+		"small1.tar.gz": 10,
+		"small2.tar.gz": 10,
+		"small3.tar.gz": 10,
+		"large.tar.gz":  100,
+	} {
+		file := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(file, bytes.Repeat([]byte("a"), size), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Name: name,
+			Path: file,
+			Type: artifact.UploadableArchive,
+		})
+	}
+
+	require.NoError(t, Upload(ctx, []config.Upload{{
+		Name:     "a",
+		Mode:     ModeArchive,
+		Username: "u1",
+		Target:   srv.URL + "/{{.ProjectName}}/{{.Version}}/",
+		BatchSmallFiles: config.UploadBatch{
+			Threshold: 50,
+			Size:      2,
+		},
+	}}, "test", func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}))
+
+	require.Len(t, uploads, 3)
+	require.Contains(t, uploads, "/blah/2.1.0/large.tar.gz")
+	require.Empty(t, uploads["/blah/2.1.0/large.tar.gz"])
+	batched := append(uploads["/blah/2.1.0/batch-0.tar"], uploads["/blah/2.1.0/batch-1.tar"]...)
+	require.Len(t, uploads["/blah/2.1.0/batch-0.tar"], 2)
+	require.ElementsMatch(t, []string{"small1.tar.gz", "small2.tar.gz", "small3.tar.gz"}, batched)
+}
+
+func TestResolveTargetURL(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	a := &artifact.Artifact{Name: "a.deb", Path: "/a/a.deb"}
+	for name, tt := range map[string]struct {
+		upload config.Upload
+		want   string
+	}{
+		"trailing slash":    {config.Upload{Target: "https://example.com/{{ .Version }}/"}, "https://example.com/2.1.0/a.deb"},
+		"no trailing slash": {config.Upload{Target: "https://example.com/{{ .Version }}"}, "https://example.com/2.1.0/a.deb"},
+		"custom name": {
+			config.Upload{Target: "https://example.com/{{ .Version }}/{{ .ArtifactName }}", CustomArtifactName: true},
+			"https://example.com/2.1.0/a.deb",
+		},
+		"custom name with trailing slash": {
+			config.Upload{Target: "https://example.com/{{ .Version }}/", CustomArtifactName: true},
+			"https://example.com/2.1.0/",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := resolveTargetURL(ctx, &tt.upload, a, "", "test")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveTargetURLTemplateFuncs(t *testing.T) {
+	ctx := testctx.NewWithCfg(
+		config.Project{ProjectName: "Blah"},
+		testctx.WithVersion("2.1.0"),
+		testctx.WithCurrentTag("v2.1.0"),
+	)
+	a := &artifact.Artifact{Name: "blah_Linux_x86_64.tar.gz", Goos: "Linux", Goarch: "amd64"}
+	for name, tt := range map[string]struct {
+		target string
+		want   string
+	}{
+		"tolower":    {"https://example.com/{{ tolower .ProjectName }}/", "https://example.com/blah/"},
+		"toupper":    {"https://example.com/{{ toupper .Os }}/", "https://example.com/LINUX/"},
+		"replace":    {`https://example.com/{{ replace .Tag "." "_" }}/`, "https://example.com/v2_1_0/"},
+		"trimprefix": {`https://example.com/{{ trimprefix .Tag "v" }}/`, "https://example.com/2.1.0/"},
+		"trimsuffix": {`https://example.com/{{ trimsuffix .ArtifactName ".tar.gz" }}/`, "https://example.com/blah_Linux_x86_64/"},
+		"time":       {`https://example.com/{{ time "2006" }}/`, fmt.Sprintf("https://example.com/%d/", time.Now().UTC().Year())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := resolveTargetURL(ctx, &config.Upload{Target: tt.target}, a, "", "test")
+			require.NoError(t, err)
+			require.Equal(t, tt.want+a.Name, got)
+		})
+	}
+}
+
+func TestResolveTargetURLSemver(t *testing.T) {
+	ctx := testctx.NewWithCfg(
+		config.Project{ProjectName: "blah"},
+		testctx.WithVersion("2.3.4-rc1"),
+		testctx.WithCurrentTag("v2.3.4-rc1"),
+		testctx.WithSemver(2, 3, 4, "rc1"),
+	)
+	a := &artifact.Artifact{Name: "blah.tar.gz"}
+	for name, tt := range map[string]struct {
+		target string
+		want   string
+	}{
+		"major":      {"https://example.com/repo/{{ .Major }}/", "https://example.com/repo/2/"},
+		"minor":      {"https://example.com/repo/{{ .Major }}.{{ .Minor }}/", "https://example.com/repo/2.3/"},
+		"patch":      {"https://example.com/repo/{{ .Patch }}/", "https://example.com/repo/4/"},
+		"prerelease": {"https://example.com/repo/{{ .Prerelease }}/", "https://example.com/repo/rc1/"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := resolveTargetURL(ctx, &config.Upload{Target: tt.target}, a, "", "test")
+			require.NoError(t, err)
+			require.Equal(t, tt.want+a.Name, got)
+		})
+	}
+}
+
+func TestResolveTargetURLLayout(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	amd64 := &artifact.Artifact{Name: "blah", Goos: "linux", Goarch: "amd64"}
+	arm := &artifact.Artifact{Name: "blah", Goos: "linux", Goarch: "arm", Goarm: "7"}
+	sums := &artifact.Artifact{Name: "checksums.txt"}
+	for layout, want := range map[string][]string{
+		LayoutSimple: {
+			"https://example.com/repo/blah/2.1.0/blah",
+			"https://example.com/repo/blah/2.1.0/blah",
+			"https://example.com/repo/blah/2.1.0/checksums.txt",
+		},
+		LayoutGo: {
+			"https://example.com/repo/blah/2.1.0/linux/amd64/blah",
+			"https://example.com/repo/blah/2.1.0/linux/armv7/blah",
+			"https://example.com/repo/blah/2.1.0/checksums.txt",
+		},
+	} {
+		t.Run(layout, func(t *testing.T) {
+			for i, a := range []*artifact.Artifact{amd64, arm, sums} {
+				got, err := resolveTargetURL(ctx, &config.Upload{Target: "https://example.com/repo/", Layout: layout}, a, "", "test")
+				require.NoError(t, err)
+				require.Equal(t, want[i], got)
+			}
+		})
+	}
+}
+
+func TestResolveTargetURLsTargetMap(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	mapped := &artifact.Artifact{Name: "a.deb", Path: "/a/a.deb"}
+	unmapped := &artifact.Artifact{Name: "b.deb", Path: "/b/b.deb"}
+	artifacts := []*artifact.Artifact{mapped, unmapped}
+
+	t.Run("mapped", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "targets.yaml")
+		require.NoError(t, os.WriteFile(file, []byte("a.deb: https://example.com/debs/{{ .Version }}/\n"), 0o644))
+		targets, err := resolveTargetURLs(ctx, &config.Upload{
+			Name:          "a",
+			Target:        "https://example.com/{{ .ProjectName }}/",
+			TargetMapFile: file,
+		}, artifacts, "test")
+		require.NoError(t, err)
+		require.Equal(t, map[*artifact.Artifact]string{
+			mapped:   "https://example.com/debs/2.1.0/a.deb",
+			unmapped: "https://example.com/blah/b.deb",
+		}, targets)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "targets.json")
+		require.NoError(t, os.WriteFile(file, []byte(`{"b.deb": "https://example.com/other/"}`), 0o644))
+		targets, err := resolveTargetURLs(ctx, &config.Upload{
+			Name:          "a",
+			Target:        "https://example.com/{{ .ProjectName }}/",
+			TargetMapFile: file,
+		}, artifacts, "test")
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/other/b.deb", targets[unmapped])
+	})
+
+	t.Run("duplicated keys", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "targets.yaml")
+		require.NoError(t, os.WriteFile(file, []byte("a.deb: https://a/\na.deb: https://b/\n"), 0o644))
+		_, err := resolveTargetURLs(ctx, &config.Upload{
+			Name:          "a",
+			Target:        "https://example.com/",
+			TargetMapFile: file,
+		}, artifacts, "test")
+		require.ErrorContains(t, err, "a: test: failed to parse target map")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := resolveTargetURLs(ctx, &config.Upload{
+			Name:          "a",
+			Target:        "https://example.com/",
+			TargetMapFile: filepath.Join(t.TempDir(), "nope.yaml"),
+		}, artifacts, "test")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestResolveTargetURLsCollisions(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	artifacts := []*artifact.Artifact{
+		{Name: "a.deb", Path: "/a/a.deb"},
+		{Name: "b.deb", Path: "/b/b.deb"},
+	}
+
+	t.Run("different properties", func(t *testing.T) {
+		_, err := resolveTargetURLs(ctx, &config.Upload{
+			Name:               "a",
+			Target:             "https://example.com/release",
+			CustomArtifactName: true,
+			Properties:         map[string]string{"name": "{{ .ArtifactName }}"},
+		}, artifacts, "test")
+		require.EqualError(t, err, "a: test: 1 target(s) would be overwritten by another artifact, check your target template or set 'allow_target_collisions'")
+	})
+
+	t.Run("different query", func(t *testing.T) {
+		_, err := resolveTargetURLs(ctx, &config.Upload{
+			Name:               "a",
+			Target:             "https://example.com/release?name={{ .ArtifactName }}",
+			CustomArtifactName: true,
+		}, artifacts, "test")
+		require.ErrorContains(t, err, "1 target(s) would be overwritten")
+	})
+
+	t.Run("different names", func(t *testing.T) {
+		_, err := resolveTargetURLs(ctx, &config.Upload{
+			Name:       "a",
+			Target:     "https://example.com/",
+			Properties: map[string]string{"name": "{{ .ArtifactName }}"},
+		}, artifacts, "test")
+		require.NoError(t, err)
+	})
+}
+
+func TestDeployedURL(t *testing.T) {
+	for target, expected := range map[string]string{
+		"https://example.com/a.deb":                  "https://example.com/a.deb",
+		"https://example.com/a.deb;os=linux;team=a":  "https://example.com/a.deb",
+		"https://example.com/a.deb?x=1":              "https://example.com/a.deb",
+		"https://example.com/a;b/c.deb;os=linux?x=1": "https://example.com/a;b/c.deb",
+		"https://example.com/a.deb;os=linux%2Famd64": "https://example.com/a.deb",
+	} {
+		require.Equal(t, expected, DeployedURL(target), target)
+	}
+}
+
+func TestResolveTargetURLProperties(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	folder := t.TempDir()
+	path := filepath.Join(folder, "a.deb")
+	require.NoError(t, os.WriteFile(path+".meta.json", []byte(`{"team": "sidecar"}`), 0o644))
+	a := &artifact.Artifact{Name: "a.deb", Path: path, Goos: "linux"}
+
+	upload := &config.Upload{
+		Name:   "a",
+		Target: "https://example.com/",
+		Properties: map[string]string{
+			"team":    "platform",
+			"os":      "{{ .Os }}",
+			"version": "{{ .Version }}",
+		},
+	}
+	target, err := resolveTargetURL(ctx, upload, a, "", "test")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/a.deb;os=linux;team=platform;version=2.1.0", target)
+
+	upload.PropertiesFromSidecar = true
+	upload.SidecarSuffix = ".meta.json"
+	target, err = resolveTargetURL(ctx, upload, a, "", "test")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/a.deb;os=linux;team=sidecar;version=2.1.0", target)
+
+	upload.Properties["bad"] = "{{ .Nope }"
+	_, err = resolveTargetURL(ctx, upload, a, "", "test")
+	require.ErrorContains(t, err, "a: test: failed to resolve properties template")
+}
+
+func TestSidecarProperties(t *testing.T) {
+	folder := t.TempDir()
+	bin := filepath.Join(folder, "mybin")
+	require.NoError(t, os.WriteFile(bin, []byte("bin"), 0o644))
+	upload := &config.Upload{Name: "a", PropertiesFromSidecar: true, SidecarSuffix: ".meta.json"}
+
+	t.Run("no sidecar", func(t *testing.T) {
+		props, err := sidecarProperties(upload, &artifact.Artifact{Path: bin})
+		require.NoError(t, err)
+		require.Empty(t, props)
+	})
+
+	t.Run("flat values", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(bin+".meta.json", []byte(`{
+			"license": "MIT",
+			"component-owner": "team a",
+			"reviewed": true,
+			"nested": {"foo": "bar"},
+			"list": ["a", "b"]
+		}`), 0o644))
+		props, err := sidecarProperties(upload, &artifact.Artifact{Path: bin})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"license":         "MIT",
+			"component-owner": "team a",
+			"reviewed":        "true",
+		}, props)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(bin+".meta.json", []byte(`nope`), 0o644))
+		_, err := sidecarProperties(upload, &artifact.Artifact{Path: bin})
+		require.ErrorContains(t, err, "failed to parse sidecar")
+	})
+}
+
+func TestMatrixParams(t *testing.T) {
+	require.Empty(t, matrixParams(nil))
+	require.Equal(t, ";a=1;b=team%20a;c=x%3By%3Dz", matrixParams(map[string]string{
+		"c": "x;y=z",
+		"b": "team a",
+		"a": "1",
+	}))
+}
+
+func TestUploadDelta(t *testing.T) {
+	previous := bytes.Repeat([]byte("the previous version of the binary "), 100)
+	current := append(bytes.Clone(previous), []byte("with some changes")...)
+
+	var m sync.Mutex
+	uploads := map[string][]byte{}
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		if r.Method == h.MethodGet {
+			if r.URL.Path != "/blah/v2.0.0/mybin" {
+				w.WriteHeader(h.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(previous)
+			return
+		}
+		bts, _ := io.ReadAll(r.Body)
+		m.Lock()
+		uploads[r.URL.Path] = bts
+		m.Unlock()
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	binPath := filepath.Join(t.TempDir(), "mybin")
+	require.NoError(t, os.WriteFile(binPath, current, 0o644))
+	newCtx := func(previousTag string) *context.Context {
+		ctx := testctx.NewWithCfg(config.Project{
+			ProjectName: "blah",
+			Env:         []string{"TEST_A_SECRET=x"},
+		}, testctx.WithCurrentTag("v2.1.0"), testctx.WithPreviousTag(previousTag))
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Name: "mybin",
+			Path: binPath,
+			Type: artifact.UploadableBinary,
+		})
+		return ctx
+	}
+	uploadCfg := func(previous string) []config.Upload {
+		uploads := []config.Upload{{
+			Name:     "a",
+			Mode:     ModeBinary,
+			Username: "u1",
+			Target:   srv.URL + "/{{.ProjectName}}/{{.Tag}}/",
+			Delta: config.UploadDelta{
+				Previous: previous,
+			},
+		}}
+		require.NoError(t, Defaults(uploads))
+		return uploads
+	}
+	is2xx := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode/100 == 2 {
+			return nil
+		}
+		return fmt.Errorf("unexpected http status code: %v", r.StatusCode)
+	}
+
+	t.Run("remote previous", func(t *testing.T) {
+		uploads = map[string][]byte{}
+		ctx := newCtx("v2.0.0")
+		require.NoError(t, Upload(ctx, uploadCfg(srv.URL+"/{{.ProjectName}}/{{.PreviousTag}}/{{.ArtifactName}}"), "test", is2xx))
+		require.Len(t, uploads, 2)
+		require.Equal(t, current, uploads["/blah/v2.1.0/mybin"])
+		patch := uploads["/blah/v2.1.0/mybin.patch"]
+		require.Less(t, len(patch), len(current))
+		got, err := delta.Patch(previous, patch)
+		require.NoError(t, err)
+		require.Equal(t, current, got)
+	})
+
+	t.Run("local previous", func(t *testing.T) {
+		uploads = map[string][]byte{}
+		prevPath := filepath.Join(t.TempDir(), "mybin")
+		require.NoError(t, os.WriteFile(prevPath, previous, 0o644))
+		ctx := newCtx("v2.0.0")
+		require.NoError(t, Upload(ctx, uploadCfg(prevPath), "test", is2xx))
+		require.Len(t, uploads, 2)
+		require.Contains(t, uploads, "/blah/v2.1.0/mybin.patch")
+	})
+
+	t.Run("previous not found", func(t *testing.T) {
+		uploads = map[string][]byte{}
+		ctx := newCtx("v1.0.0")
+		require.NoError(t, Upload(ctx, uploadCfg(srv.URL+"/{{.ProjectName}}/{{.PreviousTag}}/{{.ArtifactName}}"), "test", is2xx))
+		require.Len(t, uploads, 1)
+	})
+
+	t.Run("no previous tag", func(t *testing.T) {
+		uploads = map[string][]byte{}
+		ctx := newCtx("")
+		require.NoError(t, Upload(ctx, uploadCfg(srv.URL+"/{{.ProjectName}}/{{.PreviousTag}}/{{.ArtifactName}}"), "test", is2xx))
+		require.Len(t, uploads, 1)
+	})
+}
+
+func TestTraceRequest(t *testing.T) {
+	srv := httptest.NewTLSServer(h.HandlerFunc(func(w h.ResponseWriter, _ *h.Request) {
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	req, err := h.NewRequest(h.MethodPut, srv.URL+"/foo", nil)
+	require.NoError(t, err)
+	req, trace := traceRequest(req)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Equal(t, srv.URL+"/foo", trace.target)
+	require.Positive(t, trace.connect)
+	require.Positive(t, trace.tls)
+	require.Positive(t, trace.firstByte)
+	trace.log(&config.Upload{Name: "a"})
+}
+
+func TestUploadSeveralBinariesSameTarget(t *testing.T) {
+	var m sync.Mutex
+	var uploaded []string
+	srv := httptest.NewServer(h.HandlerFunc(func(w h.ResponseWriter, r *h.Request) {
+		_, _ = io.ReadAll(r.Body)
+		m.Lock()
+		uploaded = append(uploaded, r.URL.Path)
+		m.Unlock()
+		w.WriteHeader(h.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{ProjectName: "blah"}, testctx.WithVersion("2.1.0"))
+	for _, id := range []string{"server", "client"} {
+		path := filepath.Join(folder, id)
+		require.NoError(t, os.WriteFile(path, []byte(id), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Name:   id,
+			Path:   path,
+			Goos:   "linux",
+			Goarch: "amd64",
+			Type:   artifact.UploadableBinary,
+			Extra: map[string]interface{}{
+				artifact.ExtraID: id,
+			},
+		})
+	}
+	check := func(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+		if r.StatusCode != h.StatusCreated {
+			return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+		}
+		return nil
+	}
+
+	t.Run("all", func(t *testing.T) {
+		uploaded = nil
+		require.NoError(t, Upload(ctx, []config.Upload{
+			{Name: "a", Target: srv.URL + "/{{ .Os }}/{{ .Arch }}/", Mode: ModeBinary, Method: h.MethodPut},
+		}, "test", check))
+		require.ElementsMatch(t, []string{"/linux/amd64/server", "/linux/amd64/client"}, uploaded)
+	})
+
+	t.Run("by id", func(t *testing.T) {
+		uploaded = nil
+		require.NoError(t, Upload(ctx, []config.Upload{
+			{Name: "a", Target: srv.URL + "/{{ .Os }}/{{ .Arch }}/", Mode: ModeBinary, Method: h.MethodPut, IDs: []string{"client"}},
+		}, "test", check))
+		require.Equal(t, []string{"/linux/amd64/client"}, uploaded)
+	})
+}
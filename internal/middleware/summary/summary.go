@@ -0,0 +1,86 @@
+// Package summary keeps track of what happened to each pipe, so it can be
+// reported at the end of a run.
+package summary
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/caarlos0/log"
+	"github.com/goreleaser/goreleaser/internal/middleware"
+	"github.com/goreleaser/goreleaser/internal/pipe"
+	"github.com/goreleaser/goreleaser/pkg/context"
+)
+
+// Wrap tracks the given action under the given title.
+// It should wrap the whole middleware chain of a pipe, including skip.Maybe,
+// so pipes skipped before running are reported too.
+func Wrap(title string, next middleware.Action) middleware.Action {
+	return func(ctx *context.Context) error {
+		if ctx == nil || ctx.Summary == nil {
+			return next(ctx)
+		}
+		ctx.Summary.Begin(title)
+		defer ctx.Summary.End()
+		return next(ctx)
+	}
+}
+
+// Track records the outcome of the given action.
+// It should be the innermost middleware, so skip errors are seen before
+// errhandler.Handle ignores them.
+func Track(next middleware.Action) middleware.Action {
+	return func(ctx *context.Context) error {
+		err := next(ctx)
+		if ctx == nil || ctx.Summary == nil {
+			return err
+		}
+		switch {
+		case err == nil:
+			ctx.Summary.Record(context.PipeRan, "")
+		case pipe.IsSkip(err):
+			ctx.Summary.Record(context.PipeSkipped, fmt.Sprintf("%s: %s", pipe.CategoryOf(err), err))
+		default:
+			ctx.Summary.Record(context.PipeFailed, err.Error())
+		}
+		return err
+	}
+}
+
+// String renders the summary as a table.
+func String(ctx *context.Context) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PIPE\tSTATUS\tREASON")
+	for _, entry := range ctx.Summary.Entries() {
+		_, _ = fmt.Fprintf(
+			w,
+			"%s%s\t%s\t%s\n",
+			strings.Repeat("  ", entry.Depth),
+			entry.Pipe,
+			entry.Status,
+			entry.Reason,
+		)
+	}
+	_ = w.Flush()
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Print logs the summary.
+func Print(ctx *context.Context) {
+	if ctx == nil || ctx.Summary == nil || len(ctx.Summary.Entries()) == 0 {
+		return
+	}
+	log.Info("summary")
+	log.IncreasePadding()
+	defer log.ResetPadding()
+	for _, line := range strings.Split(String(ctx), "\n") {
+		log.Info(line)
+	}
+}
@@ -0,0 +1,89 @@
+package summary
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/internal/middleware/errhandler"
+	"github.com/goreleaser/goreleaser/internal/middleware/skip"
+	"github.com/goreleaser/goreleaser/internal/pipe"
+	"github.com/goreleaser/goreleaser/internal/testctx"
+	"github.com/goreleaser/goreleaser/pkg/context"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePipe struct {
+	name string
+	skip bool
+	err  error
+	run  func(ctx *context.Context) error
+}
+
+func (p fakePipe) String() string                 { return p.name }
+func (p fakePipe) Skip(_ *context.Context) bool   { return p.skip }
+func (p fakePipe) Run(ctx *context.Context) error { return p.runOrErr(ctx) }
+
+func (p fakePipe) runOrErr(ctx *context.Context) error {
+	if p.run != nil {
+		return p.run(ctx)
+	}
+	return p.err
+}
+
+func runAll(ctx *context.Context, pipes ...fakePipe) error {
+	for _, p := range pipes {
+		if err := Wrap(p.String(), skip.Maybe(
+			p,
+			errhandler.Handle(Track(p.Run)),
+		))(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSummary(t *testing.T) {
+	ctx := testctx.New()
+	err := runAll(
+		ctx,
+		fakePipe{name: "building"},
+		fakePipe{name: "docker images", skip: true},
+		fakePipe{name: "publishing", run: func(ctx *context.Context) error {
+			return runAll(
+				ctx,
+				fakePipe{name: "artifactory", err: pipe.SkipWith(pipe.SkipNotConfigured, "missing secret")},
+				fakePipe{name: "blobs", err: pipe.Skip("whatever")},
+				fakePipe{name: "github", err: pipe.ErrSkipPublishEnabled},
+			)
+		}},
+		fakePipe{name: "announcing", err: fmt.Errorf("failed to announce")},
+		fakePipe{name: "never reached"},
+	)
+	require.EqualError(t, err, "failed to announce")
+
+	require.Equal(t, []context.SummaryEntry{
+		{Pipe: "building", Status: context.PipeRan},
+		{Pipe: "docker images", Status: context.PipeSkipped, Reason: "not configured or disabled"},
+		{Pipe: "publishing", Status: context.PipeRan},
+		{Pipe: "artifactory", Status: context.PipeSkipped, Reason: "not configured: missing secret", Depth: 1},
+		{Pipe: "blobs", Status: context.PipeSkipped, Reason: "other: whatever", Depth: 1},
+		{Pipe: "github", Status: context.PipeSkipped, Reason: "disabled: publishing is disabled", Depth: 1},
+		{Pipe: "announcing", Status: context.PipeFailed, Reason: "failed to announce"},
+	}, ctx.Summary.Entries())
+
+	require.Equal(t, `PIPE           STATUS   REASON
+building       ran
+docker images  skipped  not configured or disabled
+publishing     ran
+  artifactory  skipped  not configured: missing secret
+  blobs        skipped  other: whatever
+  github       skipped  disabled: publishing is disabled
+announcing     failed   failed to announce`, String(ctx))
+}
+
+func TestNoSummary(t *testing.T) {
+	require.NoError(t, Wrap("foo", Track(func(_ *context.Context) error {
+		return nil
+	}))(nil))
+	require.NotPanics(t, func() { Print(nil) })
+}
@@ -0,0 +1,89 @@
+package after
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/internal/skips"
+	"github.com/goreleaser/goreleaser/internal/testctx"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescription(t *testing.T) {
+	require.NotEmpty(t, Pipe{}.String())
+}
+
+func TestRunWithEnv(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "testfile")
+	require.NoError(t, Pipe{}.Run(testctx.NewWithCfg(
+		config.Project{
+			Env: []string{
+				"TEST_FILE=" + f,
+			},
+			After: config.After{
+				Hooks: []string{"touch {{ .Env.TEST_FILE }}"},
+			},
+		},
+	)))
+	require.FileExists(t, f)
+}
+
+func TestRunPipeFail(t *testing.T) {
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			After: config.After{
+				Hooks: []string{`sh -c "echo something went wrong >&2; exit 1"`},
+			},
+		},
+	)
+	err := Pipe{}.Run(ctx)
+	require.ErrorContains(t, err, "hook failed")
+	require.ErrorContains(t, err, "something went wrong")
+}
+
+func TestRunPipeInvalidCommand(t *testing.T) {
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			After: config.After{
+				Hooks: []string{`bash -c "echo \"unterminated command\"`},
+			},
+		},
+	)
+	require.EqualError(t, Pipe{}.Run(ctx), "invalid command line string")
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	testlib.RequireTemplateError(t, Pipe{}.Run(testctx.NewWithCfg(
+		config.Project{
+			After: config.After{
+				Hooks: []string{"touch {{ .fasdsd }"},
+			},
+		},
+	)))
+}
+
+func TestSkip(t *testing.T) {
+	t.Run("skip", func(t *testing.T) {
+		require.True(t, Pipe{}.Skip(testctx.New()))
+	})
+
+	t.Run("skip after", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			After: config.After{
+				Hooks: []string{""},
+			},
+		}, testctx.Skip(skips.After))
+		require.True(t, Pipe{}.Skip(ctx))
+	})
+
+	t.Run("dont skip", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			After: config.After{
+				Hooks: []string{""},
+			},
+		})
+		require.False(t, Pipe{}.Skip(ctx))
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/goreleaser/goreleaser/internal/middleware/errhandler"
 	"github.com/goreleaser/goreleaser/internal/middleware/logging"
 	"github.com/goreleaser/goreleaser/internal/middleware/skip"
+	"github.com/goreleaser/goreleaser/internal/middleware/summary"
 	"github.com/goreleaser/goreleaser/internal/pipe/bluesky"
 	"github.com/goreleaser/goreleaser/internal/pipe/discord"
 	"github.com/goreleaser/goreleaser/internal/pipe/linkedin"
@@ -65,10 +66,10 @@ func (Pipe) Skip(ctx *context.Context) (bool, error) {
 func (Pipe) Run(ctx *context.Context) error {
 	memo := errhandler.Memo{}
 	for _, announcer := range announcers {
-		_ = skip.Maybe(
+		_ = summary.Wrap(announcer.String(), skip.Maybe(
 			announcer,
-			logging.PadLog(announcer.String(), memo.Wrap(announcer.Announce)),
-		)(ctx)
+			logging.PadLog(announcer.String(), memo.Wrap(summary.Track(announcer.Announce))),
+		))(ctx)
 	}
 	if memo.Error() != nil {
 		return fmt.Errorf("failed to announce release: %w", memo.Error())
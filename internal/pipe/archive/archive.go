@@ -9,6 +9,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
@@ -66,7 +67,7 @@ func (Pipe) Default(ctx *context.Context) error {
 		if archive.RLCP != "" && archive.Format != "binary" && len(archive.Files) > 0 {
 			deprecate.Notice(ctx, "archives.rlcp")
 		}
-		if len(archive.Files) == 0 {
+		if archive.Files == nil {
 			archive.Files = []config.File{
 				{Source: "license*", Default: true},
 				{Source: "LICENSE*", Default: true},
@@ -198,25 +199,38 @@ func create(ctx *context.Context, arch config.Archive, binaries []*artifact.Arti
 	if arch.Meta && len(files) == 0 {
 		return fmt.Errorf("no files found")
 	}
-	for _, f := range files {
-		if err = a.Add(f); err != nil {
-			return fmt.Errorf("failed to add: '%s' -> '%s': %w", f.Source, f.Destination, err)
-		}
-	}
 	bins := []string{}
+	builds := []string{}
+	var binaryFiles []config.File
 	for _, binary := range binaries {
 		dst := binary.Name
 		if arch.StripBinaryDirectory {
 			dst = filepath.Base(dst)
 		}
-		if err := a.Add(config.File{
+		binaryFiles = append(binaryFiles, config.File{
 			Source:      binary.Path,
 			Destination: dst,
 			Info:        arch.BuildsInfo,
-		}); err != nil {
-			return fmt.Errorf("failed to add: '%s' -> '%s': %w", binary.Path, dst, err)
-		}
+		})
 		bins = append(bins, binary.Name)
+		if id := binary.ID(); id != "" && !slices.Contains(builds, id) {
+			builds = append(builds, id)
+		}
+	}
+	// the entries are always added in the same order, the files first and
+	// then the binaries, each sorted, and with the release date as their mod
+	// time, so the archives are byte-for-byte identical across runs.
+	slices.SortStableFunc(binaryFiles, func(a, b config.File) int {
+		return strings.Compare(a.Destination, b.Destination)
+	})
+	files = append(files, binaryFiles...)
+	for _, f := range files {
+		if f.Info.ParsedMTime.IsZero() {
+			f.Info.ParsedMTime = ctx.Date
+		}
+		if err = a.Add(f); err != nil {
+			return fmt.Errorf("failed to add: '%s' -> '%s': %w", f.Source, f.Destination, err)
+		}
 	}
 	art := &artifact.Artifact{
 		Type: artifact.UploadableArchive,
@@ -227,6 +241,7 @@ func create(ctx *context.Context, arch config.Archive, binaries []*artifact.Arti
 			artifact.ExtraFormat:    format,
 			artifact.ExtraWrappedIn: wrap,
 			artifact.ExtraBinaries:  bins,
+			artifact.ExtraBuilds:    builds,
 		},
 	}
 	if len(binaries) > 0 {
@@ -276,6 +291,7 @@ func skip(ctx *context.Context, archive config.Archive, binaries []*artifact.Art
 				artifact.ExtraID:       archive.ID,
 				artifact.ExtraFormat:   archive.Format,
 				artifact.ExtraBinary:   binary.Name,
+				artifact.ExtraBuilds:   []string{binary.ID()},
 				artifact.ExtraReplaces: binaries[0].Extra[artifact.ExtraReplaces],
 			},
 		})
@@ -2,14 +2,18 @@ package archive
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/goreleaser/goreleaser/internal/archivefiles"
 	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/testctx"
 	"github.com/goreleaser/goreleaser/internal/testlib"
@@ -484,6 +488,12 @@ func TestRunPipeBinary(t *testing.T) {
 	})
 
 	require.NoError(t, Pipe{}.Run(ctx))
+	require.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableArchive)).List())
+	for _, ext := range []string{"*.tar.gz", "*.zip"} {
+		archives, err := filepath.Glob(filepath.Join(dist, ext))
+		require.NoError(t, err)
+		require.Empty(t, archives)
+	}
 	binaries := ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableBinary))
 	require.Len(t, binaries.List(), 4)
 	darwinThin := binaries.Filter(artifact.And(
@@ -507,6 +517,124 @@ func TestRunPipeBinary(t *testing.T) {
 	require.Equal(t, "myotherbin.exe", artifact.ExtraOr(*windows2, artifact.ExtraBinary, ""))
 }
 
+func TestRunPipeReproducible(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	for _, name := range []string{"README.md", "LICENSE", "mybin", "myotherbin"} {
+		require.NoError(t, os.WriteFile(filepath.Join(folder, name), []byte(name), 0o755))
+	}
+
+	date := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	build := func(t *testing.T, format string, binaries []string, mtime time.Time) []byte {
+		t.Helper()
+		for _, name := range []string{"README.md", "LICENSE", "mybin", "myotherbin"} {
+			require.NoError(t, os.Chtimes(filepath.Join(folder, name), mtime, mtime))
+		}
+		dist := t.TempDir()
+		ctx := testctx.NewWithCfg(
+			config.Project{
+				Dist: dist,
+				Archives: []config.Archive{{
+					Builds:       []string{"default"},
+					NameTemplate: "foo",
+					Format:       format,
+					Files: []config.File{
+						{Source: "README.md"},
+						{Source: "LICENSE"},
+					},
+				}},
+			},
+			testctx.WithVersion("1.0.0"),
+			testctx.WithDate(date),
+		)
+		for _, name := range binaries {
+			ctx.Artifacts.Add(&artifact.Artifact{
+				Goos:   "linux",
+				Goarch: "amd64",
+				Name:   name,
+				Path:   filepath.Join(folder, name),
+				Type:   artifact.Binary,
+				Extra: map[string]interface{}{
+					artifact.ExtraID: "default",
+				},
+			})
+		}
+		require.NoError(t, Pipe{}.Run(ctx))
+		bts, err := os.ReadFile(filepath.Join(dist, "foo."+format))
+		require.NoError(t, err)
+		return bts
+	}
+
+	for _, format := range []string{"tar", "tar.gz", "tar.xz", "tar.zst", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			first := build(t, format, []string{"mybin", "myotherbin"}, time.Now())
+			second := build(t, format, []string{"myotherbin", "mybin"}, time.Now().Add(-time.Hour))
+			require.Equal(t, first, second)
+		})
+	}
+
+	t.Run("mod times", func(t *testing.T) {
+		tr := tar.NewReader(bytes.NewReader(build(t, "tar", []string{"mybin"}, time.Now())))
+		var names []string
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, header.Name)
+			require.True(t, date.Equal(header.ModTime), header.Name)
+		}
+		require.Equal(t, []string{"LICENSE", "README.md", "mybin"}, names)
+	})
+}
+
+func TestRunPipeConditionalFiles(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	dist := filepath.Join(folder, "dist")
+	require.NoError(t, os.Mkdir(dist, 0o755))
+	for _, name := range []string{"README.md", "launcher.bat", "foo.1", "foo", "foo.exe"} {
+		require.NoError(t, os.WriteFile(filepath.Join(folder, name), []byte(name), 0o644))
+	}
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist: dist,
+			Archives: []config.Archive{{
+				Builds:       []string{"default"},
+				NameTemplate: "foo_{{ .Os }}",
+				Format:       "tar.gz",
+				Files: []config.File{
+					{Source: "README.md"},
+					{Source: "launcher.bat", If: `{{ eq .Os "windows" }}`},
+					{Source: "foo.1", Destination: "man/foo.1", If: `{{ ne .Os "windows" }}`},
+				},
+			}},
+		},
+		testctx.WithVersion("1.0.0"),
+	)
+	for goos, name := range map[string]string{"linux": "foo", "windows": "foo.exe"} {
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Goos:   goos,
+			Goarch: "amd64",
+			Name:   name,
+			Path:   filepath.Join(folder, name),
+			Type:   artifact.Binary,
+			Extra: map[string]interface{}{
+				artifact.ExtraID: "default",
+			},
+		})
+	}
+
+	require.NoError(t, Pipe{}.Run(ctx))
+	require.Equal(t,
+		[]string{"README.md", "man/foo.1", "foo"},
+		testlib.LsArchive(t, filepath.Join(dist, "foo_linux.tar.gz"), "tar.gz"),
+	)
+	require.Equal(t,
+		[]string{"README.md", "launcher.bat", "foo.exe"},
+		testlib.LsArchive(t, filepath.Join(dist, "foo_windows.tar.gz"), "tar.gz"),
+	)
+}
+
 func TestRunPipeDistRemoved(t *testing.T) {
 	ctx := testctx.NewWithCfg(
 		config.Project{
@@ -822,6 +950,54 @@ func TestDefaultNoFiles(t *testing.T) {
 	})
 	require.NoError(t, Pipe{}.Default(ctx))
 	require.Equal(t, defaultNameTemplate, ctx.Config.Archives[0].NameTemplate)
+	require.NotEmpty(t, ctx.Config.Archives[0].Files)
+}
+
+func TestDefaultEmptyFiles(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Archives: []config.Archive{
+			{
+				Format: "tar.gz",
+				Files:  []config.File{},
+			},
+		},
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.Empty(t, ctx.Config.Archives[0].Files)
+}
+
+func TestRunPipeFilesNoMatch(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	dist := filepath.Join(folder, "dist")
+	createFakeBinary(t, dist, "linuxamd64", "mybin")
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist:        dist,
+		ProjectName: "foo",
+		Archives: []config.Archive{
+			{
+				ID:     "foo",
+				Format: "tar.gz",
+				Files: []config.File{
+					{Source: "completions/*"},
+				},
+			},
+		},
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Goos:   "linux",
+		Goarch: "amd64",
+		Name:   "mybin",
+		Path:   filepath.Join(dist, "linuxamd64", "mybin"),
+		Type:   artifact.Binary,
+		Extra: map[string]interface{}{
+			artifact.ExtraBinary: "mybin",
+			artifact.ExtraID:     "default",
+		},
+	})
+	err := Pipe{}.Run(ctx)
+	require.ErrorIs(t, err, archivefiles.ErrNoMatch)
+	require.ErrorContains(t, err, "completions/*")
 }
 
 func TestDefaultFormatBinary(t *testing.T) {
@@ -961,11 +1137,7 @@ func TestRunPipeSameArchiveFilename(t *testing.T) {
 				{
 					Builds:       []string{"default"},
 					NameTemplate: "same-filename",
-					Files: []config.File{
-						{Source: "README.*"},
-						{Source: "./foo/**/*"},
-					},
-					Format: "tar.gz",
+					Format:       "tar.gz",
 				},
 			},
 		},
@@ -1028,6 +1200,55 @@ func TestDuplicateFilesInsideArchive(t *testing.T) {
 	}), fs.ErrExist)
 }
 
+func TestRunPipeWrapTemplates(t *testing.T) {
+	for wrap, want := range map[string]string{
+		"true": "foo_1.0.0_linux_arm64",
+		"{{ .ProjectName }}-{{ .Version }}-{{ .Os }}-{{ .Arch }}": "foo-1.0.0-linux-arm64",
+	} {
+		t.Run(wrap, func(t *testing.T) {
+			folder := testlib.Mktmp(t)
+			dist := filepath.Join(folder, "dist")
+			createFakeBinary(t, dist, "linuxarm64", "mybin")
+			require.NoError(t, os.WriteFile(filepath.Join(folder, "LICENSE"), []byte("MIT"), 0o644))
+			ctx := testctx.NewWithCfg(
+				config.Project{
+					Dist:        dist,
+					ProjectName: "foo",
+					Archives: []config.Archive{
+						{
+							WrapInDirectory: wrap,
+							Format:          "tar.gz",
+						},
+					},
+				},
+				testctx.WithVersion("1.0.0"),
+			)
+			require.NoError(t, Pipe{}.Default(ctx))
+			ctx.Artifacts.Add(&artifact.Artifact{
+				Goos:   "linux",
+				Goarch: "arm64",
+				Name:   "mybin",
+				Path:   filepath.Join(dist, "linuxarm64", "mybin"),
+				Type:   artifact.Binary,
+				Extra: map[string]interface{}{
+					artifact.ExtraBinary: "mybin",
+					artifact.ExtraID:     "default",
+				},
+			})
+			require.NoError(t, Pipe{}.Run(ctx))
+
+			archives := ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableArchive)).List()
+			require.Len(t, archives, 1)
+			require.Equal(t, want, artifact.ExtraOr(*archives[0], artifact.ExtraWrappedIn, ""))
+			require.ElementsMatch(
+				t,
+				[]string{want + "/LICENSE", want + "/mybin"},
+				testlib.LsArchive(t, archives[0].Path, "tar.gz"),
+			)
+		})
+	}
+}
+
 func TestWrapInDirectory(t *testing.T) {
 	t.Run("false", func(t *testing.T) {
 		require.Equal(t, "", wrapFolder(config.Archive{
@@ -1228,3 +1449,115 @@ func TestExtraFormatWhenOverride(t *testing.T) {
 	archives := ctx.Artifacts.Filter(artifact.ByFormats("zip")).List()
 	require.Len(t, archives, 1)
 }
+
+func TestFormatOverridesMatrix(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist:        t.TempDir(),
+		ProjectName: "foo",
+		Archives: []config.Archive{
+			{
+				ID:     "foo",
+				Format: "tar.gz",
+				FormatOverrides: []config.FormatOverride{{
+					Goos:   "windows",
+					Format: "zip",
+				}},
+				Files: []config.File{
+					{Source: "./testdata/a/a.txt"},
+				},
+			},
+		},
+	}, testctx.WithVersion("1.0.0"))
+	require.NoError(t, Pipe{}.Default(ctx))
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		for _, goarch := range []string{"amd64", "arm64"} {
+			ext := ""
+			if goos == "windows" {
+				ext = ".exe"
+			}
+			createFakeBinary(t, ctx.Config.Dist, goos+goarch, "mybin"+ext)
+			ctx.Artifacts.Add(&artifact.Artifact{
+				Goos:   goos,
+				Goarch: goarch,
+				Name:   "mybin" + ext,
+				Path:   filepath.Join(ctx.Config.Dist, goos+goarch, "mybin"+ext),
+				Type:   artifact.Binary,
+				Extra: map[string]interface{}{
+					artifact.ExtraBinary: "mybin",
+					artifact.ExtraExt:    ext,
+					artifact.ExtraID:     "default",
+				},
+			})
+		}
+	}
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	var names []string
+	for _, a := range ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableArchive)).List() {
+		names = append(names, a.Name)
+		want := "tar.gz"
+		if a.Goos == "windows" {
+			want = "zip"
+		}
+		require.Equal(t, want, artifact.ExtraOr(*a, artifact.ExtraFormat, ""), a.Name)
+		require.FileExists(t, a.Path)
+	}
+	require.ElementsMatch(t, []string{
+		"foo_1.0.0_linux_amd64.tar.gz",
+		"foo_1.0.0_linux_arm64.tar.gz",
+		"foo_1.0.0_darwin_amd64.tar.gz",
+		"foo_1.0.0_darwin_arm64.tar.gz",
+		"foo_1.0.0_windows_amd64.zip",
+		"foo_1.0.0_windows_arm64.zip",
+	}, names)
+}
+
+func TestRunPipeSeveralBuildsKeepBuildIDs(t *testing.T) {
+	for _, format := range []string{"tar.gz", "binary"} {
+		t.Run(format, func(t *testing.T) {
+			folder := testlib.Mktmp(t)
+			dist := filepath.Join(folder, "dist")
+			ctx := testctx.NewWithCfg(config.Project{
+				Dist:        dist,
+				ProjectName: "foo",
+				Archives: []config.Archive{
+					{
+						ID:     "foo",
+						Format: format,
+						Files:  []config.File{},
+					},
+				},
+			}, testctx.WithVersion("1.0.0"))
+			require.NoError(t, Pipe{}.Default(ctx))
+			for _, id := range []string{"server", "client"} {
+				createFakeBinary(t, dist, id+"_linux_amd64", id)
+				ctx.Artifacts.Add(&artifact.Artifact{
+					Goos:   "linux",
+					Goarch: "amd64",
+					Name:   id,
+					Path:   filepath.Join(dist, id+"_linux_amd64", id),
+					Type:   artifact.Binary,
+					Extra: map[string]interface{}{
+						artifact.ExtraBinary: id,
+						artifact.ExtraID:     id,
+					},
+				})
+			}
+			require.NoError(t, Pipe{}.Run(ctx))
+
+			var builds []string
+			for _, a := range ctx.Artifacts.Filter(artifact.Or(
+				artifact.ByType(artifact.UploadableArchive),
+				artifact.ByType(artifact.UploadableBinary),
+			)).List() {
+				ids := artifact.ExtraOr(*a, artifact.ExtraBuilds, []string{})
+				if format == "binary" {
+					require.Len(t, ids, 1)
+					require.Equal(t, ids[0], filepath.Base(a.Path))
+				}
+				builds = append(builds, ids...)
+			}
+			require.ElementsMatch(t, []string{"server", "client"}, builds)
+		})
+	}
+}
@@ -2,21 +2,38 @@
 package artifactory
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	h "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/caarlos0/log"
+	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/http"
 	"github.com/goreleaser/goreleaser/internal/pipe"
+	"github.com/goreleaser/goreleaser/internal/skips"
+	"github.com/goreleaser/goreleaser/internal/tmpl"
+	"github.com/goreleaser/goreleaser/pkg/config"
 	"github.com/goreleaser/goreleaser/pkg/context"
 )
 
 // Pipe for Artifactory.
 type Pipe struct{}
 
-func (Pipe) String() string                 { return "artifactory" }
-func (Pipe) Skip(ctx *context.Context) bool { return len(ctx.Config.Artifactories) == 0 }
+func (Pipe) String() string { return "artifactory" }
+func (Pipe) Skip(ctx *context.Context) bool {
+	return skips.Any(ctx, skips.Artifactory) || len(ctx.Config.Artifactories) == 0
+}
+
+// SupportsPlan tells the uploads can be planned with --print-plan.
+func (Pipe) SupportsPlan() bool { return true }
 
 // Default sets the pipe defaults.
 func (Pipe) Default(ctx *context.Context) error {
@@ -25,10 +42,48 @@ func (Pipe) Default(ctx *context.Context) error {
 			ctx.Config.Artifactories[i].ChecksumHeader = "X-Checksum-SHA256"
 		}
 		ctx.Config.Artifactories[i].Method = h.MethodPut
+		if bundle := &ctx.Config.Artifactories[i].ReleaseBundle; bundle.Name != "" && bundle.Version == "" {
+			bundle.Version = "{{ .Version }}"
+		}
+		if ctx.Config.Artifactories[i].VerifyChecksums == nil {
+			verify := true
+			ctx.Config.Artifactories[i].VerifyChecksums = &verify
+		}
+		ctx.Config.Artifactories[i].Properties = mergeProperties(
+			ctx.Config.ArtifactoryDefaults.Properties,
+			ctx.Config.Artifactories[i].Properties,
+		)
 	}
 	return http.Defaults(ctx.Config.Artifactories)
 }
 
+// Validate checks all the artifactory sections.
+func (Pipe) Validate(ctx *context.Context) error {
+	errs := []error{http.Validate(ctx, ctx.Config.Artifactories, "artifactory", "artifactories")}
+	for i, instance := range ctx.Config.Artifactories {
+		if instance.ReleaseBundle.Name != "" && instance.ReleaseBundle.URL == "" {
+			errs = append(errs, pipe.FieldErrorf(fmt.Sprintf("artifactories[%d].release_bundle.url", i), "required when release_bundle.name is set"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mergeProperties merges the default properties into the instance ones, the
+// instance values win on conflicts.
+func mergeProperties(defaults, instance map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return instance
+	}
+	result := make(map[string]string, len(defaults)+len(instance))
+	for k, v := range defaults {
+		result[k] = v
+	}
+	for k, v := range instance {
+		result[k] = v
+	}
+	return result
+}
+
 // Publish artifacts to artifactory.
 //
 // Docs: https://www.jfrog.com/confluence/display/RTF/Artifactory+REST+API#ArtifactoryRESTAPI-Example-DeployinganArtifact
@@ -38,11 +93,25 @@ func (Pipe) Publish(ctx *context.Context) error {
 	for _, instance := range ctx.Config.Artifactories {
 		instance := instance
 		if skip := http.CheckConfig(ctx, &instance, "artifactory"); skip != nil {
-			return pipe.Skip(skip.Error())
+			return pipe.SkipWith(pipe.CategoryOf(skip), skip.Error())
+		}
+		if instance.ReleaseBundle.Name != "" && instance.ReleaseBundle.URL == "" {
+			return pipe.SkipWithf(pipe.SkipNotConfigured, "artifactory section '%s' is not configured properly (missing release_bundle url)", instance.Name)
 		}
 	}
 
-	return http.Upload(ctx, ctx.Config.Artifactories, "artifactory", checkResponse)
+	uploads := &uploads{}
+	err := http.Upload(ctx, ctx.Config.Artifactories, "artifactory", uploads.check)
+	if werr := writeUploads(ctx, uploads, err); werr != nil {
+		return errors.Join(err, werr)
+	}
+	if err != nil && !pipe.IsSkip(err) {
+		return err
+	}
+	if err := createReleaseBundles(ctx, uploads); err != nil {
+		return err
+	}
+	return err
 }
 
 // An ErrorResponse reports one or more errors caused by an API request.
@@ -70,7 +139,6 @@ type Error struct {
 // body, or a JSON response body that maps to ErrorResponse. Any other
 // response body will be silently ignored.
 func checkResponse(r *h.Response) error {
-	defer r.Body.Close()
 	if c := r.StatusCode; 200 <= c && c <= 299 {
 		return nil
 	}
@@ -84,3 +152,283 @@ func checkResponse(r *h.Response) error {
 	}
 	return errorResponse
 }
+
+// deployResponse is the response of a successful artifact deploy.
+type deployResponse struct {
+	Repo              string    `json:"repo"`
+	Path              string    `json:"path"`
+	DownloadURI       string    `json:"downloadUri"`
+	Checksums         checksums `json:"checksums"`
+	OriginalChecksums checksums `json:"originalChecksums"`
+}
+
+type checksums struct {
+	SHA1   string `json:"sha1"`
+	MD5    string `json:"md5"`
+	SHA256 string `json:"sha256"`
+}
+
+// deployment is an artifact deployed to an instance.
+type deployment struct {
+	deployResponse
+	Artifact string
+	artifact *artifact.Artifact
+}
+
+// uploads keeps track of the artifacts deployed to each instance.
+type uploads struct {
+	lock     sync.Mutex
+	deployed map[string][]deployment
+}
+
+func (u *uploads) check(r *h.Response, upload *config.Upload, a *artifact.Artifact) error {
+	if err := checkResponse(r); err != nil {
+		return err
+	}
+	if a == nil {
+		return nil
+	}
+	deploy, ok := decodeDeployResponse(r.Body, a)
+	// on checksum deploys, artifactory already matched the checksums we sent.
+	if ok && upload.VerifyChecksums != nil && *upload.VerifyChecksums && !isChecksumDeploy(r) {
+		if err := verifyChecksums(deploy.Checksums, a); err != nil {
+			return err
+		}
+		if deploy.OriginalChecksums != (checksums{}) {
+			if err := verifyChecksums(deploy.OriginalChecksums, a); err != nil {
+				return fmt.Errorf("original checksums: %w", err)
+			}
+		}
+	}
+	if deploy.DownloadURI == "" {
+		// the request URL is the final one, even if the upload was
+		// redirected.
+		deploy.DownloadURI = http.DeployedURL(r.Request.URL.String())
+	}
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.deployed == nil {
+		u.deployed = map[string][]deployment{}
+	}
+	u.deployed[upload.Name] = append(u.deployed[upload.Name], deployment{
+		deployResponse: deploy,
+		Artifact:       a.Name,
+		artifact:       a,
+	})
+	return nil
+}
+
+// decodeDeployResponse decodes the response of a successful deploy, telling
+// whether it could: only checksums artifactory returned can be verified.
+func decodeDeployResponse(r io.Reader, a *artifact.Artifact) (deployResponse, bool) {
+	var deploy deployResponse
+	body := bufio.NewReader(r)
+	if _, err := body.Peek(1); errors.Is(err, io.EOF) {
+		// checksum deploy hits and some proxies don't return the deployed
+		// artifact.
+		log.WithField("artifact", a.Name).Debug("empty artifactory response, nothing to verify")
+		return deploy, false
+	}
+	if err := json.NewDecoder(body).Decode(&deploy); err != nil {
+		log.WithError(err).
+			WithField("artifact", a.Name).
+			Warn("could not parse artifactory response, checksums not verified")
+		return deployResponse{}, false
+	}
+	return deploy, true
+}
+
+// isChecksumDeploy tells whether r answers a checksum deploy request.
+func isChecksumDeploy(r *h.Response) bool {
+	return r.Request != nil && r.Request.Header.Get("X-Checksum-Deploy") == "true"
+}
+
+// verifyChecksums compares the checksums returned by artifactory with the
+// local ones, using whichever algorithms the server returned.
+func verifyChecksums(remote checksums, a *artifact.Artifact) error {
+	var verified int
+	for _, sum := range []struct {
+		algorithm string
+		value     string
+	}{
+		{"sha256", remote.SHA256},
+		{"sha1", remote.SHA1},
+		{"md5", remote.MD5},
+	} {
+		if sum.value == "" {
+			continue
+		}
+		local, err := a.Hash(sum.algorithm)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(local, sum.value) {
+			return fmt.Errorf("%s: %s checksum mismatch: expected %s, got %s", a.Name, sum.algorithm, local, sum.value)
+		}
+		verified++
+	}
+	if verified == 0 {
+		log.WithField("artifact", a.Name).Warn("artifactory returned no checksums, skipping verification")
+	}
+	return nil
+}
+
+const uploadsName = "artifactory-uploads.json"
+
+// uploadsSummary is the content of the artifactory-uploads.json file.
+type uploadsSummary struct {
+	// Uploads maps each instance name to the download URI of every artifact
+	// deployed to it.
+	Uploads map[string]map[string]string `json:"uploads"`
+	// Errors are the upload failures, if any.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// writeUploads writes the download URIs of the deployed artifacts, along
+// with the upload failures, to the dist folder, and adds it as a metadata
+// artifact.
+// Nothing is written if nothing was uploaded, e.g. all instances were
+// skipped.
+func writeUploads(ctx *context.Context, uploads *uploads, uploadErr error) error {
+	if len(uploads.deployed) == 0 && (uploadErr == nil || pipe.IsSkip(uploadErr)) {
+		return nil
+	}
+	summary := uploadsSummary{Uploads: map[string]map[string]string{}}
+	for instance, deployed := range uploads.deployed {
+		uris := map[string]string{}
+		for _, d := range deployed {
+			uris[d.Artifact] = d.DownloadURI
+		}
+		summary.Uploads[instance] = uris
+	}
+	if uploadErr != nil && !pipe.IsSkip(uploadErr) {
+		summary.Errors = errorMessages(uploadErr)
+	}
+	bts, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(ctx.Config.Dist, uploadsName)
+	log.WithField("file", path).Info("writing")
+	if err := os.WriteFile(path, bts, 0o644); err != nil {
+		return fmt.Errorf("artifactory: failed to write %s: %w", uploadsName, err)
+	}
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name: uploadsName,
+		Path: path,
+		Type: artifact.Metadata,
+	})
+	return nil
+}
+
+// errorMessages returns the messages of the given error, one per joined
+// error.
+func errorMessages(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+	var messages []string
+	for _, err := range joined.Unwrap() {
+		messages = append(messages, errorMessages(err)...)
+	}
+	return messages
+}
+
+type releaseBundle struct {
+	Name       string              `json:"release_bundle_name"`
+	Version    string              `json:"release_bundle_version"`
+	SourceType string              `json:"source_type"`
+	Source     releaseBundleSource `json:"source"`
+}
+
+type releaseBundleSource struct {
+	Artifacts []releaseBundleArtifact `json:"artifacts"`
+}
+
+type releaseBundleArtifact struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// bundleArtifact returns the release bundle entry of the deployed artifact.
+// When artifactory didn't return where it deployed it, e.g. because the
+// response was empty, its path is taken from its download URI, after the
+// artifactory context path, and its sha256 is the local one.
+func bundleArtifact(d deployment) (releaseBundleArtifact, error) {
+	result := releaseBundleArtifact{
+		Path:   d.Repo + "/" + strings.TrimPrefix(d.Path, "/"),
+		SHA256: d.Checksums.SHA256,
+	}
+	if d.Repo == "" {
+		uri, err := url.Parse(d.DownloadURI)
+		if err != nil {
+			return result, err
+		}
+		result.Path = strings.TrimPrefix(strings.TrimPrefix(uri.Path, "/"), "artifactory/")
+	}
+	if result.SHA256 == "" {
+		sum, err := d.artifact.Hash("sha256")
+		if err != nil {
+			return result, err
+		}
+		result.SHA256 = sum
+	}
+	return result, nil
+}
+
+// createReleaseBundles creates a release bundle with the deployed artifacts
+// for every instance that has one configured.
+//
+// Docs: https://jfrog.com/help/r/jfrog-rest-apis/create-release-bundle-v2-version
+func createReleaseBundles(ctx *context.Context, uploads *uploads) error {
+	for _, instance := range ctx.Config.Artifactories {
+		instance := instance
+		if instance.ReleaseBundle.Name == "" || instance.DryRun {
+			continue
+		}
+		if disabled, err := tmpl.New(ctx).Bool(instance.Disable); err != nil || disabled {
+			continue
+		}
+		deployed := uploads.deployed[instance.Name]
+		if len(deployed) == 0 {
+			log.WithField("instance", instance.Name).Warn("no artifacts deployed, skipping release bundle")
+			continue
+		}
+
+		bundle := releaseBundle{
+			Name:       instance.ReleaseBundle.Name,
+			Version:    instance.ReleaseBundle.Version,
+			SourceType: "artifacts",
+		}
+		target := instance.ReleaseBundle.URL
+		if err := tmpl.New(ctx).ApplyAll(&bundle.Name, &bundle.Version, &target); err != nil {
+			return fmt.Errorf("artifactory: %s: failed to template release bundle: %w", instance.Name, err)
+		}
+		for _, d := range deployed {
+			artifact, err := bundleArtifact(d)
+			if err != nil {
+				return fmt.Errorf("artifactory: %s: failed to add %s to the release bundle: %w", instance.Name, d.Artifact, err)
+			}
+			bundle.Source.Artifacts = append(bundle.Source.Artifacts, artifact)
+		}
+		body, err := json.Marshal(bundle)
+		if err != nil {
+			return err
+		}
+
+		headers := map[string]string{"Content-Type": "application/json"}
+		if instance.ReleaseBundle.SigningKey != "" {
+			headers["X-JFrog-Signing-Key-Name"] = instance.ReleaseBundle.SigningKey
+		}
+		if err := http.Send(ctx, &instance, "artifactory", h.MethodPost, target, headers, body, checkResponse); err != nil {
+			return fmt.Errorf("artifactory: %s: failed to create release bundle: %w", instance.Name, err)
+		}
+		log.WithField("instance", instance.Name).
+			WithField("bundle", bundle.Name).
+			WithField("version", bundle.Version).
+			WithField("artifacts", len(bundle.Source.Artifacts)).
+			Info("created release bundle")
+	}
+	return nil
+}
@@ -1,13 +1,25 @@
 package blob
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/testctx"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/goreleaser/goreleaser/pkg/context"
 	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/memblob"
 )
 
 func TestDescription(t *testing.T) {
@@ -192,6 +204,16 @@ func TestURL(t *testing.T) {
 		require.Equal(t, "gs://foo", url)
 	})
 
+	t.Run("missing env", func(t *testing.T) {
+		url, err := urlFor(testctx.New(), config.Blob{
+			Bucket:   "foobar",
+			Region:   "{{.Env.NOPE}}",
+			Provider: "s3",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "s3://foobar", url)
+	})
+
 	t.Run("template errors", func(t *testing.T) {
 		t.Run("provider", func(t *testing.T) {
 			_, err := urlFor(testctx.New(), config.Blob{
@@ -209,7 +231,7 @@ func TestURL(t *testing.T) {
 		t.Run("endpoint", func(t *testing.T) {
 			_, err := urlFor(testctx.New(), config.Blob{
 				Bucket:   "foobar",
-				Endpoint: "{{.Env.NOPE}}",
+				Endpoint: "{{.Nope}}",
 				Provider: "s3",
 			})
 			testlib.RequireTemplateError(t, err)
@@ -217,7 +239,7 @@ func TestURL(t *testing.T) {
 		t.Run("region", func(t *testing.T) {
 			_, err := urlFor(testctx.New(), config.Blob{
 				Bucket:   "foobar",
-				Region:   "{{.Env.NOPE}}",
+				Region:   "{{.Nope}}",
 				Provider: "s3",
 			})
 			testlib.RequireTemplateError(t, err)
@@ -237,3 +259,135 @@ func TestSkip(t *testing.T) {
 		require.False(t, Pipe{}.Skip(ctx))
 	})
 }
+
+func TestUploadS3MockEndpoint(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "nope"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "nope"))
+
+	var lock sync.Mutex
+	uploads := map[string]string{}
+	acls := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		bts, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		uploads[r.URL.Path] = string(bts)
+		acls[r.URL.Path] = r.Header.Get("X-Amz-Acl")
+		w.Header().Set("ETag", `"fake"`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist:        folder,
+		ProjectName: "testupload",
+		Blobs: []config.Blob{
+			{
+				Provider:  "s3",
+				Bucket:    "releases",
+				Region:    "us-east-1",
+				Endpoint:  srv.URL,
+				Directory: "{{ .ProjectName }}/{{ .Version }}",
+				ACL:       "public-read",
+			},
+		},
+	}, testctx.WithVersion("1.0.0"), testctx.WithCurrentTag("v1.0.0"))
+	ctx.Parallelism = 2
+	for name, typ := range map[string]artifact.Type{
+		"bin_linux_amd64.tar.gz": artifact.UploadableArchive,
+		"bin_darwin_arm64.zip":   artifact.UploadableArchive,
+		"checksums.txt":          artifact.Checksum,
+		"bin":                    artifact.Binary,
+	} {
+		path := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(path, []byte("fake "+name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: path, Type: typ})
+	}
+
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Publish(ctx))
+
+	require.Equal(t, map[string]string{
+		"/releases/testupload/1.0.0/bin_linux_amd64.tar.gz": "fake bin_linux_amd64.tar.gz",
+		"/releases/testupload/1.0.0/bin_darwin_arm64.zip":   "fake bin_darwin_arm64.zip",
+		"/releases/testupload/1.0.0/checksums.txt":          "fake checksums.txt",
+	}, uploads)
+	for key, acl := range acls {
+		require.Equal(t, "public-read", acl, key)
+	}
+}
+
+func TestUploadGoCloudProviders(t *testing.T) {
+	newCtx := func(t *testing.T, provider, bucket string) *context.Context {
+		t.Helper()
+		folder := t.TempDir()
+		ctx := testctx.NewWithCfg(config.Project{
+			Dist:        folder,
+			ProjectName: "testupload",
+			Blobs: []config.Blob{
+				{
+					Provider:  provider,
+					Bucket:    bucket,
+					Directory: "{{ .ProjectName }}/{{ .Version }}",
+				},
+			},
+		}, testctx.WithVersion("1.0.0"), testctx.WithCurrentTag("v1.0.0"))
+		for name, typ := range map[string]artifact.Type{
+			"bin.tar.gz":    artifact.UploadableArchive,
+			"checksums.txt": artifact.Checksum,
+		} {
+			path := filepath.Join(folder, name)
+			require.NoError(t, os.WriteFile(path, []byte("fake "+name), 0o644))
+			ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: path, Type: typ})
+		}
+		require.NoError(t, Pipe{}.Default(ctx))
+		return ctx
+	}
+
+	t.Run("mem", func(t *testing.T) {
+		ctx := newCtx(t, "mem", "releases")
+		url, err := urlFor(ctx, ctx.Config.Blobs[0])
+		require.NoError(t, err)
+		require.Equal(t, "mem://releases", url)
+		require.NoError(t, Pipe{}.Publish(ctx))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := newCtx(t, "file", dir)
+		require.NoError(t, Pipe{}.Publish(ctx))
+
+		bucket, err := blob.OpenBucket(ctx, "file://"+filepath.ToSlash(dir))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, bucket.Close()) })
+		var keys []string
+		iter := bucket.List(nil)
+		for {
+			obj, err := iter.Next(ctx)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			keys = append(keys, obj.Key)
+		}
+		require.ElementsMatch(t, []string{
+			"testupload/1.0.0/bin.tar.gz",
+			"testupload/1.0.0/checksums.txt",
+		}, keys)
+		bts, err := bucket.ReadAll(ctx, "testupload/1.0.0/checksums.txt")
+		require.NoError(t, err)
+		require.Equal(t, "fake checksums.txt", string(bts))
+	})
+}
@@ -1,9 +1,11 @@
 package brew
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/goreleaser/goreleaser/internal/artifact"
@@ -1487,3 +1489,78 @@ func TestRunPipeUniversalBinaryNotReplacing(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, client.Content, string(distBts))
 }
+
+func TestRunPipeURLsAndChecksums(t *testing.T) {
+	for name, tt := range map[string]struct {
+		urlTemplate string
+		expectedURL string
+	}{
+		"release url": {
+			expectedURL: "https://dummyhost/download/v1.2.3/",
+		},
+		"custom url": {
+			urlTemplate: "https://example.com/{{ .ProjectName }}/{{ .Version }}/{{ .ArtifactName }}",
+			expectedURL: "https://example.com/foo/1.2.3/",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			folder := t.TempDir()
+			ctx := testctx.NewWithCfg(
+				config.Project{
+					Dist:        folder,
+					ProjectName: "foo",
+					Brews: []config.Homebrew{
+						{
+							Name:        "foo",
+							Goamd64:     "v1",
+							URLTemplate: tt.urlTemplate,
+							Repository: config.RepoRef{
+								Owner: "foo",
+								Name:  "homebrew-tap",
+							},
+						},
+					},
+				},
+				testctx.WithVersion("1.2.3"),
+				testctx.WithCurrentTag("v1.2.3"),
+			)
+			sums := map[string]string{}
+			for _, target := range []struct{ goos, goarch string }{
+				{"darwin", "amd64"},
+				{"linux", "arm64"},
+			} {
+				name := fmt.Sprintf("foo_%s_%s.tar.gz", target.goos, target.goarch)
+				path := filepath.Join(folder, name)
+				require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+				sums[name] = fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+				ctx.Artifacts.Add(&artifact.Artifact{
+					Name:    name,
+					Path:    path,
+					Goos:    target.goos,
+					Goarch:  target.goarch,
+					Goamd64: "v1",
+					Type:    artifact.UploadableArchive,
+					Extra: map[string]interface{}{
+						artifact.ExtraID:       "foo",
+						artifact.ExtraFormat:   "tar.gz",
+						artifact.ExtraBinaries: []string{"foo"},
+					},
+				})
+			}
+
+			require.NoError(t, Pipe{}.Default(ctx))
+			require.NoError(t, runAll(ctx, client.NewMock()))
+
+			bts, err := os.ReadFile(filepath.Join(folder, "homebrew", "foo.rb"))
+			require.NoError(t, err)
+			formula := string(bts)
+			for name, sum := range sums {
+				url := fmt.Sprintf("url %q", tt.expectedURL+name)
+				sha := fmt.Sprintf("sha256 %q", sum)
+				require.Contains(t, formula, url)
+				require.Contains(t, formula, sha)
+				require.Less(t, strings.Index(formula, url), strings.Index(formula, sha))
+			}
+		})
+	}
+}
@@ -3,16 +3,19 @@
 package build
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/caarlos0/go-shellwords"
 	"github.com/caarlos0/log"
 	"github.com/goreleaser/goreleaser/internal/deprecate"
 	"github.com/goreleaser/goreleaser/internal/ids"
+	"github.com/goreleaser/goreleaser/internal/pipe"
 	"github.com/goreleaser/goreleaser/internal/semerrgroup"
 	"github.com/goreleaser/goreleaser/internal/shell"
 	"github.com/goreleaser/goreleaser/internal/skips"
@@ -35,17 +38,60 @@ func (Pipe) String() string {
 // Run the pipe.
 func (Pipe) Run(ctx *context.Context) error {
 	g := semerrgroup.New(ctx.Parallelism)
+	paths := &binaryPaths{}
 	for _, build := range ctx.Config.Builds {
 		if build.Skip {
 			log.WithField("id", build.ID).Info("skip is set")
 			continue
 		}
 		log.WithField("build", build).Debug("building")
-		runPipeOnBuild(ctx, g, build)
+		runPipeOnBuild(ctx, g, paths, build)
 	}
 	return g.Wait()
 }
 
+// binaryPaths keeps track of the build producing each binary path, so two
+// builds don't overwrite each other's binaries.
+type binaryPaths struct {
+	lock  sync.Mutex
+	paths map[string]string
+}
+
+func (b *binaryPaths) claim(path, id string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.paths == nil {
+		b.paths = map[string]string{}
+	}
+	if other, ok := b.paths[path]; ok {
+		return fmt.Errorf("builds %q and %q would both write %s, use a different binary name or unset no_unique_dist_dir", other, id, path)
+	}
+	b.paths[path] = id
+	return nil
+}
+
+// Validate checks all the build sections.
+func (Pipe) Validate(ctx *context.Context) error {
+	var errs []error
+	for i, build := range ctx.Config.Builds {
+		if build.Skip {
+			continue
+		}
+		field := fmt.Sprintf("builds[%d]", i)
+		if build.Binary == "" {
+			errs = append(errs, pipe.FieldErrorf(field+".binary", "required"))
+		}
+		if build.Builder != "go" || build.NoMainCheck {
+			continue
+		}
+		main := filepath.Join(build.Dir, build.Main)
+		if _, err := os.Stat(main); err != nil {
+			errs = append(errs, pipe.FieldErrorf(field+".main", "couldn't find main file: %s", main))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Default sets the pipe defaults.
 func (Pipe) Default(ctx *context.Context) error {
 	if !reflect.DeepEqual(ctx.Config.SingleBuild, config.Build{}) {
@@ -87,7 +133,7 @@ func buildWithDefaults(ctx *context.Context, build config.Build) (config.Build,
 	return builders.For(build.Builder).WithDefaults(build)
 }
 
-func runPipeOnBuild(ctx *context.Context, g semerrgroup.Group, build config.Build) {
+func runPipeOnBuild(ctx *context.Context, g semerrgroup.Group, paths *binaryPaths, build config.Build) {
 	for _, target := range filter(ctx, build.Targets) {
 		target := target
 		build := build
@@ -96,6 +142,9 @@ func runPipeOnBuild(ctx *context.Context, g semerrgroup.Group, build config.Buil
 			if err != nil {
 				return err
 			}
+			if err := paths.claim(opts.Path, build.ID); err != nil {
+				return err
+			}
 
 			if !skips.Any(ctx, skips.PreBuildHooks) {
 				if err := runHook(ctx, *opts, build.Env, build.Hooks.Pre); err != nil {
@@ -528,7 +528,7 @@ func TestBuild_hooksKnowGoosGoarch(t *testing.T) {
 		},
 	})
 	g := semerrgroup.New(ctx.Parallelism)
-	runPipeOnBuild(ctx, g, build)
+	runPipeOnBuild(ctx, g, &binaryPaths{}, build)
 	require.NoError(t, g.Wait())
 	require.FileExists(t, filepath.Join(tmpDir, "pre-hook-amd64-linux"))
 	require.FileExists(t, filepath.Join(tmpDir, "post-hook-amd64-linux"))
@@ -560,7 +560,7 @@ func TestPipeOnBuild_hooksRunPerTarget(t *testing.T) {
 		},
 	})
 	g := semerrgroup.New(ctx.Parallelism)
-	runPipeOnBuild(ctx, g, build)
+	runPipeOnBuild(ctx, g, &binaryPaths{}, build)
 	require.NoError(t, g.Wait())
 	require.FileExists(t, filepath.Join(tmpDir, "pre-hook-linux_amd64"))
 	require.FileExists(t, filepath.Join(tmpDir, "pre-hook-darwin_amd64"))
@@ -584,7 +584,7 @@ func TestPipeOnBuild_invalidBinaryTpl(t *testing.T) {
 		},
 	})
 	g := semerrgroup.New(ctx.Parallelism)
-	runPipeOnBuild(ctx, g, build)
+	runPipeOnBuild(ctx, g, &binaryPaths{}, build)
 	testlib.RequireTemplateError(t, g.Wait())
 }
 
@@ -751,3 +751,100 @@ func TestRunHookFailWithLogs(t *testing.T) {
 	require.ErrorContains(t, err, "pre hook failed")
 	require.Empty(t, ctx.Artifacts.List())
 }
+
+func TestPipeOnBuild_postHookKnowsPath(t *testing.T) {
+	tmpDir := testlib.Mktmp(t)
+	build := config.Build{
+		ID:      "foo",
+		Builder: "fake",
+		Binary:  "foo",
+		Targets: []string{"linux_amd64"},
+		Hooks: config.BuildHookConfig{
+			Post: config.Hooks{
+				{Cmd: "sh -c 'echo -n {{ .Path }} > post-hook-path'", Dir: tmpDir},
+			},
+		},
+	}
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist:   "dist",
+		Builds: []config.Build{build},
+	})
+	g := semerrgroup.New(ctx.Parallelism)
+	runPipeOnBuild(ctx, g, &binaryPaths{}, build)
+	require.NoError(t, g.Wait())
+
+	bts, err := os.ReadFile(filepath.Join(tmpDir, "post-hook-path"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(tmpDir, "dist", "foo_linux_amd64", "foo"), string(bts))
+}
+
+func TestRunPostHookFailWithLogs(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist: folder,
+		Builds: []config.Build{
+			{
+				Builder: "fake",
+				Binary:  "testing",
+				Hooks: config.BuildHookConfig{
+					Post: []config.Hook{
+						{Cmd: "sh -c 'echo upx went boom >&2; exit 1'"},
+					},
+				},
+				Targets: []string{"linux_amd64"},
+			},
+		},
+	}, testctx.WithCurrentTag("2.4.5"))
+	err := Pipe{}.Run(ctx)
+	require.ErrorContains(t, err, "post hook failed")
+	require.ErrorContains(t, err, "upx went boom")
+}
+
+func TestRunPipeSeveralBuildsSameTarget(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist: folder,
+		Builds: []config.Build{
+			{
+				ID:      "server",
+				Builder: "fake",
+				Binary:  "app",
+				Targets: []string{"linux_amd64"},
+			},
+			{
+				ID:      "client",
+				Builder: "fake",
+				Binary:  "app",
+				Targets: []string{"linux_amd64"},
+			},
+		},
+	}, testctx.WithCurrentTag("2.4.5"))
+	require.NoError(t, Pipe{}.Run(ctx))
+	require.Len(t, ctx.Artifacts.List(), 2)
+	require.FileExists(t, filepath.Join(folder, "server_linux_amd64", "app"))
+	require.FileExists(t, filepath.Join(folder, "client_linux_amd64", "app"))
+}
+
+func TestRunPipeSeveralBuildsSamePath(t *testing.T) {
+	folder := testlib.Mktmp(t)
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist: folder,
+		Builds: []config.Build{
+			{
+				ID:              "server",
+				Builder:         "fake",
+				Binary:          "app",
+				Targets:         []string{"linux_amd64"},
+				NoUniqueDistDir: true,
+			},
+			{
+				ID:              "client",
+				Builder:         "fake",
+				Binary:          "app",
+				Targets:         []string{"linux_amd64"},
+				NoUniqueDistDir: true,
+			},
+		},
+	}, testctx.WithCurrentTag("2.4.5"))
+	require.ErrorContains(t, Pipe{}.Run(ctx), "would both write "+filepath.Join(folder, "app"))
+}
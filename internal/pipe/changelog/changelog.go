@@ -216,7 +216,7 @@ func formatChangelog(ctx *context.Context, entries []string) (string, error) {
 		}
 	}
 
-	sort.Slice(groups, groupSort(groups))
+	sort.SliceStable(groups, groupSort(groups))
 	for _, group := range groups {
 		if len(group.entries) > 0 {
 			result = append(result, group.title)
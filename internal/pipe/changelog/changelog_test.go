@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -823,6 +824,46 @@ func TestGroup(t *testing.T) {
 	require.Contains(t, ctx.ReleaseNotes, "### Others")
 }
 
+func TestFilterAndGroupMarkdown(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Changelog: config.Changelog{
+			Filters: config.Filters{
+				Exclude: []string{"^docs:", "^chore:"},
+			},
+			Groups: []config.ChangelogGroup{
+				{Title: "Features", Regexp: `^.*?feat(\([[:word:]]+\))??!?:.+$`, Order: 0},
+				{Title: "Bug Fixes", Regexp: `^.*?fix(\([[:word:]]+\))??!?:.+$`, Order: 1},
+				{Title: "Performance", Regexp: `^.*?perf(\([[:word:]]+\))??!?:.+$`, Order: 1},
+				{Title: "Others", Order: 999},
+			},
+		},
+	})
+	entries, err := filterEntries(ctx, []string{
+		"a1 feat: add the thing",
+		"b2 docs: document the thing",
+		"c3 fix(api): fix the thing",
+		"d4 chore: bump deps",
+		"e5 perf: faster thing",
+		"f6 refactor the thing",
+		"g7 feat(cli)!: break the thing",
+	})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		out, err := formatChangelog(ctx, slices.Clone(entries))
+		require.NoError(t, err)
+		require.Equal(t, `## Changelog
+### Features
+* a1 feat: add the thing
+* g7 feat(cli)!: break the thing
+### Bug Fixes
+* c3 fix(api): fix the thing
+### Performance
+* e5 perf: faster thing
+### Others
+* f6 refactor the thing`, out)
+	}
+}
+
 func TestGroupBadRegex(t *testing.T) {
 	folder := testlib.Mktmp(t)
 	testlib.GitInit(t)
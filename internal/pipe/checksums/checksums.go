@@ -24,8 +24,9 @@ const (
 )
 
 var (
-	errNoArtifacts = errors.New("there are no artifacts to sign")
-	lock           sync.Mutex
+	errNoArtifacts        = errors.New("there are no artifacts to sign")
+	errMultipleAlgorithms = errors.New("checksum: name_template must use {{ .Algorithm }} when using multiple algorithms")
+	lock                  sync.Mutex
 )
 
 // Pipe for checksums.
@@ -38,28 +39,70 @@ func (Pipe) Skip(ctx *context.Context) bool { return ctx.Config.Checksum.Disable
 func (Pipe) Default(ctx *context.Context) error {
 	cs := &ctx.Config.Checksum
 	if cs.Algorithm == "" {
-		cs.Algorithm = "sha256"
+		if len(cs.Algorithms) > 0 {
+			cs.Algorithm = cs.Algorithms[0]
+		} else {
+			cs.Algorithm = "sha256"
+		}
+	}
+	if len(cs.Algorithms) == 0 {
+		cs.Algorithms = []string{cs.Algorithm}
 	}
 	if cs.NameTemplate == "" {
 		if cs.Split {
 			cs.NameTemplate = "{{ .ArtifactName }}.{{ .Algorithm }}"
 		} else {
-			cs.NameTemplate = "{{ .ProjectName }}_{{ .Version }}_checksums.txt"
+			cs.NameTemplate = aggregateNameTemplate(ctx)
 		}
 	}
+	if len(cs.Algorithms) > 1 && !strings.Contains(cs.NameTemplate, ".Algorithm") {
+		return errMultipleAlgorithms
+	}
 	return nil
 }
 
 // Run the pipe.
 func (Pipe) Run(ctx *context.Context) error {
-	if ctx.Config.Checksum.Split {
-		return splitChecksum(ctx)
+	cs := ctx.Config.Checksum
+	for _, algorithm := range algorithms(ctx) {
+		if !cs.Split {
+			if err := singleChecksum(ctx, algorithm, cs.NameTemplate); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := splitChecksum(ctx, algorithm); err != nil {
+			return err
+		}
+		if cs.DisableAggregate {
+			continue
+		}
+		if err := singleChecksum(ctx, algorithm, aggregateNameTemplate(ctx)); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return singleChecksum(ctx)
+// aggregateNameTemplate returns the name template of the file holding the
+// checksums of all artifacts.
+func aggregateNameTemplate(ctx *context.Context) string {
+	if len(algorithms(ctx)) > 1 {
+		return "{{ .ProjectName }}_{{ .Version }}_checksums-{{ .Algorithm }}.txt"
+	}
+	return "{{ .ProjectName }}_{{ .Version }}_checksums.txt"
 }
 
-func splitChecksum(ctx *context.Context) error {
+// algorithms returns the algorithms to checksum the artifacts with,
+// falling back to the single algorithm if no list was given.
+func algorithms(ctx *context.Context) []string {
+	if len(ctx.Config.Checksum.Algorithms) > 0 {
+		return ctx.Config.Checksum.Algorithms
+	}
+	return []string{ctx.Config.Checksum.Algorithm}
+}
+
+func splitChecksum(ctx *context.Context, algorithm string) error {
 	artifactList, err := buildArtifactList(ctx)
 	if err != nil {
 		return err
@@ -69,14 +112,14 @@ func splitChecksum(ctx *context.Context) error {
 		filename, err := tmpl.New(ctx).
 			WithArtifact(art).
 			WithExtraFields(tmpl.Fields{
-				"Algorithm": ctx.Config.Checksum.Algorithm,
+				"Algorithm": algorithm,
 			}).
 			Apply(ctx.Config.Checksum.NameTemplate)
 		if err != nil {
 			return fmt.Errorf("checksum: name template: %w", err)
 		}
 		filepath := filepath.Join(ctx.Config.Dist, filename)
-		if err := refreshOne(ctx, *art, filepath); err != nil {
+		if err := refreshOne(algorithm, *art, filepath); err != nil {
 			return fmt.Errorf("checksum: %s: %w", art.Path, err)
 		}
 		ctx.Artifacts.Add(&artifact.Artifact{
@@ -87,7 +130,7 @@ func splitChecksum(ctx *context.Context) error {
 				artifact.ExtraChecksumOf: art.Path,
 				artifact.ExtraRefresh: func() error {
 					log.WithField("file", filename).Info("refreshing checksums")
-					return refreshOne(ctx, *art, filepath)
+					return refreshOne(algorithm, *art, filepath)
 				},
 			},
 		})
@@ -95,13 +138,17 @@ func splitChecksum(ctx *context.Context) error {
 	return nil
 }
 
-func singleChecksum(ctx *context.Context) error {
-	filename, err := tmpl.New(ctx).Apply(ctx.Config.Checksum.NameTemplate)
+func singleChecksum(ctx *context.Context, algorithm, nameTemplate string) error {
+	filename, err := tmpl.New(ctx).
+		WithExtraFields(tmpl.Fields{
+			"Algorithm": algorithm,
+		}).
+		Apply(nameTemplate)
 	if err != nil {
 		return err
 	}
 	filepath := filepath.Join(ctx.Config.Dist, filename)
-	if err := refreshAll(ctx, filepath); err != nil {
+	if err := refreshAll(ctx, algorithm, filepath); err != nil {
 		if errors.Is(err, errNoArtifacts) {
 			return nil
 		}
@@ -114,22 +161,22 @@ func singleChecksum(ctx *context.Context) error {
 		Extra: map[string]interface{}{
 			artifact.ExtraRefresh: func() error {
 				log.WithField("file", filename).Info("refreshing checksums")
-				return refreshAll(ctx, filepath)
+				return refreshAll(ctx, algorithm, filepath)
 			},
 		},
 	})
 	return nil
 }
 
-func refreshOne(ctx *context.Context, art artifact.Artifact, path string) error {
-	check, err := art.Checksum(ctx.Config.Checksum.Algorithm)
+func refreshOne(algorithm string, art artifact.Artifact, path string) error {
+	check, err := art.Checksum(algorithm)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, []byte(check), 0o644)
+	return os.WriteFile(path, []byte(check+"\n"), 0o644)
 }
 
-func refreshAll(ctx *context.Context, filepath string) error {
+func refreshAll(ctx *context.Context, algorithm, filepath string) error {
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -144,7 +191,7 @@ func refreshAll(ctx *context.Context, filepath string) error {
 		i := i
 		artifact := artifact
 		g.Go(func() error {
-			sumLine, err := checksums(ctx.Config.Checksum.Algorithm, artifact)
+			sumLine, err := checksums(algorithm, algorithm == ctx.Config.Checksum.Algorithm, artifact)
 			if err != nil {
 				return err
 			}
@@ -207,13 +254,24 @@ func buildArtifactList(ctx *context.Context) ([]*artifact.Artifact, error) {
 	return artifactList, nil
 }
 
-func checksums(algorithm string, a *artifact.Artifact) (string, error) {
-	log.WithField("file", a.Name).Debug("checksumming")
+// checksums returns the checksum line of the given artifact, only keeping
+// the checksum in its extras if the algorithm is the primary one.
+func checksums(algorithm string, primary bool, a *artifact.Artifact) (string, error) {
+	log.WithField("file", a.Name).WithField("algorithm", algorithm).Debug("checksumming")
+	previous, hadPrevious := a.Extra[artifactChecksumExtra]
 	sha, err := a.Checksum(algorithm)
 	if err != nil {
 		return "", err
 	}
 
+	if !primary {
+		if hadPrevious {
+			a.Extra[artifactChecksumExtra] = previous
+		} else {
+			delete(a.Extra, artifactChecksumExtra)
+		}
+		return fmt.Sprintf("%v  %v\n", sha, a.Name), nil
+	}
 	if a.Extra == nil {
 		a.Extra = make(artifact.Extras)
 	}
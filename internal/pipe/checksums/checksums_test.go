@@ -104,6 +104,62 @@ func TestPipe(t *testing.T) {
 	}
 }
 
+func TestPipeAlgorithms(t *testing.T) {
+	const binary = "binary"
+	const archive = binary + ".tar.gz"
+
+	folder := t.TempDir()
+	file := filepath.Join(folder, binary)
+	require.NoError(t, os.WriteFile(file, []byte("some string"), 0o644))
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist:        folder,
+			ProjectName: binary,
+			Checksum: config.Checksum{
+				Algorithms: []string{"sha512", "md5"},
+			},
+		},
+		testctx.WithVersion("1.2.3"),
+	)
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.Equal(t, "sha512", ctx.Config.Checksum.Algorithm)
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name: binary,
+		Path: file,
+		Type: artifact.UploadableBinary,
+	})
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name: archive,
+		Path: file,
+		Type: artifact.UploadableArchive,
+	})
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	for name, sum := range map[string]string{
+		"binary_1.2.3_checksums-sha512.txt": "14925e01a7a0cf0801aa95fe52d542b578af58ae7997ada66db3a6eae68a329d50600a5b7b442eabf4ea77ea8ef5fe40acf2ab31d47311b2a232c4f64009aac1  ",
+		"binary_1.2.3_checksums-md5.txt":    "5ac749fbeec93607fc28d666be85e73a  ",
+	} {
+		t.Run(name, func(t *testing.T) {
+			bts, err := os.ReadFile(filepath.Join(folder, name))
+			require.NoError(t, err)
+			require.Equal(t, sum+binary+"\n"+sum+archive+"\n", string(bts))
+		})
+	}
+
+	checksums := ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List()
+	require.Len(t, checksums, 2)
+	for _, a := range checksums {
+		require.NoError(t, a.Refresh())
+	}
+
+	bin := ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableBinary)).List()[0]
+	require.Equal(
+		t,
+		"sha512:14925e01a7a0cf0801aa95fe52d542b578af58ae7997ada66db3a6eae68a329d50600a5b7b442eabf4ea77ea8ef5fe40acf2ab31d47311b2a232c4f64009aac1",
+		bin.Extra[artifactChecksumExtra],
+	)
+}
+
 func TestPipeSplit(t *testing.T) {
 	const binary = "binary"
 	const archive = binary + ".tar.gz"
@@ -154,14 +210,61 @@ func TestPipeSplit(t *testing.T) {
 	}))
 
 	checks := ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List()
-	require.Len(t, checks, 3)
+	require.Len(t, checks, 4)
 
+	var names []string
 	for _, check := range checks {
-		require.NotEmpty(t, check.Extra[artifact.ExtraChecksumOf])
+		names = append(names, check.Name)
 		bts, err := os.ReadFile(check.Path)
 		require.NoError(t, err)
-		require.Equal(t, sum, string(bts))
+		if check.Extra[artifact.ExtraChecksumOf] == nil {
+			require.Equal(t, "foo__checksums.txt", check.Name)
+			require.Equal(t, strings.Join([]string{
+				sum + "  " + binary,
+				sum + "  " + linuxPackage,
+				sum + "  " + archive,
+			}, "\n")+"\n", string(bts))
+			continue
+		}
+		require.Equal(t, sum+"\n", string(bts))
 	}
+	require.ElementsMatch(t, []string{
+		"binary.sha256",
+		"binary.tar.gz.sha256",
+		"binary.rpm.sha256",
+		"foo__checksums.txt",
+	}, names)
+}
+
+func TestPipeSplitDisableAggregate(t *testing.T) {
+	const binary = "binary"
+
+	folder := t.TempDir()
+	file := filepath.Join(folder, binary)
+	require.NoError(t, os.WriteFile(file, []byte("some string"), 0o644))
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist:        folder,
+			ProjectName: "foo",
+			Checksum: config.Checksum{
+				Split:            true,
+				DisableAggregate: true,
+			},
+		},
+	)
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name: binary,
+		Path: file,
+		Type: artifact.UploadableBinary,
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	checks := ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List()
+	require.Len(t, checks, 1)
+	require.Equal(t, "binary.sha256", checks[0].Name)
+	require.Equal(t, file, checks[0].Extra[artifact.ExtraChecksumOf])
+	require.NoFileExists(t, filepath.Join(folder, "foo__checksums.txt"))
 }
 
 func TestRefreshModifying(t *testing.T) {
@@ -216,7 +319,7 @@ func TestRefreshModifyingSplit(t *testing.T) {
 	require.NoError(t, Pipe{}.Default(ctx))
 	require.NoError(t, Pipe{}.Run(ctx))
 	checks := ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List()
-	require.Len(t, checks, 1)
+	require.Len(t, checks, 2)
 	previous, err := os.ReadFile(checks[0].Path)
 	require.NoError(t, err)
 	require.NoError(t, os.WriteFile(file, []byte("some other string"), 0o644))
@@ -245,6 +348,30 @@ func TestPipeFileNotExist(t *testing.T) {
 	require.ErrorIs(t, Pipe{}.Run(ctx), os.ErrNotExist)
 }
 
+func TestPipeMissingEnvNameTemplate(t *testing.T) {
+	folder := t.TempDir()
+	file := filepath.Join(folder, "binary")
+	require.NoError(t, os.WriteFile(file, []byte("some string"), 0o644))
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist:        folder,
+			ProjectName: "name",
+			Checksum: config.Checksum{
+				NameTemplate: "{{ .ProjectName }}_{{ .Env.NOPE }}_checksums.txt",
+				Algorithm:    "sha256",
+			},
+		},
+		testctx.WithCurrentTag("1.2.3"),
+	)
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name: "binary",
+		Path: file,
+		Type: artifact.UploadableBinary,
+	})
+	require.NoError(t, Pipe{}.Run(ctx))
+	require.FileExists(t, filepath.Join(folder, "name__checksums.txt"))
+}
+
 func TestPipeInvalidNameTemplate(t *testing.T) {
 	binFile, err := os.CreateTemp(t.TempDir(), "goreleasertest-bin")
 	require.NoError(t, err)
@@ -254,7 +381,7 @@ func TestPipeInvalidNameTemplate(t *testing.T) {
 
 	for _, template := range []string{
 		"{{ .Pro }_checksums.txt",
-		"{{.Env.NOPE}}",
+		"{{.Nope}}",
 	} {
 		for _, split := range []bool{true, false} {
 			t.Run(fmt.Sprintf("split_%v_%s", split, template), func(t *testing.T) {
@@ -329,6 +456,7 @@ func TestDefault(t *testing.T) {
 		ctx.Config.Checksum.NameTemplate,
 	)
 	require.Equal(t, "sha256", ctx.Config.Checksum.Algorithm)
+	require.Equal(t, []string{"sha256"}, ctx.Config.Checksum.Algorithms)
 }
 
 func TestDefaultSPlit(t *testing.T) {
@@ -346,6 +474,31 @@ func TestDefaultSPlit(t *testing.T) {
 	require.Equal(t, "sha256", ctx.Config.Checksum.Algorithm)
 }
 
+func TestDefaultAlgorithms(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Checksum: config.Checksum{
+			Algorithms: []string{"sha512", "md5"},
+		},
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.Equal(
+		t,
+		"{{ .ProjectName }}_{{ .Version }}_checksums-{{ .Algorithm }}.txt",
+		ctx.Config.Checksum.NameTemplate,
+	)
+	require.Equal(t, "sha512", ctx.Config.Checksum.Algorithm)
+}
+
+func TestDefaultAlgorithmsWithoutAlgorithmInName(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Checksum: config.Checksum{
+			NameTemplate: "checksums.txt",
+			Algorithms:   []string{"sha512", "md5"},
+		},
+	})
+	require.ErrorIs(t, Pipe{}.Default(ctx), errMultipleAlgorithms)
+}
+
 func TestDefaultSet(t *testing.T) {
 	ctx := testctx.NewWithCfg(config.Project{
 		Checksum: config.Checksum{
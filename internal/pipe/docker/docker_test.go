@@ -780,7 +780,7 @@ func TestRunPipe(t *testing.T) {
 			dockers: []config.Docker{
 				{
 					ImageTemplates: []string{
-						registry + "goreleaser/test_run_pipe:{{.Env.NOPE}}",
+						registry + "goreleaser/test_run_pipe:{{.Nope}}",
 					},
 					Goos:       "linux",
 					Goarch:     "amd64",
@@ -800,7 +800,7 @@ func TestRunPipe(t *testing.T) {
 					Goarch:     "amd64",
 					Dockerfile: "testdata/Dockerfile",
 					BuildFlagTemplates: []string{
-						"--label=nope={{.Env.NOPE}}",
+						"--label=nope={{.Nope}}",
 					},
 				},
 			},
@@ -1334,6 +1334,17 @@ func Test_processImageTemplates(t *testing.T) {
 	}, images)
 }
 
+func Test_processBuildFlagTemplatesMissingEnv(t *testing.T) {
+	flags, err := processBuildFlagTemplates(testctx.New(), config.Docker{
+		BuildFlagTemplates: []string{
+			"--label=foo=bar",
+			"--label=nope={{.Env.NOPE}}",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"--label=foo=bar", "--label=nope="}, flags)
+}
+
 func TestSkip(t *testing.T) {
 	t.Run("image", func(t *testing.T) {
 		t.Run("skip", func(t *testing.T) {
@@ -1442,3 +1453,168 @@ func TestIsFileNotFoundError(t *testing.T) {
 		require.True(t, isFileNotFoundError(`./foo: not found: not found`))
 	})
 }
+
+// fakeDocker puts a docker stub first in the PATH and returns the file it
+// logs its invocations to.
+func fakeDocker(tb testing.TB) string {
+	tb.Helper()
+	bin := tb.TempDir()
+	log := filepath.Join(tb.TempDir(), "docker.log")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$*" >>%[1]q
+case "$1" in
+build) find . -type f | sort >>%[1]q ;;
+push) echo "latest: digest: sha256:%[2]s size: 528" ;;
+manifest) [ "$2" = push ] && echo "sha256:%[2]s" ;;
+esac
+exit 0
+`, log, strings.Repeat("a", 64))
+	require.NoError(tb, os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0o755))
+	tb.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestRunPipeWithFakeDocker(t *testing.T) {
+	log := fakeDocker(t)
+	dist := t.TempDir()
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			ProjectName: "mybin",
+			Dist:        dist,
+			Dockers: []config.Docker{
+				{
+					ID:         "mybin",
+					Goos:       "linux",
+					Goarch:     "amd64",
+					Dockerfile: "testdata/Dockerfile",
+					ImageTemplates: []string{
+						"ghcr.io/foo/mybin:{{.Tag}}-amd64",
+						"ghcr.io/foo/mybin:{{.Version}}-amd64",
+					},
+					BuildFlagTemplates: []string{"--build-arg=VERSION={{.Version}}"},
+					Files:              []string{"testdata/extra_file.txt"},
+				},
+			},
+		},
+		testctx.WithVersion("1.0.0"),
+		testctx.WithCurrentTag("v1.0.0"),
+	)
+	bin := filepath.Join(dist, "mybin")
+	require.NoError(t, os.WriteFile(bin, []byte("fake"), 0o755))
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name:    "mybin",
+		Path:    bin,
+		Goos:    "linux",
+		Goarch:  "amd64",
+		Goamd64: "v1",
+		Type:    artifact.Binary,
+		Extra: map[string]interface{}{
+			artifact.ExtraID: "mybin",
+		},
+	})
+
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	images := ctx.Artifacts.Filter(artifact.ByType(artifact.PublishableDockerImage)).List()
+	require.Len(t, images, 2)
+	bts, err := os.ReadFile(log)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		"build . -t ghcr.io/foo/mybin:v1.0.0-amd64 -t ghcr.io/foo/mybin:1.0.0-amd64 --build-arg=VERSION=1.0.0",
+		"./Dockerfile",
+		"./mybin",
+		"./testdata/extra_file.txt",
+		"",
+	}, "\n"), string(bts))
+
+	require.NoError(t, Pipe{}.Publish(ctx))
+	bts, err = os.ReadFile(log)
+	require.NoError(t, err)
+	require.Contains(t, string(bts), "push ghcr.io/foo/mybin:v1.0.0-amd64\n")
+	require.Contains(t, string(bts), "push ghcr.io/foo/mybin:1.0.0-amd64\n")
+
+	pushed := ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List()
+	var names []string
+	for _, img := range pushed {
+		names = append(names, img.Name)
+		require.Equal(t, "mybin", img.ID())
+		require.Equal(t, "sha256:"+strings.Repeat("a", 64), artifact.ExtraOr(*img, artifact.ExtraDigest, ""))
+	}
+	require.ElementsMatch(t, []string{"ghcr.io/foo/mybin:v1.0.0-amd64", "ghcr.io/foo/mybin:1.0.0-amd64"}, names)
+}
+
+func TestPublishSkipPushWithFakeDocker(t *testing.T) {
+	log := fakeDocker(t)
+	ctx := testctx.NewWithCfg(config.Project{
+		Dockers: []config.Docker{
+			{
+				Goos:           "linux",
+				Goarch:         "amd64",
+				Dockerfile:     "testdata/Dockerfile.dummy",
+				ImageTemplates: []string{"ghcr.io/foo/mybin:latest"},
+				SkipPush:       "true",
+			},
+		},
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+	testlib.AssertSkipped(t, Pipe{}.Publish(ctx))
+
+	bts, err := os.ReadFile(log)
+	require.NoError(t, err)
+	require.NotContains(t, string(bts), "push")
+	require.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List())
+}
+
+func TestManifestPipeWithFakeDocker(t *testing.T) {
+	log := fakeDocker(t)
+	digest := "sha256:" + strings.Repeat("a", 64)
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			DockerManifests: []config.DockerManifest{
+				{
+					ID:           "latest",
+					NameTemplate: "ghcr.io/foo/mybin:{{ .Tag }}",
+					ImageTemplates: []string{
+						"ghcr.io/foo/mybin:{{ .Tag }}-amd64",
+						"ghcr.io/foo/mybin:{{ .Tag }}-arm64",
+						"ghcr.io/foo/mybin:{{ .Tag }}-armv7",
+					},
+					CreateFlags: []string{"--amend"},
+				},
+			},
+		},
+		testctx.WithCurrentTag("v1.0.0"),
+	)
+	for _, arch := range []string{"amd64", "arm64"} {
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Name: "ghcr.io/foo/mybin:v1.0.0-" + arch,
+			Type: artifact.DockerImage,
+			Extra: map[string]interface{}{
+				artifact.ExtraDigest: digest,
+			},
+		})
+	}
+
+	require.NoError(t, ManifestPipe{}.Default(ctx))
+	require.NoError(t, ManifestPipe{}.Publish(ctx))
+
+	bts, err := os.ReadFile(log)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		"manifest rm ghcr.io/foo/mybin:v1.0.0",
+		"manifest create ghcr.io/foo/mybin:v1.0.0" +
+			" ghcr.io/foo/mybin:v1.0.0-amd64@" + digest +
+			" ghcr.io/foo/mybin:v1.0.0-arm64@" + digest +
+			" ghcr.io/foo/mybin:v1.0.0-armv7 --amend",
+		"manifest push ghcr.io/foo/mybin:v1.0.0",
+		"",
+	}, "\n"), string(bts))
+
+	manifests := ctx.Artifacts.Filter(artifact.ByType(artifact.DockerManifest)).List()
+	require.Len(t, manifests, 1)
+	require.Equal(t, "ghcr.io/foo/mybin:v1.0.0", manifests[0].Name)
+	require.Equal(t, "latest", manifests[0].ID())
+	require.Equal(t, digest, artifact.ExtraOr(*manifests[0], artifact.ExtraDigest, ""))
+}
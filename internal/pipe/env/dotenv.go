@@ -0,0 +1,112 @@
+package env
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/caarlos0/log"
+	"github.com/goreleaser/goreleaser/internal/logext"
+	"github.com/goreleaser/goreleaser/pkg/context"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+const defaultDotEnv = ".env"
+
+// loadDotEnvs loads the configured dotenv files into the process environment
+// and ctx.Env. Variables that are already set are left untouched.
+func loadDotEnvs(ctx *context.Context) error {
+	paths := ctx.Config.EnvFiles.DotEnv
+	optional := len(paths) == 0
+	if optional {
+		paths = []string{defaultDotEnv}
+	}
+	for _, path := range paths {
+		path, err := homedir.Expand(path)
+		if err != nil {
+			return err
+		}
+		env, err := parseDotEnv(path)
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load dotenv file: %w", err)
+		}
+		log.Infof("loading environment from %s", logext.Keyword(path))
+		for k, v := range env {
+			if _, ok := os.LookupEnv(k); ok {
+				log.WithField("key", k).Debug("already set, ignoring dotenv value")
+				continue
+			}
+			if err := os.Setenv(k, v); err != nil {
+				return err
+			}
+			ctx.Env[k] = v
+		}
+	}
+	return nil
+}
+
+// parseDotEnv parses KEY=VALUE lines, ignoring blank lines and comments.
+// Values may be single or double quoted, and an optional export prefix is
+// allowed.
+func parseDotEnv(path string) (map[string]string, error) {
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: invalid line: %q", path, n, line)
+		}
+		value, err := parseDotEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		result[key] = value
+	}
+	return result, scanner.Err()
+}
+
+func parseDotEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch quote := value[0]; quote {
+	case '"', '\'':
+		end := strings.LastIndexByte(value, quote)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value: %s", value)
+		}
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected characters after quoted value: %s", value)
+		}
+		value = value[1:end]
+		if quote == '"' {
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value)
+		}
+		return value, nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
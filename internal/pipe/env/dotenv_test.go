@@ -0,0 +1,39 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	env, err := parseDotEnv("testdata/dotenv")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"GORELEASER_DOTENV_PLAIN":    "plain value",
+		"GORELEASER_DOTENV_EXPORTED": "exported",
+		"GORELEASER_DOTENV_DOUBLE":   "double # not a comment\nnext line",
+		"GORELEASER_DOTENV_SINGLE":   `single \n raw`,
+		"GORELEASER_DOTENV_EMPTY":    "",
+		"GORELEASER_DOTENV_SET":      "from dotenv",
+	}, env)
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"no equals":           "FOO",
+		"empty key":           "=foo",
+		"key with spaces":     "FOO BAR=foo",
+		"unterminated quotes": `FOO="bar`,
+		"trailing garbage":    `FOO="bar" baz`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+			_, err := parseDotEnv(path)
+			require.ErrorContains(t, err, path+":1:")
+		})
+	}
+}
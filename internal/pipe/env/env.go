@@ -53,6 +53,10 @@ func setDefaultTokenFiles(ctx *context.Context) {
 
 // Run the pipe.
 func (Pipe) Run(ctx *context.Context) error {
+	if err := loadDotEnvs(ctx); err != nil {
+		return err
+	}
+
 	templ := tmpl.New(ctx).WithEnvS(os.Environ())
 	tEnv := []string{}
 	for i := range ctx.Config.Env {
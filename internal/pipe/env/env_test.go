@@ -256,12 +256,21 @@ func TestInvalidEnvReleaseDisabled(t *testing.T) {
 		require.EqualError(t, Pipe{}.Run(ctx), ErrMissingToken.Error())
 	})
 
-	t.Run("tmpl error", func(t *testing.T) {
+	t.Run("tmpl missing env", func(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
 			Release: config.Release{
 				Disable: "{{ .Env.FOO }}",
 			},
 		})
+		require.EqualError(t, Pipe{}.Run(ctx), ErrMissingToken.Error())
+	})
+
+	t.Run("tmpl error", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: config.Release{
+				Disable: "{{ .Foo }}",
+			},
+		})
 		testlib.RequireTemplateError(t, Pipe{}.Run(ctx))
 	})
 }
@@ -305,3 +314,62 @@ func TestLoadEnv(t *testing.T) {
 		require.Equal(t, "", v)
 	})
 }
+
+func TestDotEnv(t *testing.T) {
+	// make sure every variable is restored after the test
+	for _, key := range []string{
+		"GORELEASER_DOTENV_PLAIN",
+		"GORELEASER_DOTENV_EXPORTED",
+		"GORELEASER_DOTENV_DOUBLE",
+		"GORELEASER_DOTENV_SINGLE",
+		"GORELEASER_DOTENV_EMPTY",
+	} {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+	t.Setenv("GORELEASER_DOTENV_SET", "from env")
+
+	ctx := testctx.NewWithCfg(config.Project{
+		Env: []string{"FROM_DOTENV={{ .Env.GORELEASER_DOTENV_EXPORTED }}"},
+		EnvFiles: config.EnvFiles{
+			DotEnv: []string{"testdata/dotenv"},
+		},
+	}, testctx.Skip(skips.Publish))
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	require.Equal(t, "plain value", os.Getenv("GORELEASER_DOTENV_PLAIN"))
+	require.Equal(t, "exported", os.Getenv("GORELEASER_DOTENV_EXPORTED"))
+	require.Equal(t, "from env", os.Getenv("GORELEASER_DOTENV_SET"))
+	_, ok := os.LookupEnv("GORELEASER_DOTENV_EMPTY")
+	require.True(t, ok)
+	require.Equal(t, "plain value", ctx.Env["GORELEASER_DOTENV_PLAIN"])
+	require.Equal(t, "exported", ctx.Env["FROM_DOTENV"])
+}
+
+func TestDotEnvTokens(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	require.NoError(t, os.Unsetenv("GITHUB_TOKEN"))
+	testlib.Mktmp(t)
+	require.NoError(t, os.WriteFile(".env", []byte("GITHUB_TOKEN=from-dotenv\n"), 0o600))
+
+	ctx := testctx.New()
+	require.NoError(t, Pipe{}.Run(ctx))
+	require.Equal(t, "from-dotenv", ctx.Token)
+	require.Equal(t, context.TokenTypeGitHub, ctx.TokenType)
+}
+
+func TestDotEnvMissing(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		testlib.Mktmp(t)
+		ctx := testctx.New(testctx.Skip(skips.Publish))
+		require.NoError(t, Pipe{}.Run(ctx))
+	})
+	t.Run("configured", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			EnvFiles: config.EnvFiles{
+				DotEnv: []string{"testdata/nope.env"},
+			},
+		}, testctx.Skip(skips.Publish))
+		require.ErrorIs(t, Pipe{}.Run(ctx), os.ErrNotExist)
+	})
+}
@@ -23,6 +23,16 @@ func (e ErrWrongRef) Error() string {
 	return fmt.Sprintf("git tag %v was not made against commit %v", e.tag, e.commit)
 }
 
+// ErrUnsignedTag happens when release.require_signed_tag is set and the tag
+// being built is not a verified signed annotated tag.
+type ErrUnsignedTag struct {
+	tag, reason string
+}
+
+func (e ErrUnsignedTag) Error() string {
+	return fmt.Sprintf("git tag %v is not a verified signed tag: %v", e.tag, e.reason)
+}
+
 // ErrNoTag happens if the underlying git repository doesn't contain any tags
 // but no snapshot-release was requested.
 var ErrNoTag = errors.New("git doesn't contain any tags. Either add a tag or use --snapshot")
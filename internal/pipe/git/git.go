@@ -206,6 +206,29 @@ func validate(ctx *context.Context) error {
 			tag:    ctx.Git.CurrentTag,
 		}
 	}
+	if ctx.Config.Release.RequireSignedTag {
+		return verifyTag(ctx, ctx.Git.CurrentTag)
+	}
+	return nil
+}
+
+// verifyTag checks that the given tag is an annotated tag with a valid
+// signature.
+func verifyTag(ctx *context.Context, tag string) error {
+	kind, err := git.Clean(git.Run(ctx, "cat-file", "-t", tag))
+	if err != nil {
+		return ErrUnsignedTag{tag: tag, reason: err.Error()}
+	}
+	if kind != "tag" {
+		return ErrUnsignedTag{tag: tag, reason: "lightweight tags can't be signed"}
+	}
+	_, output, err := git.RunWithStderr(ctx, "tag", "-v", tag)
+	if err != nil {
+		return ErrUnsignedTag{tag: tag, reason: strings.TrimSpace(err.Error())}
+	}
+	log.WithField("tag", tag).
+		WithField("output", strings.TrimSpace(output)).
+		Info("tag signature verified")
 	return nil
 }
 
@@ -1,15 +1,18 @@
 package git
 
 import (
+	"bytes"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
 
+	"github.com/caarlos0/log"
 	"github.com/goreleaser/goreleaser/internal/skips"
 	"github.com/goreleaser/goreleaser/internal/testctx"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/goreleaser/goreleaser/pkg/context"
 	"github.com/stretchr/testify/require"
 )
 
@@ -395,14 +398,165 @@ func TestFilterTags(t *testing.T) {
 		require.Equal(t, "v0.1.0-dev", ctx.Git.CurrentTag)
 	})
 
+	t.Run("template missing env", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Git: config.Git{
+				IgnoreTags: []string{
+					"{{.Env.IGNORE}}",
+					"v0.0.2",
+				},
+			},
+		})
+		require.NoError(t, Pipe{}.Run(ctx))
+		require.Equal(t, "nightly", ctx.Git.PreviousTag)
+		require.Equal(t, "v0.1.0-dev", ctx.Git.CurrentTag)
+	})
+
 	t.Run("invalid template", func(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
 			Git: config.Git{
 				IgnoreTags: []string{
-					"{{.Env.Nope}}",
+					"{{.Nope}}",
 				},
 			},
 		})
 		testlib.RequireTemplateError(t, Pipe{}.Run(ctx))
 	})
 }
+
+func TestValidateStates(t *testing.T) {
+	setup := func(tb testing.TB) string {
+		tb.Helper()
+		folder := testlib.Mktmp(tb)
+		testlib.GitInit(tb)
+		testlib.GitRemoteAdd(tb, "git@github.com:foo/bar.git")
+		require.NoError(tb, os.WriteFile(filepath.Join(folder, "main.go"), []byte("package main"), 0o644))
+		testlib.GitAdd(tb)
+		testlib.GitCommit(tb, "first")
+		testlib.GitTag(tb, "v1.0.0")
+		return folder
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		setup(t)
+		ctx := testctx.New()
+		require.NoError(t, Pipe{}.Run(ctx))
+		require.False(t, ctx.Git.Dirty)
+		require.Equal(t, "v1.0.0", ctx.Git.CurrentTag)
+	})
+
+	t.Run("modified file", func(t *testing.T) {
+		folder := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(folder, "main.go"), []byte("package foo"), 0o644))
+		ctx := testctx.New()
+		err := Pipe{}.Run(ctx)
+		require.ErrorAs(t, err, &ErrDirty{})
+		require.ErrorContains(t, err, "M main.go")
+		require.True(t, ctx.Git.Dirty)
+	})
+
+	t.Run("untracked file", func(t *testing.T) {
+		folder := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(folder, "new.go"), []byte("package main"), 0o644))
+		ctx := testctx.New()
+		err := Pipe{}.Run(ctx)
+		require.ErrorAs(t, err, &ErrDirty{})
+		require.ErrorContains(t, err, "?? new.go")
+		require.True(t, ctx.Git.Dirty)
+	})
+
+	t.Run("untagged head", func(t *testing.T) {
+		setup(t)
+		testlib.GitCommit(t, "second")
+		ctx := testctx.New()
+		err := Pipe{}.Run(ctx)
+		require.ErrorAs(t, err, &ErrWrongRef{})
+		require.False(t, ctx.Git.Dirty)
+	})
+}
+
+func TestRequireSignedTag(t *testing.T) {
+	testlib.CheckPath(t, "gpg")
+	gnupg := t.TempDir()
+	require.NoError(t, os.Chmod(gnupg, 0o700))
+	t.Setenv("GNUPGHOME", gnupg)
+	t.Cleanup(func() {
+		_ = exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+	})
+	const key = "test@goreleaser.com"
+	out, err := exec.Command(
+		"gpg", "--batch", "--passphrase", "",
+		"--quick-gen-key", "goreleaser <"+key+">", "ed25519", "sign", "never",
+	).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	newCtx := func() *context.Context {
+		return testctx.NewWithCfg(config.Project{
+			Release: config.Release{
+				RequireSignedTag: true,
+			},
+		})
+	}
+	setup := func(tb testing.TB) {
+		tb.Helper()
+		testlib.Mktmp(tb)
+		testlib.GitInit(tb)
+		testlib.GitRemoteAdd(tb, "git@github.com:foo/bar.git")
+		testlib.GitCommit(tb, "first")
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		setup(t)
+		testlib.GitSignedTag(t, "v1.0.0", "signed", key)
+		var w bytes.Buffer
+		log.Log = log.New(&w)
+		t.Cleanup(func() { log.Log = log.New(os.Stderr) })
+		ctx := newCtx()
+		require.NoError(t, Pipe{}.Run(ctx))
+		require.Equal(t, "v1.0.0", ctx.Git.CurrentTag)
+		require.Contains(t, w.String(), "tag signature verified")
+		require.Contains(t, w.String(), `Good signature from "goreleaser <`+key+`>"`)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		setup(t)
+		testlib.GitSignedTag(t, "v1.0.0", "signed", key)
+		empty := t.TempDir()
+		require.NoError(t, os.Chmod(empty, 0o700))
+		t.Setenv("GNUPGHOME", empty)
+		err := Pipe{}.Run(newCtx())
+		require.ErrorAs(t, err, &ErrUnsignedTag{})
+		require.ErrorContains(t, err, "git tag v1.0.0 is not a verified signed tag: gpg: ")
+		require.ErrorContains(t, err, "gpg: Signature made")
+		require.ErrorContains(t, err, "gpg: Can't check signature: No public key")
+	})
+
+	t.Run("unsigned annotated", func(t *testing.T) {
+		setup(t)
+		testlib.GitAnnotatedTag(t, "v1.0.0", "unsigned")
+		err := Pipe{}.Run(newCtx())
+		require.ErrorAs(t, err, &ErrUnsignedTag{})
+		require.ErrorContains(t, err, "git tag v1.0.0 is not a verified signed tag: error: no signature found")
+	})
+
+	t.Run("lightweight", func(t *testing.T) {
+		setup(t)
+		testlib.GitTag(t, "v1.0.0")
+		err := Pipe{}.Run(newCtx())
+		require.EqualError(t, err, "git tag v1.0.0 is not a verified signed tag: lightweight tags can't be signed")
+	})
+
+	t.Run("not required", func(t *testing.T) {
+		setup(t)
+		testlib.GitTag(t, "v1.0.0")
+		require.NoError(t, Pipe{}.Run(testctx.New()))
+	})
+
+	t.Run("skip validate", func(t *testing.T) {
+		setup(t)
+		testlib.GitTag(t, "v1.0.0")
+		ctx := newCtx()
+		skips.Set(ctx, skips.Validate)
+		testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	})
+}
@@ -0,0 +1,172 @@
+// Package gitlabpackages provides a Pipe that pushes to GitLab generic package
+// registries.
+package gitlabpackages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	h "net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/http"
+	"github.com/goreleaser/goreleaser/internal/pipe"
+	"github.com/goreleaser/goreleaser/internal/tmpl"
+	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/goreleaser/goreleaser/pkg/context"
+)
+
+const (
+	kind       = "gitlab packages"
+	defaultURL = "https://gitlab.com"
+)
+
+// Pipe for GitLab generic packages.
+type Pipe struct{}
+
+func (Pipe) String() string                 { return "gitlab packages" }
+func (Pipe) Skip(ctx *context.Context) bool { return len(ctx.Config.GitLabPackages) == 0 }
+
+// Default sets the pipe defaults.
+func (Pipe) Default(ctx *context.Context) error {
+	for i := range ctx.Config.GitLabPackages {
+		pkg := &ctx.Config.GitLabPackages[i]
+		if pkg.URL == "" {
+			pkg.URL = defaultBaseURL(ctx)
+		}
+		if pkg.Package == "" {
+			pkg.Package = "{{ .ProjectName }}"
+		}
+		if pkg.Version == "" {
+			pkg.Version = "{{ .Version }}"
+		}
+		if pkg.Mode == "" {
+			pkg.Mode = http.ModeArchive
+		}
+	}
+	return nil
+}
+
+// Validate checks all the gitlab_packages sections.
+func (Pipe) Validate(ctx *context.Context) error {
+	var errs []error
+	for i, pkg := range ctx.Config.GitLabPackages {
+		field := fmt.Sprintf("gitlab_packages[%d]", i)
+		if pkg.Name == "" {
+			errs = append(errs, pipe.FieldErrorf(field+".name", "required"))
+		}
+		if pkg.ProjectID == "" {
+			errs = append(errs, pipe.FieldErrorf(field+".project_id", "required"))
+		}
+		if tokenEnv := tokenEnv(pkg); ctx.Env[tokenEnv] == "" {
+			errs = append(errs, pipe.FieldErrorf(field, "missing %s environment variable", tokenEnv))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Publish artifacts to the GitLab generic package registries.
+//
+// Docs: https://docs.gitlab.com/ee/user/packages/generic_packages/
+func (p Pipe) Publish(ctx *context.Context) error {
+	if err := p.Validate(ctx); err != nil {
+		return err
+	}
+	uploads := make([]config.Upload, 0, len(ctx.Config.GitLabPackages))
+	for _, pkg := range ctx.Config.GitLabPackages {
+		upload, err := toUpload(ctx, pkg)
+		if err != nil {
+			return err
+		}
+		uploads = append(uploads, upload)
+	}
+	if err := http.Defaults(uploads); err != nil {
+		return err
+	}
+	if err := http.Validate(ctx, uploads, kind, "gitlab_packages"); err != nil {
+		return err
+	}
+	return http.Upload(ctx, uploads, kind, checkResponse)
+}
+
+// defaultBaseURL returns the GitLab instance set in gitlab_urls.api, if any,
+// or gitlab.com.
+func defaultBaseURL(ctx *context.Context) string {
+	api := strings.TrimSuffix(ctx.Config.GitLabURLs.API, "/")
+	if api == "" {
+		return defaultURL
+	}
+	return strings.TrimSuffix(api, "/api/v4")
+}
+
+// tokenEnv returns the environment variable holding the token of the given
+// package.
+func tokenEnv(pkg config.GitLabPackage) string {
+	if pkg.UseJobToken {
+		return "CI_JOB_TOKEN"
+	}
+	return "GITLAB_TOKEN"
+}
+
+// toUpload converts a package into the equivalent upload.
+func toUpload(ctx *context.Context, pkg config.GitLabPackage) (config.Upload, error) {
+	baseURL, projectID, name, version := pkg.URL, pkg.ProjectID, pkg.Package, pkg.Version
+	if err := tmpl.New(ctx).ApplyAll(&baseURL, &projectID, &name, &version); err != nil {
+		return config.Upload{}, fmt.Errorf("%s: %s: %w", pkg.Name, kind, err)
+	}
+
+	authType := http.AuthGitLab
+	if pkg.UseJobToken {
+		authType = http.AuthGitLabJob
+	}
+	return config.Upload{
+		Name: pkg.Name,
+		Target: fmt.Sprintf(
+			"%s/api/v4/projects/%s/packages/generic/%s/%s/",
+			strings.TrimSuffix(baseURL, "/"),
+			url.PathEscape(projectID),
+			url.PathEscape(name),
+			url.PathEscape(version),
+		),
+		Mode:      pkg.Mode,
+		Method:    h.MethodPut,
+		IDs:       pkg.IDs,
+		Exts:      pkg.Exts,
+		Checksum:  pkg.Checksum,
+		Signature: pkg.Signature,
+		Disable:   pkg.Disable,
+		AuthType:  authType,
+		SecretEnv: tokenEnv(pkg),
+	}, nil
+}
+
+// errorResponse is the body of GitLab API errors.
+type errorResponse struct {
+	Message any `json:"message"`
+	Error   any `json:"error"`
+}
+
+// checkResponse checks the API response for errors, and returns them if
+// present.
+func checkResponse(r *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
+	if c := r.StatusCode; 200 <= c && c <= 299 {
+		return nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("unexpected http response status: %s", r.Status)
+	}
+	var resp errorResponse
+	if err := json.Unmarshal(data, &resp); err == nil {
+		if resp.Message != nil {
+			return fmt.Errorf("unexpected http response status: %s: %v", r.Status, resp.Message)
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("unexpected http response status: %s: %v", r.Status, resp.Error)
+		}
+	}
+	return fmt.Errorf("unexpected http response status: %s: %s", r.Status, string(data))
+}
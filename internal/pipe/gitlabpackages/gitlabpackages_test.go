@@ -0,0 +1,201 @@
+package gitlabpackages
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/pipe"
+	"github.com/goreleaser/goreleaser/internal/testctx"
+	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/goreleaser/goreleaser/pkg/context"
+	"github.com/stretchr/testify/require"
+)
+
+type upload struct {
+	path, token, jobToken, body string
+}
+
+// registry starts a mock generic package registry, returning the server and
+// a function returning the uploads it received.
+func registry(t *testing.T, status int, response string) (*httptest.Server, func() []upload) {
+	t.Helper()
+	var m sync.Mutex
+	var uploads []upload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		bts, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		m.Lock()
+		uploads = append(uploads, upload{
+			path:     r.URL.EscapedPath(),
+			token:    r.Header.Get("PRIVATE-TOKEN"),
+			jobToken: r.Header.Get("JOB-TOKEN"),
+			body:     string(bts),
+		})
+		m.Unlock()
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []upload {
+		m.Lock()
+		defer m.Unlock()
+		return uploads
+	}
+}
+
+func newContext(t *testing.T, pkg config.GitLabPackage, env ...string) *context.Context {
+	t.Helper()
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName:    "mybin",
+		GitLabPackages: []config.GitLabPackage{pkg},
+		Env:            env,
+	}, testctx.WithVersion("1.0.0"))
+	for _, a := range []struct {
+		name string
+		typ  artifact.Type
+	}{
+		{"mybin.tar.gz", artifact.UploadableArchive},
+		{"mybin", artifact.UploadableBinary},
+	} {
+		path := filepath.Join(folder, a.name)
+		require.NoError(t, os.WriteFile(path, []byte(a.name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: a.name, Path: path, Type: a.typ})
+	}
+	require.NoError(t, Pipe{}.Default(ctx))
+	return ctx
+}
+
+func TestDescription(t *testing.T) {
+	require.NotEmpty(t, Pipe{}.String())
+}
+
+func TestSkip(t *testing.T) {
+	t.Run("skip", func(t *testing.T) {
+		require.True(t, Pipe{}.Skip(testctx.New()))
+	})
+
+	t.Run("dont skip", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			GitLabPackages: []config.GitLabPackage{{}},
+		})
+		require.False(t, Pipe{}.Skip(ctx))
+	})
+}
+
+func TestDefault(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		GitLabPackages: []config.GitLabPackage{{Name: "production"}},
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.Equal(t, config.GitLabPackage{
+		Name:    "production",
+		URL:     "https://gitlab.com",
+		Package: "{{ .ProjectName }}",
+		Version: "{{ .Version }}",
+		Mode:    "archive",
+	}, ctx.Config.GitLabPackages[0])
+}
+
+func TestDefaultGitLabURLs(t *testing.T) {
+	for _, api := range []string{
+		"https://gitlab.mycompany.com/api/v4/",
+		"https://gitlab.mycompany.com/api/v4",
+		"https://gitlab.mycompany.com/",
+	} {
+		t.Run(api, func(t *testing.T) {
+			ctx := testctx.NewWithCfg(config.Project{
+				GitLabURLs:     config.GitLabURLs{API: api},
+				GitLabPackages: []config.GitLabPackage{{Name: "production"}},
+			})
+			require.NoError(t, Pipe{}.Default(ctx))
+			require.Equal(t, "https://gitlab.mycompany.com", ctx.Config.GitLabPackages[0].URL)
+		})
+	}
+}
+
+func TestRunPipe(t *testing.T) {
+	srv, uploads := registry(t, http.StatusCreated, `{"message":"201 Created"}`)
+	ctx := newContext(t, config.GitLabPackage{
+		Name:      "production",
+		URL:       srv.URL + "/",
+		ProjectID: "group/project",
+	}, "GITLAB_TOKEN=secret")
+
+	require.NoError(t, Pipe{}.Publish(ctx))
+	require.Equal(t, []upload{{
+		path:  "/api/v4/projects/group%2Fproject/packages/generic/mybin/1.0.0/mybin.tar.gz",
+		token: "secret",
+		body:  "mybin.tar.gz",
+	}}, uploads())
+}
+
+func TestRunPipe_JobToken(t *testing.T) {
+	srv, uploads := registry(t, http.StatusCreated, "")
+	ctx := newContext(t, config.GitLabPackage{
+		Name:        "production",
+		URL:         srv.URL,
+		ProjectID:   "42",
+		Package:     "{{ .ProjectName }}-bin",
+		Mode:        "binary",
+		UseJobToken: true,
+	}, "CI_JOB_TOKEN=job")
+
+	require.NoError(t, Pipe{}.Publish(ctx))
+	require.Equal(t, []upload{{
+		path:     "/api/v4/projects/42/packages/generic/mybin-bin/1.0.0/mybin",
+		jobToken: "job",
+		body:     "mybin",
+	}}, uploads())
+}
+
+func TestRunPipe_Error(t *testing.T) {
+	srv, _ := registry(t, http.StatusForbidden, `{"message":"403 Forbidden"}`)
+	ctx := newContext(t, config.GitLabPackage{
+		Name:      "production",
+		URL:       srv.URL,
+		ProjectID: "42",
+	}, "GITLAB_TOKEN=secret")
+
+	require.EqualError(t, Pipe{}.Publish(ctx), "production: gitlab packages: upload failed: unexpected http response status: 403 Forbidden: 403 Forbidden")
+}
+
+func TestRunPipe_MissingProjectID(t *testing.T) {
+	ctx := newContext(t, config.GitLabPackage{Name: "production"}, "GITLAB_TOKEN=secret")
+	require.EqualError(t, Pipe{}.Publish(ctx), "gitlab_packages[0].project_id: required")
+}
+
+func TestRunPipe_MissingToken(t *testing.T) {
+	ctx := newContext(t, config.GitLabPackage{Name: "production", ProjectID: "42", UseJobToken: true}, "GITLAB_TOKEN=secret")
+	require.EqualError(t, Pipe{}.Publish(ctx), "gitlab_packages[0]: missing CI_JOB_TOKEN environment variable")
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		ctx := newContext(t, config.GitLabPackage{Name: "production", ProjectID: "42"}, "GITLAB_TOKEN=secret")
+		require.NoError(t, Pipe{}.Validate(ctx))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			GitLabPackages: []config.GitLabPackage{
+				{Name: "production", ProjectID: "42"},
+				{UseJobToken: true},
+			},
+		})
+		err := Pipe{}.Validate(ctx)
+		var field pipe.FieldError
+		require.ErrorAs(t, err, &field)
+		require.EqualError(t, err, `gitlab_packages[0]: missing GITLAB_TOKEN environment variable
+gitlab_packages[1].name: required
+gitlab_packages[1].project_id: required
+gitlab_packages[1]: missing CI_JOB_TOKEN environment variable`)
+	})
+}
@@ -1668,3 +1668,75 @@ func sources(contents files.Contents) []string {
 	}
 	return result
 }
+
+func TestRunPipeLinuxAmd64(t *testing.T) {
+	dist := t.TempDir()
+	binPath := filepath.Join(dist, "mybin")
+	require.NoError(t, os.WriteFile(binPath, []byte("fake"), 0o755))
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "mybin",
+		Dist:        dist,
+		NFPMs: []config.NFPM{
+			{
+				ID:          "pkgs",
+				Bindir:      "/usr/bin",
+				Formats:     []string{"deb", "rpm"},
+				Description: "My binary",
+				Maintainer:  "me@me",
+				NFPMOverridables: config.NFPMOverridables{
+					Dependencies: []string{"git"},
+					Scripts: config.NFPMScripts{
+						PreInstall:  "./testdata/pre_install.sh",
+						PostInstall: "./testdata/post_install.sh",
+					},
+					Contents: []*files.Content{
+						{
+							Source:      "./testdata/testfile.txt",
+							Destination: "/etc/mybin/config.yaml",
+							Type:        files.TypeConfig,
+						},
+					},
+				},
+			},
+		},
+	}, testctx.WithVersion("1.0.0"), testctx.WithCurrentTag("v1.0.0"))
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name:    "mybin",
+		Path:    binPath,
+		Goos:    "linux",
+		Goarch:  "amd64",
+		Goamd64: "v1",
+		Type:    artifact.Binary,
+		Extra: map[string]interface{}{
+			artifact.ExtraID: "default",
+		},
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	packages := ctx.Artifacts.Filter(artifact.ByType(artifact.LinuxPackage)).List()
+	require.Len(t, packages, 2)
+	var names []string
+	for _, pkg := range packages {
+		names = append(names, pkg.Name)
+		require.FileExists(t, pkg.Path)
+		require.Equal(t, filepath.Join(dist, pkg.Name), pkg.Path)
+		require.Equal(t, "pkgs", pkg.ID())
+		require.Equal(t, "linux", pkg.Goos)
+		require.Equal(t, "amd64", pkg.Goarch)
+		contents := artifact.ExtraOr(*pkg, extraFiles, files.Contents{})
+		require.ElementsMatch(t, []string{
+			"/usr/bin/mybin",
+			"/etc/mybin/config.yaml",
+		}, destinations(contents))
+		for _, f := range contents {
+			if f.Destination == "/etc/mybin/config.yaml" {
+				require.Equal(t, files.TypeConfig, f.Type)
+			}
+		}
+	}
+	require.ElementsMatch(t, []string{
+		"mybin_1.0.0_linux_amd64.deb",
+		"mybin_1.0.0_linux_amd64.rpm",
+	}, names)
+}
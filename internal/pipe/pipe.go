@@ -9,31 +9,57 @@ import (
 
 // ErrSnapshotEnabled happens when goreleaser is running in snapshot mode.
 // It usually means that publishing and maybe some validations were skipped.
-var ErrSnapshotEnabled = Skip("disabled during snapshot mode")
+var ErrSnapshotEnabled = SkipWith(SkipDisabled, "disabled during snapshot mode")
 
 // ErrSkipPublishEnabled happens if --skip-publish is set.
 // It means that the part of a Piper that publishes its artifacts was not run.
-var ErrSkipPublishEnabled = Skip("publishing is disabled")
+var ErrSkipPublishEnabled = SkipWith(SkipDisabled, "publishing is disabled")
 
 // ErrSkipAnnounceEnabled happens if --skip-announce is set.
-var ErrSkipAnnounceEnabled = Skip("announcing is disabled")
+var ErrSkipAnnounceEnabled = SkipWith(SkipDisabled, "announcing is disabled")
 
 // ErrSkipSignEnabled happens if --skip-sign is set.
 // It means that the part of a Piper that signs some things was not run.
-var ErrSkipSignEnabled = Skip("artifact signing is disabled")
+var ErrSkipSignEnabled = SkipWith(SkipDisabled, "artifact signing is disabled")
 
 // ErrSkipValidateEnabled happens if --skip-validate is set.
 // It means that the part of a Piper that validates some things was not run.
-var ErrSkipValidateEnabled = Skip("validation is disabled")
+var ErrSkipValidateEnabled = SkipWith(SkipDisabled, "validation is disabled")
+
+// SkipCategory tells why a pipe was skipped.
+type SkipCategory string
+
+const (
+	// SkipOther is the category of skips that were not categorized.
+	SkipOther SkipCategory = "other"
+	// SkipDisabled means the user asked for it, e.g. with --skip or disable.
+	SkipDisabled SkipCategory = "disabled"
+	// SkipNotConfigured means the configuration is missing or incomplete,
+	// e.g. a required secret is not set.
+	SkipNotConfigured SkipCategory = "not configured"
+	// SkipNothingToDo means there was nothing for the pipe to work on.
+	SkipNothingToDo SkipCategory = "nothing to do"
+)
 
 // IsSkip returns true if the error is an ErrSkip.
 func IsSkip(err error) bool {
 	return errors.As(err, &ErrSkip{})
 }
 
+// CategoryOf returns the category of the given skip error.
+// Errors that are not skips, or uncategorized skips, are SkipOther.
+func CategoryOf(err error) SkipCategory {
+	var skip ErrSkip
+	if errors.As(err, &skip) {
+		return skip.Category()
+	}
+	return SkipOther
+}
+
 // ErrSkip occurs when a pipe is skipped for some reason.
 type ErrSkip struct {
-	reason string
+	reason   string
+	category SkipCategory
 }
 
 // Error implements the error interface. returns the reason the pipe was skipped.
@@ -41,6 +67,14 @@ func (e ErrSkip) Error() string {
 	return e.reason
 }
 
+// Category returns why the pipe was skipped.
+func (e ErrSkip) Category() SkipCategory {
+	if e.category == "" {
+		return SkipOther
+	}
+	return e.category
+}
+
 // Skip skips this pipe with the given reason.
 func Skip(reason string) ErrSkip {
 	return ErrSkip{reason: reason}
@@ -51,9 +85,37 @@ func Skipf(format string, a ...any) ErrSkip {
 	return Skip(fmt.Sprintf(format, a...))
 }
 
+// SkipWith skips this pipe with the given category and reason.
+func SkipWith(category SkipCategory, reason string) ErrSkip {
+	return ErrSkip{reason: reason, category: category}
+}
+
+// SkipWithf skips this pipe with the given category and reason.
+func SkipWithf(category SkipCategory, format string, a ...any) ErrSkip {
+	return SkipWith(category, fmt.Sprintf(format, a...))
+}
+
+// FieldError is a problem with a specific configuration field, found while
+// validating the configuration.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// FieldErrorf creates a FieldError for the given field.
+func FieldErrorf(field, format string, a ...any) FieldError {
+	return FieldError{Field: field, Reason: fmt.Sprintf(format, a...)}
+}
+
 // SkipMemento remembers previous skip errors so you can return them all at once later.
 type SkipMemento struct {
-	skips []string
+	skips    []string
+	category SkipCategory
 }
 
 // Remember a skip.
@@ -64,12 +126,20 @@ func (e *SkipMemento) Remember(err error) {
 		}
 	}
 	e.skips = append(e.skips, err.Error())
+	var skip ErrSkip
+	_ = errors.As(err, &skip)
+	if len(e.skips) == 1 {
+		e.category = skip.category
+	} else if e.category != skip.category {
+		e.category = ""
+	}
 }
 
 // Evaluate return a skip error with all previous skips, or nil if none happened.
+// The error keeps the category of the remembered skips if they all share it.
 func (e *SkipMemento) Evaluate() error {
 	if len(e.skips) == 0 {
 		return nil
 	}
-	return Skip(strings.Join(e.skips, ", "))
+	return SkipWith(e.category, strings.Join(e.skips, ", "))
 }
@@ -2,6 +2,7 @@ package pipe
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -32,3 +33,37 @@ func TestSkipMemento(t *testing.T) {
 func TestSkipMementoNoErrors(t *testing.T) {
 	require.NoError(t, (&SkipMemento{}).Evaluate())
 }
+
+func TestSkipCategory(t *testing.T) {
+	require.Equal(t, SkipOther, Skip("foo").Category())
+	require.Equal(t, SkipNotConfigured, SkipWith(SkipNotConfigured, "foo").Category())
+	require.Equal(t, SkipDisabled, ErrSkipPublishEnabled.Category())
+
+	err := SkipWithf(SkipNothingToDo, "no %s", "artifacts")
+	require.EqualError(t, err, "no artifacts")
+	require.True(t, IsSkip(err))
+	require.Equal(t, SkipNothingToDo, CategoryOf(fmt.Errorf("wrapped: %w", err)))
+	require.Equal(t, SkipOther, CategoryOf(errors.New("nope")))
+}
+
+func TestSkipMementoCategory(t *testing.T) {
+	t.Run("same", func(t *testing.T) {
+		m := SkipMemento{}
+		m.Remember(SkipWith(SkipDisabled, "foo"))
+		m.Remember(SkipWith(SkipDisabled, "bar"))
+		require.Equal(t, SkipDisabled, CategoryOf(m.Evaluate()))
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		m := SkipMemento{}
+		m.Remember(SkipWith(SkipDisabled, "foo"))
+		m.Remember(SkipWith(SkipNotConfigured, "bar"))
+		require.Equal(t, SkipOther, CategoryOf(m.Evaluate()))
+	})
+}
+
+func TestFieldError(t *testing.T) {
+	err := FieldErrorf("artifactories[0].target", "%s is required", "target")
+	require.EqualError(t, err, "artifactories[0].target: target is required")
+	require.False(t, IsSkip(err))
+}
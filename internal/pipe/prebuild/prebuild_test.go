@@ -28,10 +28,18 @@ func TestRun(t *testing.T) {
 		require.Equal(t, ".", ctx.Config.Builds[0].Main)
 	})
 
-	t.Run("bad", func(t *testing.T) {
+	t.Run("missing env", func(t *testing.T) {
 		ctx := context.New(config.Project{
 			Builds: []config.Build{{Main: "{{ .Env.FOO }}"}},
 		})
+		require.NoError(t, Pipe{}.Run(ctx))
+		require.Equal(t, ".", ctx.Config.Builds[0].Main)
+	})
+
+	t.Run("bad", func(t *testing.T) {
+		ctx := context.New(config.Project{
+			Builds: []config.Build{{Main: "{{ .Foo }}"}},
+		})
 		testlib.RequireTemplateError(t, Pipe{}.Run(ctx))
 	})
 }
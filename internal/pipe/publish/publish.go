@@ -4,9 +4,12 @@ package publish
 import (
 	"fmt"
 
+	"github.com/goreleaser/goreleaser/internal/http"
 	"github.com/goreleaser/goreleaser/internal/middleware/errhandler"
 	"github.com/goreleaser/goreleaser/internal/middleware/logging"
 	"github.com/goreleaser/goreleaser/internal/middleware/skip"
+	"github.com/goreleaser/goreleaser/internal/middleware/summary"
+	"github.com/goreleaser/goreleaser/internal/pipe"
 	"github.com/goreleaser/goreleaser/internal/pipe/artifactory"
 	"github.com/goreleaser/goreleaser/internal/pipe/aur"
 	"github.com/goreleaser/goreleaser/internal/pipe/blob"
@@ -14,6 +17,7 @@ import (
 	"github.com/goreleaser/goreleaser/internal/pipe/chocolatey"
 	"github.com/goreleaser/goreleaser/internal/pipe/custompublishers"
 	"github.com/goreleaser/goreleaser/internal/pipe/docker"
+	"github.com/goreleaser/goreleaser/internal/pipe/gitlabpackages"
 	"github.com/goreleaser/goreleaser/internal/pipe/ko"
 	"github.com/goreleaser/goreleaser/internal/pipe/krew"
 	"github.com/goreleaser/goreleaser/internal/pipe/milestone"
@@ -43,6 +47,7 @@ func New() Pipe {
 			blob.Pipe{},
 			upload.Pipe{},
 			artifactory.Pipe{},
+			gitlabpackages.Pipe{},
 			custompublishers.Pipe{},
 			docker.Pipe{},
 			docker.ManifestPipe{},
@@ -73,15 +78,21 @@ func (Pipe) String() string                 { return "publishing" }
 func (Pipe) Skip(ctx *context.Context) bool { return skips.Any(ctx, skips.Publish) }
 
 func (p Pipe) Run(ctx *context.Context) error {
+	// the upload instances locks are held until every publisher is done.
+	defer ctx.UploadLocks.Release()
 	memo := errhandler.Memo{}
 	for _, publisher := range p.pipeline {
-		if err := skip.Maybe(
+		publish := publisher.Publish
+		if ctx.PrintUploadPlan && !supportsPlan(publisher) {
+			publish = skipForPlan
+		}
+		if err := summary.Wrap(publisher.String(), skip.Maybe(
 			publisher,
 			logging.PadLog(
 				publisher.String(),
-				errhandler.Handle(publisher.Publish),
+				errhandler.Handle(summary.Track(publish)),
 			),
-		)(ctx); err != nil {
+		))(ctx); err != nil {
 			if ig, ok := publisher.(Continuable); ok && ig.ContinueOnError() && !ctx.FailFast {
 				memo.Memorize(fmt.Errorf("%s: %w", publisher.String(), err))
 				continue
@@ -89,9 +100,31 @@ func (p Pipe) Run(ctx *context.Context) error {
 			return fmt.Errorf("%s: failed to publish artifacts: %w", publisher.String(), err)
 		}
 	}
-	return memo.Error()
+	if err := memo.Error(); err != nil {
+		return err
+	}
+	if err := http.TagPublished(ctx); err != nil {
+		return fmt.Errorf("tag_after_publish: %w", err)
+	}
+	return nil
 }
 
 type Continuable interface {
 	ContinueOnError() bool
 }
+
+// Planner is implemented by the publishers that, when printing the upload
+// plan, write where they would upload the artifacts instead of uploading them.
+// The other publishers are skipped when printing the upload plan.
+type Planner interface {
+	SupportsPlan() bool
+}
+
+func supportsPlan(publisher Publisher) bool {
+	planner, ok := publisher.(Planner)
+	return ok && planner.SupportsPlan()
+}
+
+func skipForPlan(*context.Context) error {
+	return pipe.SkipWithf(pipe.SkipDisabled, "printing the upload plan only")
+}
@@ -71,6 +71,18 @@ func TestPublishError(t *testing.T) {
 	require.False(t, lastStep.ran)
 }
 
+func TestPublishPrintPlan(t *testing.T) {
+	ctx := testctx.New()
+	ctx.PrintUploadPlan = true
+	other := &testPublisher{}
+	planner := &testPublisher{planner: true}
+	require.NoError(t, Pipe{
+		pipeline: []Publisher{other, planner},
+	}.Run(ctx))
+	require.False(t, other.ran)
+	require.True(t, planner.ran)
+}
+
 func TestSkip(t *testing.T) {
 	t.Run("skip", func(t *testing.T) {
 		ctx := testctx.New(testctx.Skip(skips.Publish))
@@ -86,10 +98,12 @@ type testPublisher struct {
 	shouldErr   bool
 	shouldSkip  bool
 	continuable bool
+	planner     bool
 	ran         bool
 }
 
 func (t *testPublisher) ContinueOnError() bool { return t.continuable }
+func (t *testPublisher) SupportsPlan() bool    { return t.planner }
 func (t *testPublisher) String() string        { return "test" }
 func (t *testPublisher) Publish(_ *context.Context) error {
 	if t.shouldSkip {
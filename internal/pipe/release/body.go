@@ -3,6 +3,7 @@ package release
 import (
 	"bytes"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/goreleaser/goreleaser/internal/artifact"
@@ -22,6 +23,12 @@ func describeBody(ctx *context.Context) (bytes.Buffer, error) {
 	checksums := ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum))
 
 	checksumsList := checksums.List()
+	// prefer the per artifact checksums over the aggregate ones when split
+	if split := checksums.Filter(func(a *artifact.Artifact) bool {
+		return artifact.ExtraOr(*a, artifact.ExtraChecksumOf, "") != ""
+	}).List(); len(split) > 0 {
+		checksumsList = split
+	}
 	switch len(checksumsList) {
 	case 0:
 		// do nothing
@@ -38,7 +45,7 @@ func describeBody(ctx *context.Context) (bytes.Buffer, error) {
 			if err != nil {
 				return out, err
 			}
-			checkMap[artifact.ExtraOr(*check, artifact.ExtraChecksumOf, "")] = string(bts)
+			checkMap[artifact.ExtraOr(*check, artifact.ExtraChecksumOf, "")] = strings.TrimSpace(string(bts))
 		}
 		fields["Checksums"] = checkMap
 	}
@@ -147,3 +147,28 @@ func TestDescribeBodyWithInvalidFooterTemplate(t *testing.T) {
 	_, err := describeBody(ctx)
 	testlib.RequireTemplateError(t, err)
 }
+
+func TestDescribeBodyHeaderAndFooterSandwich(t *testing.T) {
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			ProjectName: "foo",
+			Release: config.Release{
+				Header: "## {{ .ProjectName }} {{ .Version }}\n\n`go install example.com/foo@{{ .Tag }}`",
+				Footer: "Sponsor {{ .ProjectName }} at https://example.com/sponsor",
+			},
+		},
+		testctx.WithCurrentTag("v1.2.3"),
+		testctx.WithVersion("1.2.3"),
+		func(ctx *context.Context) { ctx.ReleaseNotes = "* abc123 feat: something" },
+	)
+	out, err := describeBody(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "## foo 1.2.3\n\n`go install example.com/foo@v1.2.3`\n* abc123 feat: something\nSponsor foo at https://example.com/sponsor\n", out.String())
+}
+
+func TestDescribeBodyNoHeaderNorFooter(t *testing.T) {
+	ctx := testctx.New(func(ctx *context.Context) { ctx.ReleaseNotes = "* abc123 feat: something" })
+	out, err := describeBody(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "* abc123 feat: something\n", out.String())
+}
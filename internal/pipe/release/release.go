@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"time"
 
 	"github.com/caarlos0/log"
@@ -55,6 +56,21 @@ func (p Pipe) Default(ctx *context.Context) error {
 		ctx.Config.Release.NameTemplate = "{{.Tag}}"
 	}
 
+	switch ctx.Config.Release.AssetsMode {
+	case "":
+		ctx.Config.Release.AssetsMode = config.ReleaseAssetsModeFail
+		if ctx.Config.Release.ReplaceExistingArtifacts {
+			ctx.Config.Release.AssetsMode = config.ReleaseAssetsModeReplace
+		}
+	case config.ReleaseAssetsModeFail:
+	case config.ReleaseAssetsModeReplace, config.ReleaseAssetsModeKeep:
+		if ctx.TokenType == context.TokenTypeGitLab || ctx.TokenType == context.TokenTypeGitea {
+			return fmt.Errorf("release.assets_mode '%s' is only supported on GitHub", ctx.Config.Release.AssetsMode)
+		}
+	default:
+		return fmt.Errorf("invalid release.assets_mode '%s', valid options are 'fail', 'replace' and 'keep'", ctx.Config.Release.AssetsMode)
+	}
+
 	switch ctx.TokenType {
 	case context.TokenTypeGitLab:
 		if err := setupGitLab(ctx); err != nil {
@@ -86,6 +102,39 @@ func (p Pipe) Default(ctx *context.Context) error {
 	return nil
 }
 
+// Validate checks the release section.
+func (Pipe) Validate(ctx *context.Context) error {
+	var errs []error
+	switch ctx.TokenType {
+	case context.TokenTypeGitLab:
+		// the name might be the project id, so the owner is optional
+		if ctx.Config.Release.GitLab.Name == "" {
+			errs = append(errs, pipe.FieldErrorf("release.gitlab.name", "required"))
+		}
+	case context.TokenTypeGitea:
+		errs = append(errs, validateRepo("release.gitea", ctx.Config.Release.Gitea)...)
+	default:
+		errs = append(errs, validateRepo("release.github", ctx.Config.Release.GitHub)...)
+	}
+	for i, pattern := range ctx.Config.Release.Branches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, pipe.FieldErrorf(fmt.Sprintf("release.branches[%d]", i), "invalid pattern %q", pattern))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateRepo(field string, repo config.Repo) []error {
+	var errs []error
+	if repo.Owner == "" {
+		errs = append(errs, pipe.FieldErrorf(field+".owner", "required"))
+	}
+	if repo.Name == "" {
+		errs = append(errs, pipe.FieldErrorf(field+".name", "required"))
+	}
+	return errs
+}
+
 func getRepository(ctx *context.Context) (config.Repo, error) {
 	repo, err := git.ExtractRepoFromConfig(ctx)
 	if err != nil {
@@ -99,6 +148,13 @@ func getRepository(ctx *context.Context) (config.Repo, error) {
 
 // Publish the release.
 func (Pipe) Publish(ctx *context.Context) error {
+	allowed, err := git.MatchReleaseBranch(ctx, ctx.Git.Branch, ctx.Config.Release.Branches)
+	if err != nil {
+		return fmt.Errorf("release: %w", err)
+	}
+	if !allowed {
+		return pipe.SkipWithf(pipe.SkipDisabled, "release is disabled on branch %q", ctx.Git.Branch)
+	}
 	c, err := client.New(ctx)
 	if err != nil {
 		return err
@@ -181,12 +237,30 @@ func doPublish(ctx *context.Context, client client.Client) error {
 		})
 	}
 	if err := g.Wait(); err != nil {
-		return err
+		return errors.Join(err, deleteRelease(ctx, client, releaseID))
 	}
 
 	return client.PublishRelease(ctx, releaseID)
 }
 
+// deleteRelease deletes the release after a failed upload, if the user asked
+// for it and the client supports it.
+func deleteRelease(ctx *context.Context, cli client.Client, releaseID string) error {
+	if !ctx.Config.Release.DeleteOnFailure {
+		return nil
+	}
+	deleter, ok := cli.(client.ReleaseDeleter)
+	if !ok {
+		log.Warn("deleting the release on failure is not supported by this client")
+		return nil
+	}
+	log.WithField("release-id", releaseID).Warn("upload failed, deleting the release")
+	if err := deleter.DeleteRelease(ctx, releaseID); err != nil {
+		return fmt.Errorf("failed to delete release: %w", err)
+	}
+	return nil
+}
+
 func upload(ctx *context.Context, cli client.Client, releaseID string, artifact *artifact.Artifact) error {
 	var try int
 	tryUpload := func() error {
@@ -213,11 +287,24 @@ func upload(ctx *context.Context, cli client.Client, releaseID string, artifact
 		if err == nil {
 			return nil
 		}
-		if errors.As(err, &client.RetriableError{}) {
-			time.Sleep(time.Duration(try*50) * time.Millisecond)
-			continue
+		var rerr client.RetriableError
+		if !errors.As(err, &rerr) {
+			break
+		}
+		wait := rerr.RetryAfter
+		if wait <= 0 {
+			wait = time.Duration(try*50) * time.Millisecond
+		}
+		if rerr.RetryAfter > 0 {
+			log.WithField("artifact", artifact.Name).
+				WithField("wait", wait).
+				Warn("rate limited, waiting before retrying")
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to upload %s after %d tries: %w", artifact.Name, try, ctx.Err())
+		case <-time.After(wait):
 		}
-		break
 	}
 
 	return fmt.Errorf("failed to upload %s after %d tries: %w", artifact.Name, try, err)
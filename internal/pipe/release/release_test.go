@@ -1,13 +1,20 @@
 package release
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/client"
+	gitpipe "github.com/goreleaser/goreleaser/internal/pipe/git"
 	"github.com/goreleaser/goreleaser/internal/testctx"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pkg/config"
@@ -280,6 +287,44 @@ func TestRunPipeUploadFailure(t *testing.T) {
 	require.False(t, client.ReleasePublished)
 }
 
+func TestRunPipeUploadFailureDeleteOnFailure(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		deleteOnFailure bool
+		existing        bool
+		deleted         bool
+	}{
+		{"created", true, false, true},
+		{"pre-existing", true, true, false},
+		{"disabled", false, false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := t.TempDir()
+			ctx := testctx.NewWithCfg(config.Project{
+				Release: config.Release{
+					GitHub: config.Repo{
+						Owner: "test",
+						Name:  "test",
+					},
+					DeleteOnFailure: tt.deleteOnFailure,
+				},
+			}, testctx.WithCurrentTag("v1.0.0"))
+			ctx.Artifacts.Add(&artifact.Artifact{
+				Type: artifact.UploadableArchive,
+				Name: "bin.tar.gz",
+				Path: createTmpFile(t, folder, "bin.tar.gz"),
+			})
+			client := &client.Mock{
+				FailToUpload:    true,
+				ExistingRelease: tt.existing,
+			}
+			require.EqualError(t, doPublish(ctx, client), "failed to upload bin.tar.gz after 1 tries: upload failed")
+			require.False(t, client.ReleasePublished)
+			require.Equal(t, tt.deleted, client.DeletedRelease)
+		})
+	}
+}
+
 func TestRunPipeExtraFileNotFound(t *testing.T) {
 	config := config.Project{
 		Release: config.Release{
@@ -349,6 +394,284 @@ func TestRunPipeUploadRetry(t *testing.T) {
 	require.True(t, client.ReleasePublished)
 }
 
+// newGitHubPublishContext returns a context with the given assets, releasing
+// to a fake GitHub API which handles asset uploads with the given handler.
+func newGitHubPublishContext(tb testing.TB, assets []string, uploadAsset http.HandlerFunc) *context.Context {
+	tb.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		switch {
+		case r.URL.Path == "/rate_limit":
+			fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+		case r.URL.Path == "/repos/test/test/releases/tags/v1.0.0":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/repos/test/test/releases" && r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"id": 1}`)
+		case r.URL.Path == "/repos/test/test/releases/1" && r.Method == http.MethodPatch:
+			fmt.Fprint(w, `{"id": 1}`)
+		case r.URL.Path == "/repos/test/test/releases/1/assets" && r.Method == http.MethodPost:
+			uploadAsset(w, r)
+		default:
+			tb.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+		}
+	}))
+	tb.Cleanup(srv.Close)
+
+	folder := tb.TempDir()
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist: folder,
+			GitHubURLs: config.GitHubURLs{
+				API:    srv.URL + "/",
+				Upload: srv.URL + "/",
+			},
+			Release: config.Release{
+				GitHub: config.Repo{
+					Owner: "test",
+					Name:  "test",
+				},
+			},
+		},
+		testctx.GitHubTokenType,
+		testctx.WithCurrentTag("v1.0.0"),
+		func(ctx *context.Context) { ctx.Token = "test-token" },
+	)
+	for _, name := range assets {
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Type: artifact.UploadableArchive,
+			Name: name,
+			Path: createTmpFile(tb, folder, name),
+		})
+	}
+	return ctx
+}
+
+func TestRunPipeUploadRateLimited(t *testing.T) {
+	var lock sync.Mutex
+	uploads := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		switch {
+		case r.URL.Path == "/rate_limit":
+			fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+		case r.URL.Path == "/repos/test/test/releases/tags/v1.0.0":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/repos/test/test/releases" && r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"id": 1}`)
+		case r.URL.Path == "/repos/test/test/releases/1" && r.Method == http.MethodPatch:
+			fmt.Fprint(w, `{"id": 1}`)
+		case r.URL.Path == "/repos/test/test/releases/1/assets" && r.Method == http.MethodPost:
+			name := r.URL.Query().Get("name")
+			lock.Lock()
+			uploads[name]++
+			try := uploads[name]
+			lock.Unlock()
+			if name == "bin.tar.gz" && try == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit.","documentation_url":"https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id": %d, "name": %q}`, len(uploads), name)
+		default:
+			t.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist: folder,
+			GitHubURLs: config.GitHubURLs{
+				API:    srv.URL + "/",
+				Upload: srv.URL + "/",
+			},
+			Release: config.Release{
+				GitHub: config.Repo{
+					Owner: "test",
+					Name:  "test",
+				},
+			},
+		},
+		testctx.GitHubTokenType,
+		testctx.WithCurrentTag("v1.0.0"),
+		func(ctx *context.Context) { ctx.Token = "test-token" },
+	)
+	for _, name := range []string{"bin.tar.gz", "bin.deb", "bin.rpm"} {
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Type: artifact.UploadableArchive,
+			Name: name,
+			Path: createTmpFile(t, folder, name),
+		})
+	}
+	cli, err := client.New(ctx)
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, doPublish(ctx, cli))
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+	require.Equal(t, map[string]int{
+		"bin.tar.gz": 2,
+		"bin.deb":    1,
+		"bin.rpm":    1,
+	}, uploads)
+}
+
+func TestRunPipeUploadParallelism(t *testing.T) {
+	for _, parallelism := range []int{1, 3} {
+		t.Run(fmt.Sprintf("parallelism=%d", parallelism), func(t *testing.T) {
+			var running, maxRunning atomic.Int32
+			var lock sync.Mutex
+			var order []string
+			assets := []string{"a.tar.gz", "b.tar.gz", "c.tar.gz", "d.tar.gz", "e.tar.gz", "f.tar.gz"}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				switch {
+				case r.URL.Path == "/rate_limit":
+					fmt.Fprint(w, `{"resources":{"core":{"remaining":120}}}`)
+				case r.URL.Path == "/repos/test/test/releases/tags/v1.0.0":
+					w.WriteHeader(http.StatusNotFound)
+				case r.URL.Path == "/repos/test/test/releases" && r.Method == http.MethodPost:
+					fmt.Fprint(w, `{"id": 1}`)
+				case r.URL.Path == "/repos/test/test/releases/1" && r.Method == http.MethodPatch:
+					fmt.Fprint(w, `{"id": 1}`)
+				case r.URL.Path == "/repos/test/test/releases/1/assets" && r.Method == http.MethodPost:
+					n := running.Add(1)
+					defer running.Add(-1)
+					for {
+						m := maxRunning.Load()
+						if n <= m || maxRunning.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					name := r.URL.Query().Get("name")
+					lock.Lock()
+					order = append(order, name)
+					id := len(order)
+					lock.Unlock()
+					time.Sleep(20 * time.Millisecond)
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprintf(w, `{"id": %d, "name": %q}`, id, name)
+				default:
+					t.Error("unhandled request: " + r.Method + " " + r.URL.Path)
+				}
+			}))
+			defer srv.Close()
+
+			folder := t.TempDir()
+			ctx := testctx.NewWithCfg(
+				config.Project{
+					Dist: folder,
+					GitHubURLs: config.GitHubURLs{
+						API:    srv.URL + "/",
+						Upload: srv.URL + "/",
+					},
+					Release: config.Release{
+						GitHub: config.Repo{
+							Owner: "test",
+							Name:  "test",
+						},
+					},
+				},
+				testctx.GitHubTokenType,
+				testctx.WithCurrentTag("v1.0.0"),
+				func(ctx *context.Context) { ctx.Token = "test-token" },
+			)
+			for _, name := range assets {
+				ctx.Artifacts.Add(&artifact.Artifact{
+					Type: artifact.UploadableArchive,
+					Name: name,
+					Path: createTmpFile(t, folder, name),
+				})
+			}
+			ctx.Parallelism = parallelism
+			cli, err := client.New(ctx)
+			require.NoError(t, err)
+
+			require.NoError(t, doPublish(ctx, cli))
+			require.ElementsMatch(t, assets, order)
+			require.LessOrEqual(t, int(maxRunning.Load()), parallelism)
+			if parallelism == 1 {
+				require.Equal(t, assets, order, "sequential uploads keep the artifacts order")
+			}
+		})
+	}
+}
+
+func TestPublishBranches(t *testing.T) {
+	for _, tt := range []struct {
+		branch  string
+		allowed bool
+	}{
+		{"main", true},
+		{"release/v1", true},
+		{"feature", false},
+	} {
+		t.Run(tt.branch, func(t *testing.T) {
+			testlib.Mktmp(t)
+			testlib.GitInit(t)
+			testlib.GitRemoteAdd(t, "git@github.com:test/test.git")
+			testlib.GitCommit(t, "first")
+			testlib.GitTag(t, "v1.0.0")
+			if tt.branch != "main" { // GitInit already created main
+				testlib.GitCheckoutBranch(t, tt.branch)
+			}
+
+			var uploads atomic.Int32
+			ctx := newGitHubPublishContext(t, []string{"bin.tar.gz"}, func(w http.ResponseWriter, _ *http.Request) {
+				uploads.Add(1)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id": 1}`)
+			})
+			ctx.Config.Release.Branches = []string{"main", "release/*"}
+			require.NoError(t, gitpipe.Pipe{}.Run(ctx))
+			require.Equal(t, tt.branch, ctx.Git.Branch)
+
+			err := Pipe{}.Publish(ctx)
+			if !tt.allowed {
+				testlib.AssertSkipped(t, err)
+				require.Zero(t, uploads.Load())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, int32(1), uploads.Load())
+		})
+	}
+
+	t.Run("detached tag", func(t *testing.T) {
+		testlib.Mktmp(t)
+		testlib.GitInit(t)
+		testlib.GitRemoteAdd(t, "git@github.com:test/test.git")
+		testlib.GitCommit(t, "first")
+		testlib.GitTag(t, "v1.0.0")
+		testlib.GitCheckoutDetached(t, "v1.0.0")
+
+		var uploads atomic.Int32
+		ctx := newGitHubPublishContext(t, []string{"bin.tar.gz"}, func(w http.ResponseWriter, _ *http.Request) {
+			uploads.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1}`)
+		})
+		ctx.Config.Release.Branches = []string{"main"}
+		require.NoError(t, gitpipe.Pipe{}.Run(ctx))
+		require.Equal(t, "HEAD", ctx.Git.Branch)
+
+		require.NoError(t, Pipe{}.Publish(ctx))
+		require.Equal(t, int32(1), uploads.Load())
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: config.Release{
+				Branches: []string{"[main"},
+			},
+		}, testctx.WithGitInfo(context.GitInfo{Branch: "main"}))
+		require.EqualError(t, Pipe{}.Publish(ctx), `release: invalid branch pattern "[main": syntax error in pattern`)
+	})
+}
+
 func TestDefault(t *testing.T) {
 	testlib.Mktmp(t)
 	testlib.GitInit(t)
@@ -624,6 +947,64 @@ func TestDefaultMultipleReleasesDefined(t *testing.T) {
 	require.EqualError(t, Pipe{}.Default(ctx), ErrMultipleReleases.Error())
 }
 
+func TestDefaultAssetsMode(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		release config.Release
+		want    config.ReleaseAssetsMode
+	}{
+		{"default", config.Release{}, config.ReleaseAssetsModeFail},
+		{"replace existing artifacts", config.Release{ReplaceExistingArtifacts: true}, config.ReleaseAssetsModeReplace},
+		{"keep", config.Release{AssetsMode: config.ReleaseAssetsModeKeep}, config.ReleaseAssetsModeKeep},
+		{"explicit wins", config.Release{AssetsMode: config.ReleaseAssetsModeFail, ReplaceExistingArtifacts: true}, config.ReleaseAssetsModeFail},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			release := tt.release
+			release.GitHub = config.Repo{
+				Name:  "foo",
+				Owner: "bar",
+			}
+			ctx := testctx.NewWithCfg(config.Project{Release: release}, testctx.GitHubTokenType)
+			require.NoError(t, Pipe{}.Default(ctx))
+			require.Equal(t, tt.want, ctx.Config.Release.AssetsMode)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: config.Release{
+				AssetsMode: "merge",
+			},
+		}, testctx.GitHubTokenType)
+		require.EqualError(t, Pipe{}.Default(ctx), "invalid release.assets_mode 'merge', valid options are 'fail', 'replace' and 'keep'")
+	})
+
+	for name, tokenType := range map[string]testctx.Opt{
+		"gitlab": testctx.GitLabTokenType,
+		"gitea":  testctx.GiteaTokenType,
+	} {
+		t.Run("keep on "+name, func(t *testing.T) {
+			ctx := testctx.NewWithCfg(config.Project{
+				Release: config.Release{
+					AssetsMode: config.ReleaseAssetsModeKeep,
+				},
+			}, tokenType)
+			require.EqualError(t, Pipe{}.Default(ctx), "release.assets_mode 'keep' is only supported on GitHub")
+		})
+	}
+
+	t.Run("replace existing artifacts on gitlab", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: config.Release{
+				GitLab:                   config.Repo{Name: "foo", Owner: "bar"},
+				ReplaceExistingArtifacts: true,
+			},
+		}, testctx.GitLabTokenType)
+		require.NoError(t, Pipe{}.Default(ctx))
+		require.Equal(t, config.ReleaseAssetsModeReplace, ctx.Config.Release.AssetsMode)
+	})
+}
+
 func TestSkip(t *testing.T) {
 	t.Run("skip", func(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
@@ -648,12 +1029,23 @@ func TestSkip(t *testing.T) {
 		require.True(t, b)
 	})
 
-	t.Run("tmpl err", func(t *testing.T) {
+	t.Run("tmpl missing env", func(t *testing.T) {
 		ctx := testctx.NewWithCfg(config.Project{
 			Release: config.Release{
 				Disable: "{{ .Env.FOO }}",
 			},
 		})
+		b, err := Pipe{}.Skip(ctx)
+		require.NoError(t, err)
+		require.False(t, b)
+	})
+
+	t.Run("tmpl err", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: config.Release{
+				Disable: "{{ .Foo }}",
+			},
+		})
 		_, err := Pipe{}.Skip(ctx)
 		require.Error(t, err)
 	})
@@ -45,7 +45,7 @@ func TestSetupGitLab(t *testing.T) {
 				Release: config.Release{
 					GitLab: config.Repo{
 						Name:  "foo",
-						Owner: "{{.Env.NOPE}}",
+						Owner: "{{.Nope}}",
 					},
 				},
 			})
@@ -57,7 +57,7 @@ func TestSetupGitLab(t *testing.T) {
 			ctx := testctx.NewWithCfg(config.Project{
 				Release: config.Release{
 					GitLab: config.Repo{
-						Name: "{{.Env.NOPE}}",
+						Name: "{{.Nope}}",
 					},
 				},
 			})
@@ -101,7 +101,7 @@ func TestSetupGitea(t *testing.T) {
 				Release: config.Release{
 					Gitea: config.Repo{
 						Name:  "foo",
-						Owner: "{{.Env.NOPE}}",
+						Owner: "{{.Nope}}",
 					},
 				},
 			})
@@ -113,7 +113,7 @@ func TestSetupGitea(t *testing.T) {
 			ctx := testctx.NewWithCfg(config.Project{
 				Release: config.Release{
 					Gitea: config.Repo{
-						Name: "{{.Env.NOPE}}",
+						Name: "{{.Nope}}",
 					},
 				},
 			})
@@ -157,7 +157,7 @@ func TestSetupGitHub(t *testing.T) {
 				Release: config.Release{
 					GitHub: config.Repo{
 						Name:  "foo",
-						Owner: "{{.Env.NOPE}}",
+						Owner: "{{.Nope}}",
 					},
 				},
 			})
@@ -169,7 +169,7 @@ func TestSetupGitHub(t *testing.T) {
 			ctx := testctx.NewWithCfg(config.Project{
 				Release: config.Release{
 					GitHub: config.Repo{
-						Name: "{{.Env.NOPE}}",
+						Name: "{{.Nope}}",
 					},
 				},
 			})
@@ -418,6 +418,43 @@ func TestSBOMCatalogArtifacts(t *testing.T) {
 			}),
 			expectedErrMsg: "cataloging artifacts: command did not write any files, check your configuration",
 		},
+		{
+			desc: "stub generator per binary",
+			ctx: testctx.NewWithCfg(config.Project{
+				SBOMs: []config.SBOM{
+					{
+						Artifacts: "binary",
+						Cmd:       "sh",
+						Args:      []string{"-c", "echo sbom of $artifact > $document"},
+						Documents: []string{"{{ .Binary }}_{{ .Os }}_{{ .Arch }}.cdx.json"},
+					},
+				},
+			}),
+			sbomPaths: []string{
+				"artifact3-name_linux_amd64.cdx.json",
+				"artifact4-name_linux_amd64.cdx.json",
+			},
+			sbomNames: []string{
+				"artifact3-name_linux_amd64.cdx.json",
+				"artifact4-name_linux_amd64.cdx.json",
+			},
+		},
+		{
+			desc: "stub generator combined",
+			ctx: testctx.NewWithCfg(config.Project{
+				SBOMs: []config.SBOM{
+					{
+						Artifacts: "any",
+						Cmd:       "sh",
+						Args:      []string{"-c", "echo sbom > $document"},
+						Documents: []string{"{{ .ProjectName }}.spdx.json"},
+					},
+				},
+				ProjectName: "proj",
+			}),
+			sbomPaths: []string{"proj.spdx.json"},
+			sbomNames: []string{"proj.spdx.json"},
+		},
 		{
 			desc: "no matches",
 			ctx: testctx.NewWithCfg(config.Project{
@@ -450,7 +487,11 @@ func testSBOMCataloging(
 	expectedErrMsg string,
 ) {
 	tb.Helper()
-	testlib.CheckPath(tb, "syft")
+	for _, cfg := range ctx.Config.SBOMs {
+		if cfg.Cmd == "" || cfg.Cmd == "syft" {
+			testlib.CheckPath(tb, "syft")
+		}
+	}
 	tmpdir := tb.TempDir()
 
 	ctx.Config.Dist = tmpdir
@@ -1,6 +1,9 @@
 package scoop
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -659,7 +662,7 @@ func Test_doRun(t *testing.T) {
 						ProjectName: "run-pipe",
 						Scoop: config.Scoop{
 							Repository: config.RepoRef{
-								Owner: "{{ .Env.aaaaaa }}",
+								Owner: "{{ .Nope }}",
 								Name:  "test",
 							},
 							Folder:      "scoops",
@@ -714,6 +717,39 @@ func Test_doRun(t *testing.T) {
 				require.Equal(tb, "scoops/run-pipe.json", a.client.Path)
 			},
 		},
+		{
+			"ref templ missing env",
+			args{
+				testctx.NewWithCfg(
+					config.Project{
+						ProjectName: "run-pipe",
+						Scoops: []config.Scoop{{
+							Repository: config.RepoRef{
+								Owner:  "test",
+								Name:   "test",
+								Branch: "{{ .Env.BRANCH }}",
+							},
+							Folder:      "scoops",
+							Description: "A run pipe test formula",
+							Homepage:    "https://github.com/goreleaser",
+						}},
+					},
+					testctx.GitHubTokenType,
+					testctx.WithCurrentTag("v1.0.1"),
+					testctx.WithVersion("1.0.1"),
+				),
+				client.NewMock(),
+			},
+			[]artifact.Artifact{
+				{Name: "foo_1.0.1_windows_amd64.tar.gz", Goos: "windows", Goarch: "amd64", Goamd64: "v1", Path: file},
+			},
+			shouldNotErr,
+			shouldNotErr,
+			func(tb testing.TB, a args) {
+				tb.Helper()
+				require.Equal(tb, "scoops/run-pipe.json", a.client.Path)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1125,3 +1161,103 @@ func TestSkip(t *testing.T) {
 		require.False(t, Pipe{}.Skip(ctx))
 	})
 }
+
+func TestRunPipeURLsAndHashes(t *testing.T) {
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist:        folder,
+			ProjectName: "foo",
+			Scoops: []config.Scoop{
+				{
+					Repository: config.RepoRef{
+						Owner: "foo",
+						Name:  "scoop-bucket",
+					},
+					Shortcuts: [][]string{{"foo.exe", "Foo"}},
+				},
+			},
+		},
+		testctx.WithCurrentTag("v1.2.3"),
+		testctx.WithVersion("1.2.3"),
+	)
+	sums := map[string]string{}
+	for _, target := range []struct{ goos, goarch string }{
+		{"windows", "amd64"},
+		{"windows", "386"},
+		{"linux", "amd64"},
+	} {
+		name := fmt.Sprintf("foo_%s_%s.zip", target.goos, target.goarch)
+		path := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+		sums[name] = fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+		ctx.Artifacts.Add(&artifact.Artifact{
+			Name:    name,
+			Path:    path,
+			Goos:    target.goos,
+			Goarch:  target.goarch,
+			Goamd64: "v1",
+			Type:    artifact.UploadableArchive,
+			Extra: map[string]interface{}{
+				artifact.ExtraID:       "foo",
+				artifact.ExtraFormat:   "zip",
+				artifact.ExtraBinaries: []string{"foo.exe"},
+			},
+		})
+	}
+
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, runAll(ctx, client.NewMock()))
+
+	bts, err := os.ReadFile(filepath.Join(folder, "scoop", "foo.json"))
+	require.NoError(t, err)
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(bts, &manifest))
+	require.Equal(t, "1.2.3", manifest.Version)
+	require.Equal(t, [][]string{{"foo.exe", "Foo"}}, manifest.Shortcuts)
+	require.Equal(t, map[string]Resource{
+		"64bit": {
+			URL:  "https://dummyhost/download/v1.2.3/foo_windows_amd64.zip",
+			Bin:  []string{"foo.exe"},
+			Hash: sums["foo_windows_amd64.zip"],
+		},
+		"32bit": {
+			URL:  "https://dummyhost/download/v1.2.3/foo_windows_386.zip",
+			Bin:  []string{"foo.exe"},
+			Hash: sums["foo_windows_386.zip"],
+		},
+	}, manifest.Architecture)
+}
+
+func TestRunPipeNoWindowsArchives(t *testing.T) {
+	folder := t.TempDir()
+	ctx := testctx.NewWithCfg(
+		config.Project{
+			Dist:        folder,
+			ProjectName: "foo",
+			Scoops: []config.Scoop{
+				{
+					Repository: config.RepoRef{
+						Owner: "foo",
+						Name:  "scoop-bucket",
+					},
+				},
+			},
+		},
+		testctx.WithCurrentTag("v1.2.3"),
+		testctx.WithVersion("1.2.3"),
+	)
+	ctx.Artifacts.Add(&artifact.Artifact{
+		Name:    "foo_linux_amd64.tar.gz",
+		Path:    filepath.Join(folder, "foo_linux_amd64.tar.gz"),
+		Goos:    "linux",
+		Goarch:  "amd64",
+		Goamd64: "v1",
+		Type:    artifact.UploadableArchive,
+	})
+
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.ErrorAs(t, runAll(ctx, client.NewMock()), &ErrIncorrectArchiveCount{})
+	require.NoFileExists(t, filepath.Join(folder, "scoop", "foo.json"))
+	require.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.ScoopManifest)).List())
+}
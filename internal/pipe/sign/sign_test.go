@@ -790,3 +790,122 @@ func setGpg(tb testing.TB, ctx *context.Context, p string) {
 	_, err := git.Run(ctx, "config", "--local", "--add", "gpg.program", p)
 	require.NoError(tb, err)
 }
+
+func TestSignWithFakeSigner(t *testing.T) {
+	folder := t.TempDir()
+	signer := filepath.Join(folder, "signer.sh")
+	stdin := "{{ .Env.SIGN_PASSPHRASE }}"
+	require.NoError(t, os.WriteFile(signer, []byte("#!/bin/sh\nprintf 'signed with %s: ' \"$(cat)\" > \"$1\"\ncat \"$2\" >> \"$1\"\n"), 0o755))
+
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist: folder,
+		Env:  []string{"SIGN_PASSPHRASE=secret"},
+		Signs: []config.Sign{
+			{
+				Cmd:       signer,
+				Args:      []string{"${signature}", "${artifact}"},
+				Artifacts: "all",
+				Stdin:     &stdin,
+			},
+		},
+	})
+	for name, typ := range map[string]artifact.Type{
+		"foo.tar.gz":    artifact.UploadableArchive,
+		"bar.zip":       artifact.UploadableArchive,
+		"checksums.txt": artifact.Checksum,
+	} {
+		path := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+		ctx.Artifacts.Add(&artifact.Artifact{Name: name, Path: path, Type: typ})
+	}
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	signatures := ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List()
+	var names []string
+	for _, sig := range signatures {
+		names = append(names, sig.Name)
+		bts, err := os.ReadFile(sig.Path)
+		require.NoError(t, err)
+		require.Equal(t, "signed with secret: "+strings.TrimSuffix(sig.Name, ".sig"), string(bts))
+	}
+	require.ElementsMatch(t, []string{"foo.tar.gz.sig", "bar.zip.sig", "checksums.txt.sig"}, names)
+}
+
+func TestSignChecksumsWithCosign(t *testing.T) {
+	folder := t.TempDir()
+	bin := t.TempDir()
+	invocation := filepath.Join(folder, "invocation")
+	require.NoError(t, os.WriteFile(
+		filepath.Join(bin, "cosign"),
+		[]byte("#!/bin/sh\necho \"$@\" > "+invocation+"\nfor arg in \"$@\"; do\n  case \"$arg\" in\n    --output-certificate=*) echo cert > \"${arg#*=}\" ;;\n    --output-signature=*) echo sig > \"${arg#*=}\" ;;\n  esac\ndone\n"),
+		0o755,
+	))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist: folder,
+		Signs: []config.Sign{
+			{
+				ID:          "cosign",
+				Cmd:         "cosign",
+				Artifacts:   "checksum",
+				Certificate: "${artifact}.pem",
+				Args: []string{
+					"sign-blob",
+					"--output-certificate=${certificate}",
+					"--output-signature=${signature}",
+					"${artifact}",
+					"--yes",
+				},
+			},
+		},
+	})
+	path := filepath.Join(folder, "checksums.txt")
+	require.NoError(t, os.WriteFile(path, []byte("checksums"), 0o644))
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "checksums.txt", Path: path, Type: artifact.Checksum})
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "foo.tar.gz", Path: filepath.Join(folder, "foo.tar.gz"), Type: artifact.UploadableArchive})
+
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+
+	bts, err := os.ReadFile(invocation)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf(
+		"sign-blob --output-certificate=%[1]s.pem --output-signature=%[1]s.sig %[1]s --yes\n",
+		path,
+	), string(bts))
+
+	sigs := ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List()
+	require.Len(t, sigs, 1)
+	require.Equal(t, "checksums.txt.sig", sigs[0].Name)
+	require.FileExists(t, sigs[0].Path)
+	require.Equal(t, "cosign", sigs[0].ID())
+
+	certs := ctx.Artifacts.Filter(artifact.ByType(artifact.Certificate)).List()
+	require.Len(t, certs, 1)
+	require.Equal(t, "checksums.txt.pem", certs[0].Name)
+	require.FileExists(t, certs[0].Path)
+	require.Equal(t, "cosign", certs[0].ID())
+}
+
+func TestSignCmdNotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	folder := t.TempDir()
+	path := filepath.Join(folder, "checksums.txt")
+	require.NoError(t, os.WriteFile(path, []byte("checksums"), 0o644))
+	ctx := testctx.NewWithCfg(config.Project{
+		Dist: folder,
+		Signs: []config.Sign{
+			{
+				Cmd:       "cosign",
+				Artifacts: "checksum",
+			},
+		},
+	})
+	ctx.Artifacts.Add(&artifact.Artifact{Name: "checksums.txt", Path: path, Type: artifact.Checksum})
+	require.NoError(t, Pipe{}.Default(ctx))
+	err := Pipe{}.Run(ctx)
+	require.ErrorIs(t, err, exec.ErrNotFound)
+	require.ErrorContains(t, err, "make sure it is installed and in your $PATH")
+}
@@ -3,6 +3,8 @@ package slack
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/goreleaser/goreleaser/internal/testctx"
@@ -71,6 +73,46 @@ func TestAnnounceWithQuotes(t *testing.T) {
 	})
 }
 
+func TestAnnounceMockWebhook(t *testing.T) {
+	var msg slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	t.Setenv("SLACK_WEBHOOK", srv.URL)
+
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "foo",
+		Announce: config.Announce{
+			Slack: config.Slack{
+				Channel: "#releases",
+			},
+		},
+	}, testctx.WithCurrentTag("v1.0.0"))
+	ctx.ReleaseURL = "https://github.com/foo/foo/releases/tag/v1.0.0"
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Announce(ctx))
+	require.Equal(t, "foo v1.0.0 is out! Check it out at https://github.com/foo/foo/releases/tag/v1.0.0", msg.Text)
+	require.Equal(t, "GoReleaser", msg.Username)
+	require.Equal(t, "#releases", msg.Channel)
+}
+
+func TestAnnounceMockWebhookFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+	t.Setenv("SLACK_WEBHOOK", srv.URL)
+
+	ctx := testctx.New()
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.ErrorContains(t, Pipe{}.Announce(ctx), "slack: ")
+}
+
 func TestAnnounceMissingEnv(t *testing.T) {
 	ctx := testctx.NewWithCfg(config.Project{
 		Announce: config.Announce{
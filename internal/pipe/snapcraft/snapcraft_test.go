@@ -509,11 +509,20 @@ func TestDefaultGradeTmpl(t *testing.T) {
 	require.Equal(t, "devel", ctx.Config.Snapcrafts[0].Grade)
 }
 
-func TestDefaultGradeTmplError(t *testing.T) {
+func TestDefaultGradeTmplMissingEnv(t *testing.T) {
 	ctx := testctx.NewWithCfg(config.Project{
 		Builds:     []config.Build{{ID: "foo"}},
 		Snapcrafts: []config.Snapcraft{{Grade: "{{.Env.Grade}}"}},
 	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.Equal(t, "stable", ctx.Config.Snapcrafts[0].Grade)
+}
+
+func TestDefaultGradeTmplError(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Builds:     []config.Build{{ID: "foo"}},
+		Snapcrafts: []config.Snapcraft{{Grade: "{{.Grade}}"}},
+	})
 	testlib.RequireTemplateError(t, Pipe{}.Default(ctx))
 }
 
@@ -3,9 +3,11 @@ package snapshot
 import (
 	"testing"
 
+	"github.com/goreleaser/goreleaser/internal/pipe/git"
 	"github.com/goreleaser/goreleaser/internal/testctx"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/goreleaser/goreleaser/pkg/context"
 	"github.com/stretchr/testify/require"
 )
 
@@ -69,3 +71,36 @@ func TestSkip(t *testing.T) {
 		require.False(t, Pipe{}.Skip(ctx))
 	})
 }
+
+func TestSnapshotUntaggedRepo(t *testing.T) {
+	testlib.Mktmp(t)
+	testlib.GitInit(t)
+	testlib.GitRemoteAdd(t, "git@github.com:foo/bar.git")
+	testlib.GitCommit(t, "first")
+	ctx := testctx.NewWithCfg(config.Project{
+		Snapshot: config.Snapshot{
+			NameTemplate: "{{ .Tag }}-SNAPSHOT-{{ .ShortCommit }}",
+		},
+	}, testctx.Snapshot)
+	testlib.AssertSkipped(t, git.Pipe{}.Run(ctx))
+	require.Equal(t, "v0.0.0", ctx.Git.CurrentTag)
+	require.NotEmpty(t, ctx.Git.ShortCommit)
+
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+	require.Equal(t, "v0.0.0-SNAPSHOT-"+ctx.Git.ShortCommit, ctx.Version)
+}
+
+func TestSnapshotDefaultNameTemplate(t *testing.T) {
+	ctx := testctx.New(
+		testctx.Snapshot,
+		testctx.WithVersion("1.2.3"),
+		testctx.WithGitInfo(context.GitInfo{
+			CurrentTag:  "v1.2.3",
+			ShortCommit: "abc1234",
+		}),
+	)
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Run(ctx))
+	require.Equal(t, "1.2.3-SNAPSHOT-abc1234", ctx.Version)
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	h "net/http"
 
+	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/http"
 	"github.com/goreleaser/goreleaser/internal/pipe"
+	"github.com/goreleaser/goreleaser/pkg/config"
 	"github.com/goreleaser/goreleaser/pkg/context"
 )
 
@@ -17,11 +19,19 @@ type Pipe struct{}
 func (Pipe) String() string                 { return "http upload" }
 func (Pipe) Skip(ctx *context.Context) bool { return len(ctx.Config.Uploads) == 0 }
 
+// SupportsPlan tells the uploads can be planned with --print-plan.
+func (Pipe) SupportsPlan() bool { return true }
+
 // Default sets the pipe defaults.
 func (Pipe) Default(ctx *context.Context) error {
 	return http.Defaults(ctx.Config.Uploads)
 }
 
+// Validate checks all the upload sections.
+func (Pipe) Validate(ctx *context.Context) error {
+	return http.Validate(ctx, ctx.Config.Uploads, "upload", "uploads")
+}
+
 // Publish artifacts.
 func (Pipe) Publish(ctx *context.Context) error {
 	// Check requirements for every instance we have configured.
@@ -33,7 +43,7 @@ func (Pipe) Publish(ctx *context.Context) error {
 		}
 	}
 
-	return http.Upload(ctx, ctx.Config.Uploads, "upload", func(res *h.Response) error {
+	return http.Upload(ctx, ctx.Config.Uploads, "upload", func(res *h.Response, _ *config.Upload, _ *artifact.Artifact) error {
 		if c := res.StatusCode; c < 200 || 299 < c {
 			return fmt.Errorf("unexpected http response status: %s", res.Status)
 		}
@@ -0,0 +1,62 @@
+// Package validate provides a pipe that validates the configuration before
+// any work is done.
+package validate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/goreleaser/goreleaser/internal/middleware/skip"
+	"github.com/goreleaser/goreleaser/internal/pipe/artifactory"
+	"github.com/goreleaser/goreleaser/internal/pipe/build"
+	"github.com/goreleaser/goreleaser/internal/pipe/gitlabpackages"
+	"github.com/goreleaser/goreleaser/internal/pipe/release"
+	"github.com/goreleaser/goreleaser/internal/pipe/upload"
+	"github.com/goreleaser/goreleaser/internal/skips"
+	"github.com/goreleaser/goreleaser/pkg/context"
+)
+
+// Validator can check its configuration up front.
+type Validator interface {
+	fmt.Stringer
+
+	// Validate returns all the problems found in the configuration, if any.
+	Validate(ctx *context.Context) error
+}
+
+// Validators is the list of pipes validated before the release.
+// Publishers are only validated when publishing.
+// nolint: gochecknoglobals
+var Validators = []Validator{
+	build.Pipe{},
+	release.Pipe{},
+	artifactory.Pipe{},
+	upload.Pipe{},
+	gitlabpackages.Pipe{},
+}
+
+// publisher is implemented by the validators that publish artifacts.
+type publisher interface {
+	Publish(ctx *context.Context) error
+}
+
+// Pipe that validates the configuration.
+type Pipe struct{}
+
+func (Pipe) String() string { return "validating configuration" }
+
+// Run validates everything, reporting all the problems at once.
+func (Pipe) Run(ctx *context.Context) error {
+	publishing := ctx.Action != context.ActionBuild && !skips.Any(ctx, skips.Publish)
+	var errs []error
+	for _, validator := range Validators {
+		if _, ok := validator.(publisher); ok && !publishing {
+			continue
+		}
+		errs = append(errs, skip.Maybe(validator, validator.Validate)(ctx))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
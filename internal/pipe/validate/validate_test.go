@@ -0,0 +1,180 @@
+package validate
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/internal/pipe"
+	"github.com/goreleaser/goreleaser/internal/skips"
+	"github.com/goreleaser/goreleaser/internal/testctx"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/goreleaser/goreleaser/pkg/config"
+	"github.com/goreleaser/goreleaser/pkg/context"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescription(t *testing.T) {
+	require.NotEmpty(t, Pipe{}.String())
+}
+
+func TestRun(t *testing.T) {
+	release := config.Release{
+		GitHub: config.Repo{Owner: "goreleaser", Name: "goreleaser"},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: release,
+			Artifactories: []config.Upload{{
+				Name:     "production",
+				Target:   "https://example.com/{{ .ProjectName }}",
+				Username: "deployer",
+				Mode:     "archive",
+			}},
+		}, testctx.WithEnv(map[string]string{
+			"ARTIFACTORY_PRODUCTION_SECRET": "secret",
+		}))
+		require.NoError(t, Pipe{}.Run(ctx))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: release,
+			Artifactories: []config.Upload{
+				{
+					Name: "no-target",
+					Mode: "archive",
+				},
+				{
+					Name:     "no-secret",
+					Target:   "https://example.com",
+					Username: "deployer",
+					Mode:     "archive",
+				},
+				{
+					Name:          "bundle",
+					Target:        "https://example.com",
+					Mode:          "archive",
+					ReleaseBundle: config.ReleaseBundle{Name: "foo"},
+				},
+				{
+					Name:    "disabled",
+					Disable: "true",
+				},
+			},
+			Uploads: []config.Upload{
+				{
+					Target: "https://example.com",
+					Mode:   "archive",
+				},
+			},
+		})
+		err := Pipe{}.Run(ctx)
+		require.Error(t, err)
+		require.False(t, pipe.IsSkip(err))
+
+		require.Equal(t, []string{
+			"artifactories[0]",
+			"artifactories[1]",
+			"artifactories[2].release_bundle.url",
+			"uploads[0]",
+		}, fieldsOf(err))
+		require.EqualError(t, err, `invalid configuration:
+artifactories[0]: artifactory section 'no-target' is not configured properly (missing target)
+artifactories[1]: artifactory section 'no-secret' is not configured properly (environment variable 'ARTIFACTORY_NO-SECRET_SECRET' is required when 'username' is set)
+artifactories[2].release_bundle.url: required when release_bundle.name is set
+uploads[0]: upload section '' is not configured properly (missing name)`)
+	})
+
+	t.Run("skipped artifactory", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release:       release,
+			Artifactories: []config.Upload{{Name: "no-target"}},
+		}, testctx.Skip(skips.Artifactory))
+		require.NoError(t, Pipe{}.Run(ctx))
+	})
+
+	t.Run("builds and release", func(t *testing.T) {
+		testlib.Mktmp(t)
+		require.NoError(t, os.WriteFile("main.go", []byte("package main\nfunc main() {}\n"), 0o644))
+		ctx := testctx.NewWithCfg(config.Project{
+			Builds: []config.Build{
+				{Builder: "go", Binary: "ok", Dir: ".", Main: "."},
+				{Builder: "go", Dir: ".", Main: "./cmd/nope"},
+				{Builder: "go", Binary: "skipped", Dir: ".", Main: "./cmd/nope", Skip: true},
+				{Builder: "go", Binary: "no-check", Dir: ".", Main: "./cmd/nope", NoMainCheck: true},
+			},
+			Release: config.Release{
+				GitHub:   config.Repo{Owner: "goreleaser"},
+				Branches: []string{"main", "[release"},
+			},
+		})
+		err := Pipe{}.Run(ctx)
+		require.Equal(t, []string{
+			"builds[1].binary",
+			"builds[1].main",
+			"release.github.name",
+			"release.branches[1]",
+		}, fieldsOf(err))
+		require.EqualError(t, err, `invalid configuration:
+builds[1].binary: required
+builds[1].main: couldn't find main file: cmd/nope
+release.github.name: required
+release.branches[1]: invalid pattern "[release"`)
+	})
+
+	t.Run("gitlab packages", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release:        release,
+			GitLabPackages: []config.GitLabPackage{{Name: "production"}},
+		})
+		err := Pipe{}.Run(ctx)
+		require.Equal(t, []string{
+			"gitlab_packages[0].project_id",
+			"gitlab_packages[0]",
+		}, fieldsOf(err))
+		require.EqualError(t, err, `invalid configuration:
+gitlab_packages[0].project_id: required
+gitlab_packages[0]: missing GITLAB_TOKEN environment variable`)
+	})
+
+	t.Run("disabled release", func(t *testing.T) {
+		ctx := testctx.NewWithCfg(config.Project{
+			Release: config.Release{Disable: "true"},
+		})
+		require.NoError(t, Pipe{}.Run(ctx))
+	})
+
+	t.Run("not publishing", func(t *testing.T) {
+		for name, ctx := range map[string]*context.Context{
+			"skip publish": testctx.New(testctx.Skip(skips.Publish)),
+			"build":        testctx.New(func(ctx *context.Context) { ctx.Action = context.ActionBuild }),
+		} {
+			t.Run(name, func(t *testing.T) {
+				ctx.Config.Artifactories = []config.Upload{{Name: "no-target"}}
+				ctx.Config.Builds = []config.Build{{Builder: "go", Binary: "a", Dir: ".", Main: "./nope"}}
+				err := Pipe{}.Run(ctx)
+				require.Equal(t, []string{"builds[0].main"}, fieldsOf(err))
+			})
+		}
+	})
+}
+
+// fieldsOf returns the fields of all the FieldErrors in the given error tree.
+func fieldsOf(err error) []string {
+	var ferr pipe.FieldError
+	if errors.As(err, &ferr) && err.Error() == ferr.Error() {
+		return []string{ferr.Field}
+	}
+	var fields []string
+	switch err := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, err := range err.Unwrap() {
+			fields = append(fields, fieldsOf(err)...)
+		}
+	case interface{ Unwrap() error }:
+		fields = append(fields, fieldsOf(err.Unwrap())...)
+	}
+	return fields
+}
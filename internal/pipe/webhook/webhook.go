@@ -107,13 +107,12 @@ func (p Pipe) Announce(ctx *context.Context) error {
 	}
 	defer resp.Body.Close()
 
-	switch resp.StatusCode {
-	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent:
-		log.Infof("Post OK: '%v'", resp.StatusCode)
-		body, _ := io.ReadAll(resp.Body)
-		log.Infof("Response : %v\n", string(body))
-		return nil
-	default:
-		return fmt.Errorf("request failed with status %v", resp.Status)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook: unexpected http response status: %s: %s", resp.Status, strings.TrimSpace(string(body)))
 	}
+	log.Infof("Post OK: '%v'", resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	log.Infof("Response : %v\n", string(body))
+	return nil
 }
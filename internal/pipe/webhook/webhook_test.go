@@ -89,6 +89,52 @@ func TestAnnounceWebhook(t *testing.T) {
 	require.NoError(t, Pipe{}.Announce(ctx))
 }
 
+func TestAnnounceWebhookDefaultPayload(t *testing.T) {
+	var payload map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.Equal(t, DefaultContentType, r.Header.Get(ContentTypeHeaderKey))
+		require.Equal(t, UserAgentHeaderValue, r.Header.Get(UserAgentHeaderKey))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx := testctx.NewWithCfg(config.Project{
+		ProjectName: "foo",
+		Announce: config.Announce{
+			Webhook: config.Webhook{
+				EndpointURL: srv.URL,
+			},
+		},
+	}, testctx.WithCurrentTag("v1.0.0"))
+	ctx.ReleaseURL = "https://github.com/foo/foo/releases/tag/v1.0.0"
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.NoError(t, Pipe{}.Announce(ctx))
+	require.Equal(t, map[string]string{
+		"message": "foo v1.0.0 is out! Check it out at https://github.com/foo/foo/releases/tag/v1.0.0",
+	}, payload)
+}
+
+func TestAnnounceWebhookFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintln(w, "upstream is down")
+	}))
+	defer srv.Close()
+
+	ctx := testctx.NewWithCfg(config.Project{
+		Announce: config.Announce{
+			Webhook: config.Webhook{
+				EndpointURL: srv.URL,
+			},
+		},
+	})
+	require.NoError(t, Pipe{}.Default(ctx))
+	require.EqualError(t, Pipe{}.Announce(ctx), "webhook: unexpected http response status: 502 Bad Gateway: upstream is down")
+}
+
 func TestAnnounceTLSWebhook(t *testing.T) {
 	responseServer := WebHookServerMockMessage{
 		Response: "Thanks for the announcement!",
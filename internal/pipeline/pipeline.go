@@ -4,6 +4,7 @@ package pipeline
 import (
 	"fmt"
 
+	"github.com/goreleaser/goreleaser/internal/pipe/after"
 	"github.com/goreleaser/goreleaser/internal/pipe/announce"
 	"github.com/goreleaser/goreleaser/internal/pipe/archive"
 	"github.com/goreleaser/goreleaser/internal/pipe/aur"
@@ -38,6 +39,7 @@ import (
 	"github.com/goreleaser/goreleaser/internal/pipe/sourcearchive"
 	"github.com/goreleaser/goreleaser/internal/pipe/universalbinary"
 	"github.com/goreleaser/goreleaser/internal/pipe/upx"
+	"github.com/goreleaser/goreleaser/internal/pipe/validate"
 	"github.com/goreleaser/goreleaser/internal/pipe/winget"
 	"github.com/goreleaser/goreleaser/pkg/context"
 )
@@ -61,6 +63,8 @@ var BuildPipeline = []Piper{
 	semver.Pipe{},
 	// load default configs
 	defaults.Pipe{},
+	// validate the configuration up front
+	validate.Pipe{},
 	// setup things for partial builds/releases
 	partial.Pipe{},
 	// snapshot version handling
@@ -145,4 +149,6 @@ var Pipeline = append(
 	metadata.ArtifactsPipe{},
 	// announce releases
 	announce.Pipe{},
+	// run global hooks after the release
+	after.Pipe{},
 )
@@ -3,6 +3,7 @@
 package semerrgroup
 
 import (
+	"runtime"
 	"sync"
 
 	"github.com/goreleaser/goreleaser/internal/pipe"
@@ -17,7 +18,12 @@ type Group interface {
 }
 
 // New returns a new Group of a given size.
+//
+// A size of 0 or less defaults to the number of CPUs.
 func New(size int) Group {
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
 	var g errgroup.Group
 	g.SetLimit(size)
 	return &g
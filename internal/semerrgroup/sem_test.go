@@ -2,7 +2,9 @@ package semerrgroup
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -32,6 +34,36 @@ func TestSemaphore(t *testing.T) {
 	}
 }
 
+func TestSemaphoreBounded(t *testing.T) {
+	for _, size := range []int{-1, 0, 1, 3} {
+		t.Run(fmt.Sprintf("size-%d", size), func(t *testing.T) {
+			limit := size
+			if limit <= 0 {
+				limit = runtime.NumCPU()
+			}
+			g := New(size)
+			var running, maxRunning atomic.Int32
+			for i := 0; i < 10; i++ {
+				g.Go(func() error {
+					n := running.Add(1)
+					defer running.Add(-1)
+					for {
+						m := maxRunning.Load()
+						if n <= m || maxRunning.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					time.Sleep(10 * time.Millisecond)
+					return nil
+				})
+			}
+			require.NoError(t, g.Wait())
+			require.LessOrEqual(t, int(maxRunning.Load()), limit)
+			require.Positive(t, maxRunning.Load())
+		})
+	}
+}
+
 func TestSemaphoreOrder(t *testing.T) {
 	num := 10
 	g := New(1)
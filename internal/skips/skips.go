@@ -23,6 +23,7 @@ const (
 	Ko             Key = "ko"
 	Docker         Key = "docker"
 	Before         Key = "before"
+	After          Key = "after"
 	Winget         Key = "winget"
 	Snapcraft      Key = "snapcraft"
 	Scoop          Key = "scoop"
@@ -32,6 +33,7 @@ const (
 	NFPM           Key = "nfpm"
 	Chocolatey     Key = "chocolatey"
 	Notarize       Key = "notarize"
+	Artifactory    Key = "artifactory"
 )
 
 func String(ctx *context.Context) string {
@@ -115,7 +117,9 @@ var Release = Keys{
 	AUR,
 	NFPM,
 	Before,
+	After,
 	Notarize,
+	Artifactory,
 }
 
 var Build = Keys{
@@ -38,3 +38,18 @@ func TestSet(t *testing.T) {
 	skips.Set(ctx, skips.Publish, skips.Announce)
 	require.ElementsMatch(t, []string{"publish", "announce"}, maps.Keys(ctx.Skips))
 }
+
+func TestSetRelease(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		ctx := testctx.New()
+		require.NoError(t, skips.SetRelease(ctx, "artifactory", "docker"))
+		require.True(t, skips.Any(ctx, skips.Artifactory))
+		require.True(t, skips.Any(ctx, skips.Docker))
+		require.False(t, skips.Any(ctx, skips.Publish))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := testctx.New()
+		require.ErrorContains(t, skips.SetRelease(ctx, "pre-hooks"), "--skip=pre-hooks is not allowed")
+	})
+}
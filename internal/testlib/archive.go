@@ -8,6 +8,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
 	"github.com/ulikunitz/xz"
 )
@@ -22,6 +23,8 @@ func GetFileFromArchive(tb testing.TB, path, format, filename string) []byte {
 		return catTarFile(tb, openGzip(tb, f), filename)
 	case "tar.xz", "txz":
 		return catTarFile(tb, openXz(tb, f), filename)
+	case "tar.zst", "tzst":
+		return catTarFile(tb, openZstd(tb, f), filename)
 	case "tar":
 		return catTarFile(tb, f, filename)
 	case "zip":
@@ -45,6 +48,8 @@ func LsArchive(tb testing.TB, path, format string) []string {
 		return doLsTar(openGzip(tb, f))
 	case "tar.xz", "txz":
 		return doLsTar(openXz(tb, f))
+	case "tar.zst", "tzst":
+		return doLsTar(openZstd(tb, f))
 	case "tar":
 		return doLsTar(f)
 	case "zip":
@@ -71,6 +76,14 @@ func openXz(tb testing.TB, r io.Reader) *xz.Reader {
 	return xz
 }
 
+func openZstd(tb testing.TB, r io.Reader) io.Reader {
+	tb.Helper()
+	zr, err := zstd.NewReader(r)
+	require.NoError(tb, err)
+	tb.Cleanup(zr.Close)
+	return zr
+}
+
 func catZipFile(tb testing.TB, f *os.File, path string) []byte {
 	tb.Helper()
 
@@ -62,6 +62,14 @@ func GitAnnotatedTag(tb testing.TB, tag, message string) {
 	require.Empty(tb, out)
 }
 
+// GitSignedTag creates a signed annotated tag using the given gpg key.
+func GitSignedTag(tb testing.TB, tag, message, key string) {
+	tb.Helper()
+	out, err := fakeGit("-c", "user.signingkey="+key, "tag", "-s", tag, "-m", message)
+	require.NoError(tb, err)
+	require.Empty(tb, out)
+}
+
 // GitBranch creates a git branch.
 func GitBranch(tb testing.TB, branch string) {
 	tb.Helper()
@@ -98,6 +106,14 @@ func GitCheckoutBranch(tb testing.TB, name string) {
 	require.Empty(tb, out)
 }
 
+// GitCheckoutDetached checks out the given ref in detached HEAD mode, as CI
+// jobs building a tag usually do.
+func GitCheckoutDetached(tb testing.TB, ref string) {
+	tb.Helper()
+	_, err := fakeGit("checkout", "--detach", ref)
+	require.NoError(tb, err)
+}
+
 func GitMakeBareRepository(tb testing.TB) string {
 	tb.Helper()
 	dir := tb.TempDir()
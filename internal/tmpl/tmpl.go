@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"text/template/parse"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -20,7 +21,8 @@ import (
 
 // Template holds data that can be applied to a template string.
 type Template struct {
-	fields Fields
+	fields    Fields
+	strictEnv bool
 }
 
 // Fields that will be available to the template engine.
@@ -131,7 +133,8 @@ func New(ctx *context.Context) *Template {
 	}
 
 	return &Template{
-		fields: fields,
+		fields:    fields,
+		strictEnv: ctx.Config.StrictEnv,
 	}
 }
 
@@ -239,10 +242,117 @@ func (t *Template) Apply(s string) (string, error) {
 		return "", newTmplError(s, err)
 	}
 
-	err = tmpl.Execute(&out, t.fields)
+	err = tmpl.Execute(&out, t.fieldsFor(tmpl))
 	return out.String(), newTmplError(s, err)
 }
 
+// fieldsFor returns the fields to execute the given template with.
+// Unless strict_env is set, environment variables referenced by the template
+// but not set render as empty strings.
+func (t *Template) fieldsFor(tmpl *template.Template) Fields {
+	if t.strictEnv {
+		return t.fields
+	}
+	var current map[string]string
+	switch e := t.fields[env].(type) {
+	case context.Env:
+		current = e
+	case map[string]string:
+		current = e
+	}
+	var missing []string
+	for _, key := range envKeys(tmpl) {
+		if _, ok := current[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return t.fields
+	}
+
+	merged := make(map[string]string, len(current)+len(missing))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for _, k := range missing {
+		merged[k] = ""
+	}
+	fields := make(Fields, len(t.fields))
+	for k, v := range t.fields {
+		fields[k] = v
+	}
+	if _, ok := t.fields[env].(context.Env); ok {
+		fields[env] = context.Env(merged)
+	} else {
+		fields[env] = merged
+	}
+	return fields
+}
+
+// envKeys returns the keys of all the .Env.KEY references in the given
+// template.
+func envKeys(tmpl *template.Template) []string {
+	var keys []string
+	add := func(ident []string) {
+		if len(ident) > 1 && ident[0] == env {
+			keys = append(keys, ident[1])
+		}
+	}
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			add(n.Ident)
+		case *parse.VariableNode:
+			if len(n.Ident) > 0 && n.Ident[0] == "$" {
+				add(n.Ident[1:])
+			}
+		}
+	}
+	for _, tt := range tmpl.Templates() {
+		if tt.Tree != nil {
+			walk(tt.Tree.Root)
+		}
+	}
+	return keys
+}
+
 // ApplyAll applies all the given strings against the Fields stored in the
 // template. Application stops as soon as an error is encountered.
 func (t *Template) ApplyAll(sps ...*string) error {
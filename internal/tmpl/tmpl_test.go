@@ -379,11 +379,52 @@ func TestInvalidTemplate(t *testing.T) {
 func TestEnvNotFound(t *testing.T) {
 	ctx := testctx.New(testctx.WithCurrentTag("v1.2.4"))
 	result, err := New(ctx).Apply("{{.Env.FOO}}")
+	require.NoError(t, err)
+	require.Empty(t, result)
+}
+
+func TestEnvNotFoundStrict(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{StrictEnv: true}, testctx.WithCurrentTag("v1.2.4"))
+	result, err := New(ctx).Apply("{{.Env.FOO}}")
 	require.Empty(t, result)
 	require.ErrorAs(t, err, &Error{})
 	require.EqualError(t, err, `template: failed to apply "{{.Env.FOO}}": map has no entry for key "FOO"`)
 }
 
+func TestEnvMissingKeys(t *testing.T) {
+	ctx := testctx.NewWithCfg(config.Project{
+		Env: []string{"BUILD_NUMBER=42"},
+	})
+	for tmpl, expected := range map[string]string{
+		"{{ .Env.BUILD_NUMBER }}":                                      "42",
+		"{{ .Env.BUILD_NUMBER }}-{{ .Env.PIPELINE_ID }}":               "42-",
+		"{{ if .Env.PIPELINE_ID }}set{{ else }}unset{{ end }}":         "unset",
+		"{{ with .Env.PIPELINE_ID }}{{ . }}{{ else }}none{{ end }}":    "none",
+		`{{ range split "a,b" "," }}{{ . }}{{ $.Env.NOPE }}{{ end }}`:  "ab",
+		`{{ tolower .Env.NOPE }}{{ .Env.BUILD_NUMBER }}`:               "42",
+		`{{ define "x" }}{{ .Env.NOPE }}{{ end }}{{ template "x" . }}`: "",
+		`{{ isEnvSet "PIPELINE_ID" }}`:                                 "false",
+		`{{ envOrDefault "PIPELINE_ID" "default" }}`:                   "default",
+	} {
+		t.Run(tmpl, func(t *testing.T) {
+			result, err := New(ctx).Apply(tmpl)
+			require.NoError(t, err)
+			require.Equal(t, expected, result)
+		})
+	}
+
+	t.Run("with env", func(t *testing.T) {
+		result, err := New(ctx).WithEnvS([]string{"FOO=bar"}).Apply("{{ .Env.FOO }}{{ .Env.NOPE }}")
+		require.NoError(t, err)
+		require.Equal(t, "bar", result)
+	})
+
+	t.Run("other fields are still strict", func(t *testing.T) {
+		_, err := New(ctx).Apply("{{ .Nope }}")
+		require.ErrorAs(t, err, &Error{})
+	})
+}
+
 func TestWithExtraFields(t *testing.T) {
 	ctx := testctx.New()
 	out, _ := New(ctx).WithExtraFields(Fields{
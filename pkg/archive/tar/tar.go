@@ -54,6 +54,9 @@ func (a Archive) Close() error {
 }
 
 // Add file to the archive.
+//
+// The entries are owned by the user and group with ID 0, and have empty user
+// and group names, unless the file info sets an owner or a group.
 func (a Archive) Add(f config.File) error {
 	if _, ok := a.files[f.Destination]; ok {
 		return &fs.PathError{Err: fs.ErrExist, Path: f.Destination, Op: "add"}
@@ -81,14 +84,10 @@ func (a Archive) Add(f config.File) error {
 	if f.Info.Mode != 0 {
 		header.Mode = int64(f.Info.Mode)
 	}
-	if f.Info.Owner != "" {
-		header.Uid = 0
-		header.Uname = f.Info.Owner
-	}
-	if f.Info.Group != "" {
-		header.Gid = 0
-		header.Gname = f.Info.Group
-	}
+	// the owner of the files on the machine creating the archive is
+	// meaningless elsewhere, and would make the archive not reproducible.
+	header.Uid, header.Uname = 0, f.Info.Owner
+	header.Gid, header.Gname = 0, f.Info.Group
 	if err = a.tw.WriteHeader(header); err != nil {
 		return fmt.Errorf("%s: %w", f.Source, err)
 	}
@@ -88,6 +88,10 @@ func TestTarFile(t *testing.T) {
 		}
 		require.NoError(t, err)
 		paths = append(paths, next.Name)
+		require.Zero(t, next.Uid, next.Name)
+		require.Zero(t, next.Gid, next.Name)
+		require.Empty(t, next.Uname, next.Name)
+		require.Empty(t, next.Gname, next.Name)
 		if next.Name == "sub1/executable" {
 			ex := next.FileInfo().Mode()&0o111 != 0
 			require.True(t, ex, "expected executable permissions, got %s", next.FileInfo().Mode())
@@ -523,6 +523,8 @@ type Build struct {
 	Command         string          `yaml:"command,omitempty" json:"command,omitempty"`
 	NoUniqueDistDir bool            `yaml:"no_unique_dist_dir,omitempty" json:"no_unique_dist_dir,omitempty"`
 	NoMainCheck     bool            `yaml:"no_main_check,omitempty" json:"no_main_check,omitempty"`
+	Trimpath        bool            `yaml:"trimpath,omitempty" json:"trimpath,omitempty"`
+	Strip           bool            `yaml:"strip,omitempty" json:"strip,omitempty"`
 	UnproxiedMain   string          `yaml:"-" json:"-"` // used by gomod.proxy
 	UnproxiedDir    string          `yaml:"-" json:"-"` // used by gomod.proxy
 
@@ -633,7 +635,7 @@ func (bh Hook) JSONSchema() *jsonschema.Schema {
 // FormatOverride is used to specify a custom format for a specific GOOS.
 type FormatOverride struct {
 	Goos   string `yaml:"goos,omitempty" json:"goos,omitempty"`
-	Format string `yaml:"format,omitempty" json:"format,omitempty" jsonschema:"enum=tar,enum=tgz,enum=tar.gz,enum=zip,enum=gz,enum=tar.xz,enum=txz,enum=binary,enum=none,default=tar.gz"`
+	Format string `yaml:"format,omitempty" json:"format,omitempty" jsonschema:"enum=tar,enum=tgz,enum=tar.gz,enum=zip,enum=gz,enum=tar.xz,enum=txz,enum=tar.zst,enum=tzst,enum=binary,enum=none,default=tar.gz"`
 }
 
 // File is a file inside an archive.
@@ -642,6 +644,7 @@ type File struct {
 	Destination string   `yaml:"dst,omitempty" json:"dst,omitempty"`
 	StripParent bool     `yaml:"strip_parent,omitempty" json:"strip_parent,omitempty"`
 	Info        FileInfo `yaml:"info,omitempty" json:"info,omitempty"`
+	If          string   `yaml:"if,omitempty" json:"if,omitempty"`
 	Default     bool     `yaml:"-" json:"-"`
 }
 
@@ -717,7 +720,7 @@ type Archive struct {
 	Builds                    []string         `yaml:"builds,omitempty" json:"builds,omitempty"`
 	BuildsInfo                FileInfo         `yaml:"builds_info,omitempty" json:"builds_info,omitempty"`
 	NameTemplate              string           `yaml:"name_template,omitempty" json:"name_template,omitempty"`
-	Format                    string           `yaml:"format,omitempty" json:"format,omitempty" jsonschema:"enum=tar,enum=tgz,enum=tar.gz,enum=zip,enum=gz,enum=tar.xz,enum=txz,enum=binary,default=tar.gz"`
+	Format                    string           `yaml:"format,omitempty" json:"format,omitempty" jsonschema:"enum=tar,enum=tgz,enum=tar.gz,enum=zip,enum=gz,enum=tar.xz,enum=txz,enum=tar.zst,enum=tzst,enum=binary,default=tar.gz"`
 	FormatOverrides           []FormatOverride `yaml:"format_overrides,omitempty" json:"format_overrides,omitempty"`
 	WrapInDirectory           string           `yaml:"wrap_in_directory,omitempty" json:"wrap_in_directory,omitempty" jsonschema:"oneof_type=string;boolean"`
 	StripBinaryDirectory      bool             `yaml:"strip_binary_directory,omitempty" json:"strip_binary_directory,omitempty"`
@@ -741,6 +744,16 @@ const (
 	ReleaseNotesModePrepend      ReleaseNotesMode = "prepend"
 )
 
+// ReleaseAssetsMode is what to do when an asset being uploaded already
+// exists in the release.
+type ReleaseAssetsMode string
+
+const (
+	ReleaseAssetsModeFail    ReleaseAssetsMode = "fail"
+	ReleaseAssetsModeReplace ReleaseAssetsMode = "replace"
+	ReleaseAssetsModeKeep    ReleaseAssetsMode = "keep"
+)
+
 // Release config used for the GitHub/GitLab release.
 type Release struct {
 	GitHub                 Repo        `yaml:"github,omitempty" json:"github,omitempty"`
@@ -760,9 +773,13 @@ type Release struct {
 	Header                 string      `yaml:"header,omitempty" json:"header,omitempty"`
 	Footer                 string      `yaml:"footer,omitempty" json:"footer,omitempty"`
 
-	ReleaseNotesMode         ReleaseNotesMode `yaml:"mode,omitempty" json:"mode,omitempty" jsonschema:"enum=keep-existing,enum=append,enum=prepend,enum=replace,default=keep-existing"`
-	ReplaceExistingArtifacts bool             `yaml:"replace_existing_artifacts,omitempty" json:"replace_existing_artifacts,omitempty"`
-	IncludeMeta              bool             `yaml:"include_meta,omitempty" json:"include_meta,omitempty"`
+	ReleaseNotesMode         ReleaseNotesMode  `yaml:"mode,omitempty" json:"mode,omitempty" jsonschema:"enum=keep-existing,enum=append,enum=prepend,enum=replace,default=keep-existing"`
+	ReplaceExistingArtifacts bool              `yaml:"replace_existing_artifacts,omitempty" json:"replace_existing_artifacts,omitempty"`
+	AssetsMode               ReleaseAssetsMode `yaml:"assets_mode,omitempty" json:"assets_mode,omitempty" jsonschema:"enum=fail,enum=replace,enum=keep,default=fail"`
+	DeleteOnFailure          bool              `yaml:"delete_on_failure,omitempty" json:"delete_on_failure,omitempty"`
+	Branches                 []string          `yaml:"branches,omitempty" json:"branches,omitempty"`
+	IncludeMeta              bool              `yaml:"include_meta,omitempty" json:"include_meta,omitempty"`
+	RequireSignedTag         bool              `yaml:"require_signed_tag,omitempty" json:"require_signed_tag,omitempty"`
 }
 
 // Milestone config used for VCS milestone.
@@ -1063,12 +1080,14 @@ type Snapshot struct {
 
 // Checksum config.
 type Checksum struct {
-	NameTemplate string      `yaml:"name_template,omitempty" json:"name_template,omitempty"`
-	Algorithm    string      `yaml:"algorithm,omitempty" json:"algorithm,omitempty"`
-	Split        bool        `yaml:"split,omitempty" json:"split,omitempty"`
-	IDs          []string    `yaml:"ids,omitempty" json:"ids,omitempty"`
-	Disable      bool        `yaml:"disable,omitempty" json:"disable,omitempty"`
-	ExtraFiles   []ExtraFile `yaml:"extra_files,omitempty" json:"extra_files,omitempty"`
+	NameTemplate     string      `yaml:"name_template,omitempty" json:"name_template,omitempty"`
+	Algorithm        string      `yaml:"algorithm,omitempty" json:"algorithm,omitempty"`
+	Algorithms       []string    `yaml:"algorithms,omitempty" json:"algorithms,omitempty"`
+	Split            bool        `yaml:"split,omitempty" json:"split,omitempty"`
+	DisableAggregate bool        `yaml:"disable_aggregate,omitempty" json:"disable_aggregate,omitempty"`
+	IDs              []string    `yaml:"ids,omitempty" json:"ids,omitempty"`
+	Disable          bool        `yaml:"disable,omitempty" json:"disable,omitempty"`
+	ExtraFiles       []ExtraFile `yaml:"extra_files,omitempty" json:"extra_files,omitempty"`
 }
 
 // Docker image config.
@@ -1129,9 +1148,10 @@ type ChangelogGroup struct {
 // EnvFiles holds paths to files that contains environment variables
 // values like the github token for example.
 type EnvFiles struct {
-	GitHubToken string `yaml:"github_token,omitempty" json:"github_token,omitempty"`
-	GitLabToken string `yaml:"gitlab_token,omitempty" json:"gitlab_token,omitempty"`
-	GiteaToken  string `yaml:"gitea_token,omitempty" json:"gitea_token,omitempty"`
+	GitHubToken string   `yaml:"github_token,omitempty" json:"github_token,omitempty"`
+	GitLabToken string   `yaml:"gitlab_token,omitempty" json:"gitlab_token,omitempty"`
+	GiteaToken  string   `yaml:"gitea_token,omitempty" json:"gitea_token,omitempty"`
+	DotEnv      []string `yaml:"dotenv,omitempty" json:"dotenv,omitempty"`
 }
 
 // Before config.
@@ -1139,6 +1159,29 @@ type Before struct {
 	Hooks []string `yaml:"hooks,omitempty" json:"hooks,omitempty"`
 }
 
+// Include is another configuration file merged into this one.
+type Include struct {
+	FromFile IncludeFromFile `yaml:"from_file,omitempty" json:"from_file,omitempty"`
+	FromURL  IncludeFromURL  `yaml:"from_url,omitempty" json:"from_url,omitempty"`
+}
+
+// IncludeFromFile includes a local file.
+type IncludeFromFile struct {
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+// IncludeFromURL includes a remote file.
+type IncludeFromURL struct {
+	URL     string            `yaml:"url,omitempty" json:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// After config.
+type After struct {
+	Hooks  []string `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	Always bool     `yaml:"always,omitempty" json:"always,omitempty"`
+}
+
 // Blob contains config for GO CDK blob.
 type Blob struct {
 	Bucket             string      `yaml:"bucket,omitempty" json:"bucket,omitempty"`
@@ -1169,22 +1212,118 @@ type Blob struct {
 
 // Upload configuration.
 type Upload struct {
-	Name               string            `yaml:"name,omitempty" json:"name,omitempty"`
-	IDs                []string          `yaml:"ids,omitempty" json:"ids,omitempty"`
-	Exts               []string          `yaml:"exts,omitempty" json:"exts,omitempty"`
-	Target             string            `yaml:"target,omitempty" json:"target,omitempty"`
-	Username           string            `yaml:"username,omitempty" json:"username,omitempty"`
-	Mode               string            `yaml:"mode,omitempty" json:"mode,omitempty"`
-	Method             string            `yaml:"method,omitempty" json:"method,omitempty"`
-	ChecksumHeader     string            `yaml:"checksum_header,omitempty" json:"checksum_header,omitempty"`
-	ClientX509Cert     string            `yaml:"client_x509_cert,omitempty" json:"client_x509_cert,omitempty"`
-	ClientX509Key      string            `yaml:"client_x509_key,omitempty" json:"client_x509_key,omitempty"`
-	TrustedCerts       string            `yaml:"trusted_certificates,omitempty" json:"trusted_certificates,omitempty"`
-	Checksum           bool              `yaml:"checksum,omitempty" json:"checksum,omitempty"`
-	Signature          bool              `yaml:"signature,omitempty" json:"signature,omitempty"`
-	Meta               bool              `yaml:"meta,omitempty" json:"meta,omitempty"`
-	CustomArtifactName bool              `yaml:"custom_artifact_name,omitempty" json:"custom_artifact_name,omitempty"`
-	CustomHeaders      map[string]string `yaml:"custom_headers,omitempty" json:"custom_headers,omitempty"`
+	Name                  string            `yaml:"name,omitempty" json:"name,omitempty"`
+	IDs                   []string          `yaml:"ids,omitempty" json:"ids,omitempty"`
+	Exts                  []string          `yaml:"exts,omitempty" json:"exts,omitempty"`
+	Target                string            `yaml:"target,omitempty" json:"target,omitempty"`
+	Layout                string            `yaml:"layout,omitempty" json:"layout,omitempty" jsonschema:"enum=simple,enum=go"`
+	Username              string            `yaml:"username,omitempty" json:"username,omitempty"`
+	SecretEnv             string            `yaml:"secret_env,omitempty" json:"secret_env,omitempty"`
+	AuthType              string            `yaml:"auth_type,omitempty" json:"auth_type,omitempty" jsonschema:"enum=basic,enum=apikey,enum=bearer,enum=gitlab,enum=gitlab-job,default=basic"`
+	Mode                  string            `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Method                string            `yaml:"method,omitempty" json:"method,omitempty"`
+	ChecksumHeader        string            `yaml:"checksum_header,omitempty" json:"checksum_header,omitempty"`
+	ChecksumDeploy        bool              `yaml:"checksum_deploy,omitempty" json:"checksum_deploy,omitempty"`
+	ClientX509Cert        string            `yaml:"client_x509_cert,omitempty" json:"client_x509_cert,omitempty"`
+	ClientX509Key         string            `yaml:"client_x509_key,omitempty" json:"client_x509_key,omitempty"`
+	TrustedCerts          string            `yaml:"trusted_certificates,omitempty" json:"trusted_certificates,omitempty"`
+	Proxy                 string            `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+	InsecureSkipVerify    bool              `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"`
+	Checksum              bool              `yaml:"checksum,omitempty" json:"checksum,omitempty"`
+	Signature             bool              `yaml:"signature,omitempty" json:"signature,omitempty"`
+	Meta                  bool              `yaml:"meta,omitempty" json:"meta,omitempty"`
+	SBOM                  bool              `yaml:"sbom,omitempty" json:"sbom,omitempty"`
+	CustomArtifactName    bool              `yaml:"custom_artifact_name,omitempty" json:"custom_artifact_name,omitempty"`
+	ContentType           string            `yaml:"content_type,omitempty" json:"content_type,omitempty"`
+	CustomHeaders         map[string]string `yaml:"custom_headers,omitempty" json:"custom_headers,omitempty"`
+	Properties            map[string]string `yaml:"properties,omitempty" json:"properties,omitempty"`
+	DialRetries           int               `yaml:"dial_retries,omitempty" json:"dial_retries,omitempty"`
+	Retries               int               `yaml:"retries,omitempty" json:"retries,omitempty"`
+	Timeout               time.Duration     `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	NameTransform         string            `yaml:"name_transform,omitempty" json:"name_transform,omitempty" jsonschema:"enum=lowercase,enum=slugify"`
+	BatchSmallFiles       UploadBatch       `yaml:"batch_small_files,omitempty" json:"batch_small_files,omitempty"`
+	AllowTargetCollisions bool              `yaml:"allow_target_collisions,omitempty" json:"allow_target_collisions,omitempty"`
+	Branches              []string          `yaml:"branches,omitempty" json:"branches,omitempty"`
+	TargetMapFile         string            `yaml:"target_map_file,omitempty" json:"target_map_file,omitempty"`
+	BufferForMirrors      bool              `yaml:"buffer_for_mirrors,omitempty" json:"buffer_for_mirrors,omitempty"`
+	MirrorBufferMaxSize   int64             `yaml:"mirror_buffer_max_size,omitempty" json:"mirror_buffer_max_size,omitempty"`
+	MinArtifacts          int               `yaml:"min_artifacts,omitempty" json:"min_artifacts,omitempty"`
+	RetryBudget           int               `yaml:"retry_budget,omitempty" json:"retry_budget,omitempty"`
+	VerifyRoundTrip       []string          `yaml:"verify_round_trip,omitempty" json:"verify_round_trip,omitempty"`
+	Priority              int               `yaml:"priority,omitempty" json:"priority,omitempty"`
+	PostUploadWait        UploadWait        `yaml:"post_upload_wait,omitempty" json:"post_upload_wait,omitempty"`
+	VerifyChecksums       *bool             `yaml:"verify_checksums,omitempty" json:"verify_checksums,omitempty"`
+	Lock                  UploadLock        `yaml:"lock,omitempty" json:"lock,omitempty"`
+	Critical              []string          `yaml:"critical,omitempty" json:"critical,omitempty"`
+	CriticalGracePeriod   time.Duration     `yaml:"critical_grace_period,omitempty" json:"critical_grace_period,omitempty"`
+	TagAfterPublish       string            `yaml:"tag_after_publish,omitempty" json:"tag_after_publish,omitempty"`
+	FailOnRedirect        bool              `yaml:"fail_on_redirect,omitempty" json:"fail_on_redirect,omitempty"`
+	PropertiesFromSidecar bool              `yaml:"properties_from_sidecar,omitempty" json:"properties_from_sidecar,omitempty"`
+	SidecarSuffix         string            `yaml:"sidecar_suffix,omitempty" json:"sidecar_suffix,omitempty"`
+	Disable               string            `yaml:"disable,omitempty" json:"disable,omitempty" jsonschema:"oneof_type=string;boolean"`
+	DryRun                bool              `yaml:"dry_run,omitempty" json:"dry_run,omitempty"`
+	Delta                 UploadDelta       `yaml:"delta,omitempty" json:"delta,omitempty"`
+	Trace                 bool              `yaml:"trace,omitempty" json:"trace,omitempty"`
+	ReleaseBundle         ReleaseBundle     `yaml:"release_bundle,omitempty" json:"release_bundle,omitempty"`
+}
+
+// GitLabPackage configures the upload of artifacts to a GitLab generic
+// package registry.
+type GitLabPackage struct {
+	Name        string   `yaml:"name,omitempty" json:"name,omitempty"`
+	URL         string   `yaml:"url,omitempty" json:"url,omitempty"`
+	ProjectID   string   `yaml:"project_id,omitempty" json:"project_id,omitempty"`
+	Package     string   `yaml:"package,omitempty" json:"package,omitempty"`
+	Version     string   `yaml:"version,omitempty" json:"version,omitempty"`
+	Mode        string   `yaml:"mode,omitempty" json:"mode,omitempty" jsonschema:"enum=archive,enum=binary,default=archive"`
+	IDs         []string `yaml:"ids,omitempty" json:"ids,omitempty"`
+	Exts        []string `yaml:"exts,omitempty" json:"exts,omitempty"`
+	Checksum    bool     `yaml:"checksum,omitempty" json:"checksum,omitempty"`
+	Signature   bool     `yaml:"signature,omitempty" json:"signature,omitempty"`
+	UseJobToken bool     `yaml:"use_job_token,omitempty" json:"use_job_token,omitempty"`
+	Disable     string   `yaml:"disable,omitempty" json:"disable,omitempty" jsonschema:"oneof_type=string;boolean"`
+}
+
+// UploadLock configures an advisory lock held while uploading.
+type UploadLock struct {
+	Path string        `yaml:"path,omitempty" json:"path,omitempty"`
+	TTL  time.Duration `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+}
+
+// UploadWait configures waiting for the server-side processing of each
+// uploaded artifact.
+type UploadWait struct {
+	URL      string        `yaml:"url,omitempty" json:"url,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// ArtifactoryDefaults are settings inherited by all the artifactories.
+type ArtifactoryDefaults struct {
+	Properties map[string]string `yaml:"properties,omitempty" json:"properties,omitempty"`
+}
+
+// ReleaseBundle configures the creation of an Artifactory release bundle
+// with the uploaded artifacts.
+type ReleaseBundle struct {
+	URL        string `yaml:"url,omitempty" json:"url,omitempty"`
+	Name       string `yaml:"name,omitempty" json:"name,omitempty"`
+	Version    string `yaml:"version,omitempty" json:"version,omitempty"`
+	SigningKey string `yaml:"signing_key,omitempty" json:"signing_key,omitempty"`
+}
+
+// UploadDelta configures the upload of binary patches against the previous
+// version of each artifact.
+type UploadDelta struct {
+	Previous string `yaml:"previous,omitempty" json:"previous,omitempty"`
+	Target   string `yaml:"target,omitempty" json:"target,omitempty"`
+}
+
+// UploadBatch configures the batching of small artifacts into a single
+// request.
+type UploadBatch struct {
+	Threshold int64 `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	Size      int   `yaml:"size,omitempty" json:"size,omitempty"`
 }
 
 // Publisher configuration.
@@ -1237,6 +1376,7 @@ type Project struct {
 	DockerManifests []DockerManifest `yaml:"docker_manifests,omitempty" json:"docker_manifests,omitempty"`
 	Artifactories   []Upload         `yaml:"artifactories,omitempty" json:"artifactories,omitempty"`
 	Uploads         []Upload         `yaml:"uploads,omitempty" json:"uploads,omitempty"`
+	GitLabPackages  []GitLabPackage  `yaml:"gitlab_packages,omitempty" json:"gitlab_packages,omitempty"`
 	Blobs           []Blob           `yaml:"blobs,omitempty" json:"blobs,omitempty"`
 	Publishers      []Publisher      `yaml:"publishers,omitempty" json:"publishers,omitempty"`
 	Changelog       Changelog        `yaml:"changelog,omitempty" json:"changelog,omitempty"`
@@ -1246,6 +1386,7 @@ type Project struct {
 	DockerSigns     []Sign           `yaml:"docker_signs,omitempty" json:"docker_signs,omitempty"`
 	EnvFiles        EnvFiles         `yaml:"env_files,omitempty" json:"env_files,omitempty"`
 	Before          Before           `yaml:"before,omitempty" json:"before,omitempty"`
+	After           After            `yaml:"after,omitempty" json:"after,omitempty"`
 	Source          Source           `yaml:"source,omitempty" json:"source,omitempty"`
 	GoMod           GoMod            `yaml:"gomod,omitempty" json:"gomod,omitempty"`
 	Announce        Announce         `yaml:"announce,omitempty" json:"announce,omitempty"`
@@ -1254,10 +1395,17 @@ type Project struct {
 	Git             Git              `yaml:"git,omitempty" json:"git,omitempty"`
 	ReportSizes     bool             `yaml:"report_sizes,omitempty" json:"report_sizes,omitempty"`
 	Metadata        ProjectMetadata  `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	Includes        []Include        `yaml:"includes,omitempty" json:"includes,omitempty"`
+	IncludesSlices  string           `yaml:"includes_slices,omitempty" json:"includes_slices,omitempty" jsonschema:"enum=replace,enum=append,default=replace"`
 
 	UniversalBinaries []UniversalBinary `yaml:"universal_binaries,omitempty" json:"universal_binaries,omitempty"`
 	UPXs              []UPX             `yaml:"upx,omitempty" json:"upx,omitempty"`
 
+	ArtifactoryDefaults ArtifactoryDefaults `yaml:"artifactory_defaults,omitempty" json:"artifactory_defaults,omitempty"`
+
+	// fail templates referencing environment variables that are not set
+	StrictEnv bool `yaml:"strict_env,omitempty" json:"strict_env,omitempty"`
+
 	// force the SCM token to use when multiple are set
 	ForceToken string `yaml:"force_token,omitempty" json:"force_token,omitempty" jsonschema:"enum=github,enum=gitlab,enum=gitea,enum=,default="`
 
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const includeBase = `project_name: base
+env:
+  - FOO=base
+checksum:
+  name_template: checksums.txt
+  algorithm: sha512
+artifactories:
+  - name: shared
+    target: https://artifacts.example.com/{{ .ProjectName }}
+    username: deployer
+`
+
+func writeInclude(tb testing.TB, name, content string) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), name)
+	require.NoError(tb, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadIncludes(t *testing.T) {
+	base := writeInclude(t, "base.yaml", includeBase)
+
+	t.Run("replace slices", func(t *testing.T) {
+		cfg, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+project_name: main
+env:
+  - FOO=main
+checksum:
+  algorithm: sha256
+`, base)))
+		require.NoError(t, err)
+		require.Equal(t, "main", cfg.ProjectName)
+		require.Equal(t, []string{"FOO=main"}, cfg.Env)
+		require.Equal(t, Checksum{
+			NameTemplate: "checksums.txt",
+			Algorithm:    "sha256",
+		}, cfg.Checksum)
+		require.Equal(t, []Upload{{
+			Name:     "shared",
+			Target:   "https://artifacts.example.com/{{ .ProjectName }}",
+			Username: "deployer",
+		}}, cfg.Artifactories)
+		require.Empty(t, cfg.Includes)
+	})
+
+	t.Run("append slices", func(t *testing.T) {
+		cfg, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+includes_slices: append
+env:
+  - FOO=main
+artifactories:
+  - name: local
+    target: https://local.example.com
+`, base)))
+		require.NoError(t, err)
+		require.Equal(t, "base", cfg.ProjectName)
+		require.Equal(t, []string{"FOO=base", "FOO=main"}, cfg.Env)
+		require.Len(t, cfg.Artifactories, 2)
+		require.Equal(t, "shared", cfg.Artifactories[0].Name)
+		require.Equal(t, "local", cfg.Artifactories[1].Name)
+		require.Empty(t, cfg.IncludesSlices)
+	})
+
+	t.Run("later includes win", func(t *testing.T) {
+		other := writeInclude(t, "other.yaml", "project_name: other\n")
+		cfg, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+  - from_file:
+      path: %s
+`, base, other)))
+		require.NoError(t, err)
+		require.Equal(t, "other", cfg.ProjectName)
+		require.Equal(t, "sha512", cfg.Checksum.Algorithm)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		middle := writeInclude(t, "middle.yaml", fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+dist: out
+`, base))
+		cfg, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+`, middle)))
+		require.NoError(t, err)
+		require.Equal(t, "base", cfg.ProjectName)
+		require.Equal(t, "out", cfg.Dist)
+	})
+
+	t.Run("relative to the including file", func(t *testing.T) {
+		folder := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(folder, "config", "shared"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(folder, "config", "shared", "base.yaml"), []byte(includeBase), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(folder, "config", "middle.yaml"), []byte(`
+includes:
+  - from_file:
+      path: shared/base.yaml
+dist: out
+`), 0o644))
+		path := filepath.Join(folder, "goreleaser.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+includes:
+  - from_file:
+      path: ./config/middle.yaml
+`), 0o644))
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		require.Equal(t, "base", cfg.ProjectName)
+		require.Equal(t, "out", cfg.Dist)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cycle.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+`, path)), 0o644))
+		_, err := Load(path)
+		require.ErrorContains(t, err, "too many nested includes")
+	})
+
+	t.Run("unknown field in include", func(t *testing.T) {
+		bad := writeInclude(t, "bad.yaml", "foo: bar\n")
+		_, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+`, bad)))
+		require.ErrorContains(t, err, "field foo not found in type config.Project")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadReader(strings.NewReader(`
+includes:
+  - from_file:
+      path: /nope/nope.yaml
+`))
+		require.ErrorContains(t, err, "includes:")
+	})
+
+	t.Run("invalid slices", func(t *testing.T) {
+		_, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+includes_slices: merge
+`, base)))
+		require.EqualError(t, err, "includes: invalid includes_slices 'merge', valid options are 'replace' and 'append'")
+	})
+
+	t.Run("both sources", func(t *testing.T) {
+		_, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_file:
+      path: %s
+    from_url:
+      url: https://example.com/base.yaml
+`, base)))
+		require.EqualError(t, err, "includes: only one of 'from_file' and 'from_url' can be set")
+	})
+
+	t.Run("no source", func(t *testing.T) {
+		_, err := LoadReader(strings.NewReader(`
+includes:
+  - {}
+`))
+		require.EqualError(t, err, "includes: either 'from_file' or 'from_url' must be set")
+	})
+}
+
+func TestLoadIncludesFromURL(t *testing.T) {
+	t.Setenv("GORELEASER_TEST_INCLUDE_TOKEN", "secret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, includeBase)
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Run("ok", func(t *testing.T) {
+		cfg, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_url:
+      url: %s/base.yaml
+      headers:
+        x-api-token: "${GORELEASER_TEST_INCLUDE_TOKEN}"
+`, srv.URL)))
+		require.NoError(t, err)
+		require.Equal(t, "base", cfg.ProjectName)
+	})
+
+	t.Run("bad status", func(t *testing.T) {
+		_, err := LoadReader(strings.NewReader(fmt.Sprintf(`
+includes:
+  - from_url:
+      url: %s/base.yaml
+`, srv.URL)))
+		require.ErrorContains(t, err, "unexpected status 401 Unauthorized")
+	})
+}
+
+func TestReadIncludeURL(t *testing.T) {
+	t.Setenv("GORELEASER_TEST_INCLUDE_TOKEN", "secret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/base.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("X-Api-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, includeBase)
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Run("ok", func(t *testing.T) {
+		bts, err := readIncludeURL(IncludeFromURL{
+			URL:     srv.URL + "/base.yaml",
+			Headers: map[string]string{"x-api-token": "${GORELEASER_TEST_INCLUDE_TOKEN}"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, includeBase, string(bts))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := readIncludeURL(IncludeFromURL{URL: srv.URL + "/base.yaml"})
+		require.EqualError(t, err, "includes: "+srv.URL+"/base.yaml: unexpected status 401 Unauthorized")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := readIncludeURL(IncludeFromURL{
+			URL:     srv.URL + "/nope.yaml",
+			Headers: map[string]string{"x-api-token": "secret"},
+		})
+		require.EqualError(t, err, "includes: "+srv.URL+"/nope.yaml: unexpected status 404 Not Found")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		_, err := readIncludeURL(IncludeFromURL{URL: closed.URL + "/base.yaml"})
+		require.ErrorContains(t, err, "includes: ")
+	})
+}
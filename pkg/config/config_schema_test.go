@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	bts, err := json.Marshal(JSONSchema())
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(bts, &schema))
+	require.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	require.Equal(t, "#/$defs/Project", schema["$ref"])
+
+	defs := schema["$defs"].(map[string]any)
+	require.Contains(t, defs, "FileInfo")
+	properties := func(def string) map[string]any {
+		t.Helper()
+		require.Contains(t, defs, def)
+		return defs[def].(map[string]any)["properties"].(map[string]any)
+	}
+
+	require.Equal(t, map[string]any{
+		"type":  "array",
+		"items": map[string]any{"$ref": "#/$defs/Upload"},
+	}, properties("Project")["artifactories"])
+
+	upload := properties("Upload")
+	require.Equal(t, map[string]any{"type": "string"}, upload["target"])
+	require.Equal(t, map[string]any{"type": "string"}, upload["username"])
+	require.Equal(t, map[string]any{"type": "boolean"}, upload["checksum"])
+	require.Equal(t, map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+	}, upload["properties"])
+	require.Equal(t, map[string]any{
+		"type":    "string",
+		"enum":    []any{"basic", "apikey", "bearer", "gitlab", "gitlab-job"},
+		"default": "basic",
+	}, upload["auth_type"])
+}
+
+func TestJSONSchemaIsStable(t *testing.T) {
+	first, err := json.Marshal(JSONSchema())
+	require.NoError(t, err)
+	second, err := json.Marshal(JSONSchema())
+	require.NoError(t, err)
+	require.JSONEq(t, string(first), string(second))
+}
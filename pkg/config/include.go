@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goreleaser/goreleaser/internal/yaml"
+)
+
+const (
+	includeSlicesReplace = "replace"
+	includeSlicesAppend  = "append"
+
+	// maxIncludeDepth prevents files including each other from looping forever.
+	maxIncludeDepth = 10
+)
+
+// resolveIncludes deep merges all the included files into the given config,
+// the config itself taking precedence over the included files, which take
+// precedence over the files included before them.
+// Slices are replaced, or appended to if includes_slices is 'append'.
+// Relative from_file paths are resolved against dir, the directory of the
+// including file.
+//
+// The config is returned as is if it has no includes.
+func resolveIncludes(data []byte, dir string, depth int) ([]byte, error) {
+	var project struct {
+		Includes       []Include `yaml:"includes"`
+		IncludesSlices string    `yaml:"includes_slices"`
+	}
+	if err := yaml.Unmarshal(data, &project); err != nil || len(project.Includes) == 0 {
+		// let the strict unmarshal report any error
+		return data, nil //nolint:nilerr
+	}
+	if depth >= maxIncludeDepth {
+		return nil, fmt.Errorf("includes: too many nested includes, do you have a cycle?")
+	}
+
+	var appendSlices bool
+	switch project.IncludesSlices {
+	case "", includeSlicesReplace:
+	case includeSlicesAppend:
+		appendSlices = true
+	default:
+		return nil, fmt.Errorf(
+			"includes: invalid includes_slices '%s', valid options are '%s' and '%s'",
+			project.IncludesSlices, includeSlicesReplace, includeSlicesAppend,
+		)
+	}
+
+	merged := map[string]any{}
+	for _, include := range project.Includes {
+		content, includeDir, err := readInclude(include, dir)
+		if err != nil {
+			return nil, err
+		}
+		content, err = resolveIncludes(content, includeDir, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		var included map[string]any
+		if err := yaml.Unmarshal(content, &included); err != nil {
+			return nil, fmt.Errorf("includes: %s: %w", includeName(include), err)
+		}
+		delete(included, "includes_slices")
+		merged = mergeMaps(merged, included, appendSlices)
+	}
+
+	var main map[string]any
+	if err := yaml.Unmarshal(data, &main); err != nil {
+		return nil, err
+	}
+	delete(main, "includes")
+	delete(main, "includes_slices")
+	return yaml.Marshal(mergeMaps(merged, main, appendSlices))
+}
+
+func includeName(include Include) string {
+	if include.FromURL.URL != "" {
+		return include.FromURL.URL
+	}
+	return include.FromFile.Path
+}
+
+// readInclude reads the given include, returning its content and the
+// directory its own includes are resolved against: the directory of the
+// file, or dir for URLs.
+func readInclude(include Include, dir string) ([]byte, string, error) {
+	switch {
+	case include.FromFile.Path != "" && include.FromURL.URL != "":
+		return nil, "", fmt.Errorf("includes: only one of 'from_file' and 'from_url' can be set")
+	case include.FromFile.Path != "":
+		path := include.FromFile.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		bts, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("includes: %w", err)
+		}
+		return bts, filepath.Dir(path), nil
+	case include.FromURL.URL != "":
+		bts, err := readIncludeURL(include.FromURL)
+		return bts, dir, err
+	default:
+		return nil, "", fmt.Errorf("includes: either 'from_file' or 'from_url' must be set")
+	}
+}
+
+func readIncludeURL(include IncludeFromURL) ([]byte, error) {
+	url := include.URL
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "https://raw.githubusercontent.com/" + url
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("includes: %w", err)
+	}
+	for k, v := range include.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("includes: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("includes: %s: unexpected status %s", url, resp.Status)
+	}
+	bts, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("includes: %s: %w", url, err)
+	}
+	return bts, nil
+}
+
+// mergeMaps deep merges src into dst, src taking precedence.
+// Slices in src replace the ones in dst, or are appended to them if
+// appendSlices is true.
+func mergeMaps(dst, src map[string]any, appendSlices bool) map[string]any {
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	for k, v := range src {
+		switch v := v.(type) {
+		case map[string]any:
+			if existing, ok := dst[k].(map[string]any); ok {
+				dst[k] = mergeMaps(existing, v, appendSlices)
+				continue
+			}
+		case []any:
+			if existing, ok := dst[k].([]any); ok && appendSlices {
+				dst[k] = append(existing, v...)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/goreleaser/goreleaser/internal/logext"
 	"github.com/goreleaser/goreleaser/internal/yaml"
@@ -30,11 +31,17 @@ func Load(file string) (config Project, err error) {
 		return
 	}
 	defer f.Close()
-	return LoadReader(f)
+	return loadReader(f, filepath.Dir(file))
 }
 
 // LoadReader config via io.Reader.
+// Included files are resolved against the working directory.
 func LoadReader(fd io.Reader) (config Project, err error) {
+	return loadReader(fd, "")
+}
+
+// loadReader loads the config, resolving the included files against dir.
+func loadReader(fd io.Reader, dir string) (config Project, err error) {
 	data, err := io.ReadAll(fd)
 	if err != nil {
 		return config, err
@@ -46,6 +53,11 @@ func LoadReader(fd io.Reader) (config Project, err error) {
 		return config, VersionError{versioned.Version}
 	}
 
+	data, err = resolveIncludes(data, dir, 0)
+	if err != nil {
+		return config, err
+	}
+
 	err = yaml.UnmarshalStrict(data, &config)
 	return config, err
 }
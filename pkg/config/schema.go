@@ -0,0 +1,12 @@
+package config
+
+import "github.com/invopop/jsonschema"
+
+// JSONSchema returns the JSON schema of the configuration file, which editors
+// can use to validate and autocomplete it.
+func JSONSchema() *jsonschema.Schema {
+	schema := jsonschema.Reflect(&Project{})
+	schema.Definitions["FileInfo"] = jsonschema.Reflect(&FileInfo{})
+	schema.Description = "goreleaser configuration definition file"
+	return schema
+}
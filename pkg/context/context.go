@@ -10,6 +10,7 @@ import (
 	stdctx "context"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -101,6 +102,7 @@ type Context struct {
 	PartialTarget     string
 	Snapshot          bool
 	FailFast          bool
+	PrintUploadPlan   bool
 	Partial           bool
 	SkipTokenCheck    bool
 	Clean             bool
@@ -110,6 +112,13 @@ type Context struct {
 	Semver            Semver
 	Runtime           Runtime
 	Skips             map[string]bool
+	Summary           *Summary
+	CriticalUploads   *CriticalUploads
+	RetryBudgets      *RetryBudgets
+	UploadLocks       *UploadLocks
+	// PublishTags are the tags to create on the release commit once every
+	// publisher succeeded.
+	PublishTags []string
 }
 
 type Runtime struct {
@@ -138,14 +147,19 @@ func NewWithTimeout(config config.Project, timeout time.Duration) (*Context, std
 
 // Wrap wraps an existing context.
 func Wrap(ctx stdctx.Context, config config.Project) *Context {
+	env := ToEnv(append(os.Environ(), config.Env...))
 	return &Context{
-		Context:     ctx,
-		Config:      config,
-		Env:         ToEnv(append(os.Environ(), config.Env...)),
-		Parallelism: 4,
-		Artifacts:   artifact.New(),
-		Date:        time.Now(),
-		Skips:       map[string]bool{},
+		Context:         ctx,
+		Config:          config,
+		Env:             env,
+		Parallelism:     4,
+		Artifacts:       artifact.New(),
+		Date:            releaseDate(env),
+		Skips:           map[string]bool{},
+		Summary:         &Summary{},
+		CriticalUploads: &CriticalUploads{},
+		RetryBudgets:    &RetryBudgets{},
+		UploadLocks:     &UploadLocks{},
 		Runtime: Runtime{
 			Goos:   runtime.GOOS,
 			Goarch: runtime.GOARCH,
@@ -153,6 +167,19 @@ func Wrap(ctx stdctx.Context, config config.Project) *Context {
 	}
 }
 
+// releaseDate returns the date of the release: the time set in
+// SOURCE_DATE_EPOCH, for reproducible builds, or the current time if it isn't
+// set or isn't a valid unix timestamp.
+//
+// See: https://reproducible-builds.org/specs/source-date-epoch/
+func releaseDate(env Env) time.Time {
+	epoch, err := strconv.ParseInt(env["SOURCE_DATE_EPOCH"], 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(epoch, 0).UTC()
+}
+
 // ToEnv converts a list of strings to an Env (aka a map[string]string).
 func ToEnv(env []string) Env {
 	r := Env{}
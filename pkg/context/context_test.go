@@ -1,6 +1,7 @@
 package context
 
 import (
+	"errors"
 	"runtime"
 	"testing"
 	"time"
@@ -33,8 +34,119 @@ func TestNewWithTimeout(t *testing.T) {
 	require.EqualError(t, ctx.Err(), `context canceled`)
 }
 
+func TestDate(t *testing.T) {
+	t.Run("now", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "")
+		before := time.Now()
+		ctx := New(config.Project{})
+		require.False(t, ctx.Date.Before(before))
+		require.False(t, ctx.Date.After(time.Now()))
+	})
+
+	t.Run("source date epoch", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+		ctx := New(config.Project{})
+		require.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC), ctx.Date)
+	})
+
+	t.Run("source date epoch from config", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "")
+		ctx := New(config.Project{Env: []string{"SOURCE_DATE_EPOCH=1700000000"}})
+		require.Equal(t, int64(1700000000), ctx.Date.Unix())
+	})
+
+	t.Run("invalid source date epoch", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+		before := time.Now()
+		ctx := New(config.Project{})
+		require.False(t, ctx.Date.Before(before))
+	})
+}
+
 func TestToEnv(t *testing.T) {
 	require.Equal(t, Env{"FOO": "BAR"}, ToEnv([]string{"=nope", "FOO=BAR"}))
 	require.Equal(t, Env{"FOO": "BAR"}, ToEnv([]string{"nope", "FOO=BAR"}))
 	require.Equal(t, Env{"FOO": "BAR", "nope": ""}, ToEnv([]string{"nope=", "FOO=BAR"}))
 }
+
+func TestCriticalUploads(t *testing.T) {
+	t.Run("nothing running", func(t *testing.T) {
+		c := &CriticalUploads{}
+		_, running := c.Running()
+		require.False(t, running)
+		require.True(t, c.Wait())
+	})
+
+	t.Run("waits for running uploads", func(t *testing.T) {
+		c := &CriticalUploads{}
+		finish := c.Start(time.Minute)
+		defer c.Start(time.Hour)()
+		grace, running := c.Running()
+		require.True(t, running)
+		require.Equal(t, time.Hour, grace)
+		finish()
+		finish()
+		_, running = c.Running()
+		require.True(t, running)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		c := &CriticalUploads{}
+		finish := c.Start(time.Hour)
+		go finish()
+		require.True(t, c.Wait())
+	})
+
+	t.Run("bounded by the grace period", func(t *testing.T) {
+		c := &CriticalUploads{}
+		defer c.Start(10 * time.Millisecond)()
+		require.False(t, c.Wait())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var c *CriticalUploads
+		defer c.Start(time.Hour)()
+		_, running := c.Running()
+		require.False(t, running)
+		require.True(t, c.Wait())
+	})
+}
+
+func TestRetryBudgets(t *testing.T) {
+	b := &RetryBudgets{}
+	require.True(t, b.Take("a", 2))
+	require.True(t, b.Take("a", 2))
+	require.False(t, b.Take("a", 2))
+	require.True(t, b.Take("b", 1))
+	require.False(t, b.Take("b", 1))
+
+	require.True(t, (*RetryBudgets)(nil).Take("a", 0))
+}
+
+func TestUploadLocks(t *testing.T) {
+	l := &UploadLocks{}
+	var acquired, released int
+	acquire := func() (func(), error) {
+		acquired++
+		return func() { released++ }, nil
+	}
+	require.NoError(t, l.Hold("a", acquire))
+	require.NoError(t, l.Hold("a", acquire))
+	require.NoError(t, l.Hold("b", acquire))
+	require.EqualError(t, l.Hold("c", func() (func(), error) {
+		return nil, errors.New("taken")
+	}), "taken")
+	require.Equal(t, 2, acquired)
+	require.Zero(t, released)
+
+	l.Release()
+	require.Equal(t, 2, released)
+	l.Release()
+	require.Equal(t, 2, released)
+
+	require.NoError(t, l.Hold("a", acquire))
+	require.Equal(t, 3, acquired)
+
+	require.Error(t, (*UploadLocks)(nil).Hold("a", acquire))
+	(*UploadLocks)(nil).Release()
+}
@@ -0,0 +1,77 @@
+package context
+
+import (
+	"sync"
+	"time"
+)
+
+// CriticalUploads keeps track of the uploads of critical artifacts still
+// running, so the release can wait for them before exiting, even when it was
+// canceled or timed out.
+// A nil CriticalUploads, as in a Context not created with New or Wrap, tracks
+// nothing.
+type CriticalUploads struct {
+	lock    sync.Mutex
+	running int
+	grace   time.Duration
+	idle    chan struct{}
+}
+
+// Start marks an upload with the given grace period as running, returning the
+// function that marks it as done.
+func (c *CriticalUploads) Start(grace time.Duration) func() {
+	if c == nil {
+		return func() {}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.running == 0 {
+		c.idle = make(chan struct{})
+		c.grace = 0
+	}
+	c.running++
+	c.grace = max(c.grace, grace)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			c.running--
+			if c.running == 0 {
+				close(c.idle)
+			}
+		})
+	}
+}
+
+// Running tells whether uploads are running, and the longest of their grace
+// periods.
+func (c *CriticalUploads) Running() (time.Duration, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.grace, c.running > 0
+}
+
+// Wait waits for the running uploads to finish, for at most their longest
+// grace period, and tells whether they did.
+func (c *CriticalUploads) Wait() bool {
+	if c == nil {
+		return true
+	}
+	c.lock.Lock()
+	if c.running == 0 {
+		c.lock.Unlock()
+		return true
+	}
+	idle, grace := c.idle, c.grace
+	c.lock.Unlock()
+	select {
+	case <-idle:
+		return true
+	case <-time.After(grace):
+		return false
+	}
+}
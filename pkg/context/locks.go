@@ -0,0 +1,48 @@
+package context
+
+import (
+	"errors"
+	"sync"
+)
+
+// UploadLocks keeps the locks held on upload instances, so each lock is
+// acquired once per release and held until publishing is done.
+type UploadLocks struct {
+	lock sync.Mutex
+	held map[string]func()
+}
+
+// Hold acquires the given lock with acquire, unless it is held already,
+// keeping the function that releases it until Release is called.
+func (l *UploadLocks) Hold(key string, acquire func() (func(), error)) error {
+	if l == nil {
+		return errors.New("upload locks are not tracked by this context")
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, ok := l.held[key]; ok {
+		return nil
+	}
+	release, err := acquire()
+	if err != nil {
+		return err
+	}
+	if l.held == nil {
+		l.held = map[string]func(){}
+	}
+	l.held[key] = release
+	return nil
+}
+
+// Release releases all the held locks.
+func (l *UploadLocks) Release() {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for key, release := range l.held {
+		release()
+		delete(l.held, key)
+	}
+}
@@ -0,0 +1,29 @@
+package context
+
+import "sync"
+
+// RetryBudgets keeps track of the retries used by each upload instance, so
+// all the requests made to an instance during the release share its budget.
+type RetryBudgets struct {
+	lock sync.Mutex
+	used map[string]int
+}
+
+// Take uses one of the limit retries of the given instance, returning false
+// if they are all used already.
+// A nil RetryBudgets does not limit retries.
+func (b *RetryBudgets) Take(instance string, limit int) bool {
+	if b == nil {
+		return true
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.used == nil {
+		b.used = map[string]int{}
+	}
+	if b.used[instance] >= limit {
+		return false
+	}
+	b.used[instance]++
+	return true
+}
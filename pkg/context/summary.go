@@ -0,0 +1,74 @@
+package context
+
+import "sync"
+
+// PipeStatus is the outcome of a pipe.
+type PipeStatus string
+
+const (
+	PipeRan     PipeStatus = "ran"
+	PipeSkipped PipeStatus = "skipped"
+	PipeFailed  PipeStatus = "failed"
+)
+
+// SummaryEntry is what happened to a single pipe.
+type SummaryEntry struct {
+	Pipe   string
+	Status PipeStatus
+	Reason string
+	Depth  int
+}
+
+// Summary keeps track of what happened to each pipe during a run, in the
+// order they were started.
+// Pipes can be nested, e.g. the publishers inside the publishing pipe.
+type Summary struct {
+	lock    sync.Mutex
+	entries []SummaryEntry
+	open    []int
+}
+
+// Begin starts tracking the given pipe.
+func (s *Summary) Begin(pipe string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = append(s.entries, SummaryEntry{Pipe: pipe, Depth: len(s.open)})
+	s.open = append(s.open, len(s.entries)-1)
+}
+
+// Record sets the outcome of the innermost pipe being tracked.
+func (s *Summary) Record(status PipeStatus, reason string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.open) == 0 {
+		return
+	}
+	entry := &s.entries[s.open[len(s.open)-1]]
+	entry.Status = status
+	entry.Reason = reason
+}
+
+// End stops tracking the innermost pipe.
+// If no outcome was recorded, the pipe was skipped before it ran.
+func (s *Summary) End() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.open) == 0 {
+		return
+	}
+	entry := &s.entries[s.open[len(s.open)-1]]
+	if entry.Status == "" {
+		entry.Status = PipeSkipped
+		entry.Reason = "not configured or disabled"
+	}
+	s.open = s.open[:len(s.open)-1]
+}
+
+// Entries returns what happened to each pipe so far.
+func (s *Summary) Entries() []SummaryEntry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make([]SummaryEntry, len(s.entries))
+	copy(result, s.entries)
+	return result
+}
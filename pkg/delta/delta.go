@@ -0,0 +1,153 @@
+// Package delta computes and applies binary patches between two versions of
+// a file.
+//
+// Patches are made of instructions that either copy a range of the old file
+// or insert literal bytes, which works well for binaries that change little
+// between releases.
+//
+// Updaters can apply the patches uploaded by goreleaser with Patch, or
+// implement the format themselves:
+//
+//	patch       = "GRDELTA1" *instruction
+//	instruction = copy / insert
+//	copy        = "C" uvarint(offset) uvarint(length)
+//	insert      = "I" uvarint(length) bytes
+//
+// where uvarint is an unsigned varint as written by
+// encoding/binary.AppendUvarint.
+// A copy appends length bytes of the old file starting at offset to the
+// output, and an insert appends the bytes that follow it.
+package delta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	magic     = "GRDELTA1"
+	blockSize = 32
+	base      = 16777619
+
+	opCopy   byte = 'C'
+	opInsert byte = 'I'
+)
+
+// ErrInvalidPatch happens when a patch can't be applied.
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// Diff returns a patch that transforms old into updated.
+func Diff(old, updated []byte) []byte {
+	index := map[uint32][]int{}
+	for i := 0; i+blockSize <= len(old); i += blockSize {
+		h := hash(old[i : i+blockSize])
+		index[h] = append(index[h], i)
+	}
+
+	var pow uint32 = 1
+	for i := 0; i < blockSize-1; i++ {
+		pow *= base
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	pending := 0
+	var h uint32
+	rolling := false
+	for i := 0; i+blockSize <= len(updated); {
+		if !rolling {
+			h = hash(updated[i : i+blockSize])
+			rolling = true
+		}
+		if off, ok := lookup(index, old, updated[i:i+blockSize], h); ok {
+			n := blockSize
+			for off+n < len(old) && i+n < len(updated) && old[off+n] == updated[i+n] {
+				n++
+			}
+			writeInsert(&buf, updated[pending:i])
+			writeCopy(&buf, off, n)
+			i += n
+			pending = i
+			rolling = false
+			continue
+		}
+		if i+blockSize < len(updated) {
+			h = (h-uint32(updated[i])*pow)*base + uint32(updated[i+blockSize])
+		}
+		i++
+	}
+	writeInsert(&buf, updated[pending:])
+	return buf.Bytes()
+}
+
+// Patch applies the given patch to old, returning the updated version.
+func Patch(old, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(magic)) {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidPatch)
+	}
+	r := bytes.NewReader(patch[len(magic):])
+	var out bytes.Buffer
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		switch op {
+		case opCopy:
+			off, err1 := binary.ReadUvarint(r)
+			n, err2 := binary.ReadUvarint(r)
+			if err := errors.Join(err1, err2); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+			}
+			if off+n > uint64(len(old)) {
+				return nil, fmt.Errorf("%w: copy out of bounds", ErrInvalidPatch)
+			}
+			out.Write(old[off : off+n])
+		case opInsert:
+			n, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+			}
+			if n > uint64(r.Len()) {
+				return nil, fmt.Errorf("%w: insert out of bounds", ErrInvalidPatch)
+			}
+			data := make([]byte, n)
+			_, _ = r.Read(data)
+			out.Write(data)
+		default:
+			return nil, fmt.Errorf("%w: unknown instruction %q", ErrInvalidPatch, op)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func hash(b []byte) uint32 {
+	var h uint32
+	for _, c := range b {
+		h = h*base + uint32(c)
+	}
+	return h
+}
+
+func lookup(index map[uint32][]int, old, block []byte, h uint32) (int, bool) {
+	for _, off := range index[h] {
+		if bytes.Equal(old[off:off+blockSize], block) {
+			return off, true
+		}
+	}
+	return 0, false
+}
+
+func writeCopy(buf *bytes.Buffer, off, n int) {
+	buf.WriteByte(opCopy)
+	buf.Write(binary.AppendUvarint(nil, uint64(off)))
+	buf.Write(binary.AppendUvarint(nil, uint64(n)))
+}
+
+func writeInsert(buf *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	buf.WriteByte(opInsert)
+	buf.Write(binary.AppendUvarint(nil, uint64(len(data))))
+	buf.Write(data)
+}
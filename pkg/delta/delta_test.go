@@ -0,0 +1,57 @@
+package delta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPatch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	old := make([]byte, 64*1024)
+	_, _ = rnd.Read(old)
+
+	changed := bytes.Clone(old)
+	copy(changed[1000:], []byte("a small change in the middle"))
+	changed = append(changed[:5000], append([]byte("inserted bytes"), changed[5000:]...)...)
+	changed = append(changed[:20000], changed[21000:]...)
+	changed = append(changed, []byte("appended at the end")...)
+
+	for name, tt := range map[string]struct {
+		old, updated []byte
+	}{
+		"changed":   {old, changed},
+		"identical": {old, old},
+		"from nil":  {nil, changed},
+		"to nil":    {old, nil},
+		"tiny":      {[]byte("abc"), []byte("abd")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			patch := Diff(tt.old, tt.updated)
+			got, err := Patch(tt.old, patch)
+			require.NoError(t, err)
+			require.Equal(t, len(tt.updated), len(got))
+			require.True(t, bytes.Equal(tt.updated, got))
+		})
+	}
+
+	t.Run("small patch", func(t *testing.T) {
+		require.Less(t, len(Diff(old, changed)), len(changed)/50)
+	})
+}
+
+func TestPatchInvalid(t *testing.T) {
+	for name, patch := range map[string][]byte{
+		"header":      []byte("nope"),
+		"instruction": []byte(magic + "X"),
+		"copy bounds": append([]byte(magic+"C"), 0, 10),
+		"insert":      append([]byte(magic+"I"), 10, 'a'),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Patch([]byte("abc"), patch)
+			require.ErrorIs(t, err, ErrInvalidPatch)
+		})
+	}
+}